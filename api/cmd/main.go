@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"time"
 
 	"github.com/app/venside/cmd/server"
 	"github.com/app/venside/config"
 	"github.com/app/venside/database"
+	"github.com/app/venside/internal/features/account/statistics"
+	"github.com/app/venside/internal/features/application/products"
 	"github.com/app/venside/pkg/cache"
 	"github.com/app/venside/pkg/logger"
 	"github.com/app/venside/pkg/seed"
@@ -43,6 +47,12 @@ func main() {
 	redisCache := cache.NewRedisClient(cfg.RedisUrl)
 	defer redisCache.Close()
 
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	statistics.StartSnapshotJob(jobsCtx, db, 24*time.Hour)
+	products.StartLowStockJob(jobsCtx, db, 15*time.Minute)
+
 	// Start server
 	e := server.NewServer(db, redisCache, cfg)
 	logger.Info("Starting server...", logrus.Fields{
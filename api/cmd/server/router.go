@@ -6,6 +6,7 @@ import (
 	"github.com/app/venside/config"
 	"github.com/app/venside/internal/features/account/auth"
 	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/features/account/notifications"
 	"github.com/app/venside/internal/features/account/statistics"
 	"github.com/app/venside/internal/features/application/customers"
 	"github.com/app/venside/internal/features/application/products"
@@ -16,6 +17,7 @@ import (
 	"github.com/app/venside/internal/routes"
 	"github.com/app/venside/pkg/cache"
 	"github.com/app/venside/pkg/cloudflare"
+	"github.com/app/venside/pkg/mailer"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
@@ -41,6 +43,19 @@ func ConfigureRoutes(e *echo.Echo, db *sqlx.DB, cache cache.RedisService, config
 		log.Fatalf("Failed to initialize R2 client: %v", err)
 	}
 
+	mail, err := mailer.New(mailer.Config{
+		Driver:   config.MailDriver,
+		From:     config.MailFrom,
+		Host:     config.SMTPHost,
+		Port:     config.SMTPPort,
+		Username: config.SMTPUsername,
+		Password: config.SMTPPassword,
+		Dir:      config.MailDir,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
 	// Initialize auth routing components
 	authRepo := auth.NewRepository(db)
 	authValidator := auth.NewValidator(db)
@@ -54,6 +69,11 @@ func ConfigureRoutes(e *echo.Echo, db *sqlx.DB, cache cache.RedisService, config
 	inventoryController := inventories.NewController(inventoryRepo, inventoryValidator)
 	routes.InventoryRoutes(e, inventoryController, authService)
 
+	// Notification routes
+	notificationRepo := notifications.NewRepository(db)
+	notificationController := notifications.NewController(notificationRepo)
+	routes.NotificationRoutes(e, notificationController, authService)
+
 	// Warehouse routes
 	warehouseRepo := warehouses.NewRepository(db, cache)
 	warehouseValidator := warehouses.NewValidator(db)
@@ -85,7 +105,7 @@ func ConfigureRoutes(e *echo.Echo, db *sqlx.DB, cache cache.RedisService, config
 
 	// Purchase routes
 	purchaseRepo := purchases.NewRepository(db, cache)
-	purchaseController := purchases.NewController(purchaseRepo)
+	purchaseController := purchases.NewController(purchaseRepo, mail)
 	routes.PurchaseRoutes(e, purchaseController, authService)
 
 	// Statistics routes
@@ -19,6 +19,13 @@ type Variables struct {
 	R2SecretAccessKey string
 	R2BucketName      string
 	R2PublicURL       string
+	MailDriver        string
+	MailFrom          string
+	MailDir           string
+	SMTPHost          string
+	SMTPPort          string
+	SMTPUsername      string
+	SMTPPassword      string
 }
 
 func LoadEnv() *Variables {
@@ -42,10 +49,22 @@ func LoadEnv() *Variables {
 		R2BucketName:      os.Getenv("R2_BUCKET_NAME"),
 		R2PublicURL:       os.Getenv("R2_PUBLIC_URL"),
 
+		MailDriver:   os.Getenv("MAIL_DRIVER"),
+		MailFrom:     os.Getenv("MAIL_FROM"),
+		MailDir:      os.Getenv("MAIL_DIR"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     os.Getenv("SMTP_PORT"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
 		Domain:      os.Getenv("DOMAIN"),
 		Port:        os.Getenv("PORT"),
 		Environment: env,
 	}
 
+	if config.MailFrom == "" {
+		config.MailFrom = "no-reply@" + config.Domain
+	}
+
 	return config
 }
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sale_items
+    ADD COLUMN IF NOT EXISTS warehouse_id UUID,
+    ADD CONSTRAINT fk_sale_items_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sale_items_sale_id ON sale_items (sale_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sale_items_sale_id;
+
+ALTER TABLE sale_items
+    DROP CONSTRAINT IF EXISTS fk_sale_items_warehouse,
+    DROP COLUMN IF EXISTS warehouse_id;
+-- +goose StatementEnd
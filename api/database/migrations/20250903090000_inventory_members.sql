@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS inventory_members (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'manager', 'clerk', 'viewer')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active')),
+    invited_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (inventory_id, user_id),
+    CONSTRAINT fk_inventory_members_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_inventory_members_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_inventory_members_invited_by FOREIGN KEY (invited_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_members_user_id ON inventory_members (user_id);
+
+-- Existing inventory owners become active owner members
+INSERT INTO inventory_members (id, inventory_id, user_id, role, status, created_at, updated_at)
+SELECT gen_random_uuid(), id, user_id, 'owner', 'active', created_at, updated_at
+FROM inventories
+ON CONFLICT (inventory_id, user_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS inventory_members;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Weighted search document of a product. Indexes built on it are maintained by
+-- Postgres on every insert and update, so nothing has to keep them in sync.
+CREATE OR REPLACE FUNCTION product_search_vector(
+    name TEXT, code TEXT, sku TEXT, brand TEXT, model TEXT, description TEXT
+) RETURNS tsvector
+LANGUAGE SQL IMMUTABLE PARALLEL SAFE AS $$
+    SELECT setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+           setweight(to_tsvector('simple', coalesce(code, '') || ' ' || coalesce(sku, '')), 'B') ||
+           setweight(to_tsvector('simple', coalesce(brand, '') || ' ' || coalesce(model, '')), 'C') ||
+           setweight(to_tsvector('simple', coalesce(description, '')), 'D')
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_products_search ON products
+    USING GIN (product_search_vector(name, code, sku, brand, model, description));
+
+-- Trigram indexes catch typos the full-text match misses
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_sku_trgm ON products USING GIN (sku gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_code_trgm ON products USING GIN (code gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_products_code_trgm;
+DROP INDEX IF EXISTS idx_products_sku_trgm;
+DROP INDEX IF EXISTS idx_products_name_trgm;
+DROP INDEX IF EXISTS idx_products_search;
+DROP FUNCTION IF EXISTS product_search_vector(TEXT, TEXT, TEXT, TEXT, TEXT, TEXT);
+-- +goose StatementEnd
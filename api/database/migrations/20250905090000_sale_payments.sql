@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sale_payments (
+    id UUID PRIMARY KEY,
+    sale_id UUID NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    method VARCHAR(20) NOT NULL,
+    reference VARCHAR(100),
+    payment_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_sale_payments_sale FOREIGN KEY (sale_id) REFERENCES sales (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_payments_sale_id ON sale_payments (sale_id);
+
+-- Existing balances are whatever the client sent; record the paid part as a
+-- single opening payment so the ledger and the sales agree.
+INSERT INTO sale_payments (id, sale_id, amount, method, reference, payment_date, created_at)
+SELECT gen_random_uuid(), id, total_amount - balance, 'cash', 'Opening balance', sale_date, created_at
+FROM sales
+WHERE total_amount > balance AND balance >= 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sale_payments_sale_id;
+
+DROP TABLE IF EXISTS sale_payments CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sale_items
+    ADD COLUMN IF NOT EXISTS discount_amount INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount_percent INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE purchase_items
+    ADD COLUMN IF NOT EXISTS discount_amount INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount_percent INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE purchase_items
+    DROP COLUMN IF EXISTS discount_percent,
+    DROP COLUMN IF EXISTS discount_amount;
+
+ALTER TABLE sale_items
+    DROP COLUMN IF EXISTS discount_percent,
+    DROP COLUMN IF EXISTS discount_amount;
+-- +goose StatementEnd
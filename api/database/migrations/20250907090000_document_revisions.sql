@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS document_revisions (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    document_type VARCHAR(20) NOT NULL,
+    document_id UUID NOT NULL,
+    revision INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    edited_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_document_revisions_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_document_revisions_user FOREIGN KEY (edited_by) REFERENCES users (id) ON DELETE SET NULL,
+    CONSTRAINT uq_document_revisions UNIQUE (document_type, document_id, revision)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS document_revisions CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS document_sequences (
+    inventory_id UUID NOT NULL,
+    document_type VARCHAR(20) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    template VARCHAR(100) NOT NULL DEFAULT '{PREFIX}-{YYMMDD}-{SEQ:4}',
+    last_value INTEGER NOT NULL DEFAULT 0,
+    period_key VARCHAR(150) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (inventory_id, document_type),
+    CONSTRAINT fk_document_sequences_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE
+);
+
+-- Numbers used to be global per day, so an inventory may hold duplicates.
+-- Suffix all but the oldest so the unique indexes can be built.
+UPDATE sales s SET sale_number = s.sale_number || '-' || d.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY inventory_id, sale_number ORDER BY created_at, id) AS rn
+    FROM sales
+) d
+WHERE s.id = d.id AND d.rn > 1;
+
+UPDATE purchases p SET purchase_number = p.purchase_number || '-' || d.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY inventory_id, purchase_number ORDER BY created_at, id) AS rn
+    FROM purchases
+) d
+WHERE p.id = d.id AND d.rn > 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_sales_inventory_number ON sales (inventory_id, sale_number);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_purchases_inventory_number ON purchases (inventory_id, purchase_number);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS uq_purchases_inventory_number;
+DROP INDEX IF EXISTS uq_sales_inventory_number;
+
+DROP TABLE IF EXISTS document_sequences CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS stock_snapshots (
+    snapshot_date DATE NOT NULL,
+    inventory_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    warehouse_id UUID NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 0,
+    value BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (snapshot_date, product_id, warehouse_id),
+    CONSTRAINT fk_stock_snapshots_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stock_snapshots_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_stock_snapshots_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_snapshots_inventory ON stock_snapshots (inventory_id, snapshot_date);
+CREATE INDEX IF NOT EXISTS idx_stock_snapshots_warehouse ON stock_snapshots (warehouse_id, snapshot_date);
+
+-- Seed the first snapshot so the trend has a starting point
+INSERT INTO stock_snapshots (snapshot_date, inventory_id, product_id, warehouse_id, quantity, value)
+SELECT CURRENT_DATE, p.inventory_id, wpl.product_id, wpl.warehouse_id,
+       wpl.quantity_in_stock, wpl.quantity_in_stock::BIGINT * p.cost_price
+FROM warehouse_product_link wpl
+JOIN products p ON p.id = wpl.product_id
+ON CONFLICT (snapshot_date, product_id, warehouse_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_stock_snapshots_warehouse;
+DROP INDEX IF EXISTS idx_stock_snapshots_inventory;
+
+DROP TABLE IF EXISTS stock_snapshots CASCADE;
+-- +goose StatementEnd
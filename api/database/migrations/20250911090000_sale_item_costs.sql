@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sale_items ADD COLUMN IF NOT EXISTS unit_cost INTEGER NOT NULL DEFAULT 0;
+
+-- Earlier sales never captured a cost, so the current cost price is the best estimate
+UPDATE sale_items si
+SET unit_cost = p.cost_price
+FROM products p
+WHERE p.id = si.product_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sale_items DROP COLUMN IF EXISTS unit_cost;
+-- +goose StatementEnd
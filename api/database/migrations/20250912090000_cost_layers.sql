@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE inventories ADD COLUMN IF NOT EXISTS valuation_method VARCHAR(20) NOT NULL DEFAULT 'weighted_average';
+
+CREATE TABLE IF NOT EXISTS cost_layers (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    document_id UUID,
+    quantity INTEGER NOT NULL,
+    remaining_quantity INTEGER NOT NULL,
+    unit_cost INTEGER NOT NULL DEFAULT 0,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_cost_layers_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_cost_layers_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS cost_consumptions (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    layer_id UUID,
+    document_id UUID,
+    quantity INTEGER NOT NULL,
+    total_cost BIGINT NOT NULL DEFAULT 0,
+    consumed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_cost_consumptions_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_cost_consumptions_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_cost_consumptions_layer FOREIGN KEY (layer_id) REFERENCES cost_layers (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_layers_product ON cost_layers (product_id, received_at);
+CREATE INDEX IF NOT EXISTS idx_cost_layers_inventory ON cost_layers (inventory_id, received_at);
+CREATE INDEX IF NOT EXISTS idx_cost_consumptions_product ON cost_consumptions (product_id, consumed_at);
+CREATE INDEX IF NOT EXISTS idx_cost_consumptions_inventory ON cost_consumptions (inventory_id, consumed_at);
+CREATE INDEX IF NOT EXISTS idx_cost_consumptions_document ON cost_consumptions (document_id);
+
+-- Stock owned before layers existed opens at the product's cost price
+INSERT INTO cost_layers (id, inventory_id, product_id, source, quantity, remaining_quantity, unit_cost, received_at)
+SELECT gen_random_uuid(), inventory_id, id, 'opening', total_quantity, total_quantity, cost_price, created_at
+FROM products
+WHERE total_quantity > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_cost_consumptions_document;
+DROP INDEX IF EXISTS idx_cost_consumptions_inventory;
+DROP INDEX IF EXISTS idx_cost_consumptions_product;
+DROP INDEX IF EXISTS idx_cost_layers_inventory;
+DROP INDEX IF EXISTS idx_cost_layers_product;
+
+DROP TABLE IF EXISTS cost_consumptions CASCADE;
+DROP TABLE IF EXISTS cost_layers CASCADE;
+
+ALTER TABLE inventories DROP COLUMN IF EXISTS valuation_method;
+-- +goose StatementEnd
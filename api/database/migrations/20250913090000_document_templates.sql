@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS document_templates (
+    inventory_id UUID NOT NULL,
+    layout VARCHAR(20) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    header TEXT NOT NULL DEFAULT '',
+    footer TEXT NOT NULL DEFAULT '',
+    paper_size VARCHAR(10) NOT NULL,
+    show_payments BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (inventory_id, layout),
+    CONSTRAINT fk_document_templates_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS document_templates CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    product_id UUID,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_notifications_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_notifications_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE SET NULL
+);
+
+-- Products currently at or below their restock level that were already
+-- notified. A row is removed once the product is restocked, so the next drop
+-- notifies again.
+CREATE TABLE IF NOT EXISTS low_stock_alerts (
+    product_id UUID PRIMARY KEY,
+    inventory_id UUID NOT NULL,
+    notified_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_low_stock_alerts_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_low_stock_alerts_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_inventory ON notifications (inventory_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications (inventory_id) WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_products_restock ON products (inventory_id) WHERE restock_level > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_products_restock;
+DROP INDEX IF EXISTS idx_notifications_unread;
+DROP INDEX IF EXISTS idx_notifications_inventory;
+
+DROP TABLE IF EXISTS low_stock_alerts CASCADE;
+DROP TABLE IF EXISTS notifications CASCADE;
+-- +goose StatementEnd
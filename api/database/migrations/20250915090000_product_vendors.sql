@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS product_vendors (
+    product_id UUID NOT NULL,
+    vendor_id UUID NOT NULL,
+    inventory_id UUID NOT NULL,
+    vendor_sku VARCHAR(100) NOT NULL DEFAULT '',
+    lead_time_days INTEGER NOT NULL DEFAULT 0,
+    last_cost INTEGER,
+    is_preferred BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, vendor_id),
+    CONSTRAINT fk_product_vendors_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    CONSTRAINT fk_product_vendors_vendor FOREIGN KEY (vendor_id) REFERENCES vendors (id) ON DELETE CASCADE,
+    CONSTRAINT fk_product_vendors_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE
+);
+
+-- A product has at most one preferred vendor
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_vendors_preferred ON product_vendors (product_id) WHERE is_preferred;
+CREATE INDEX IF NOT EXISTS idx_product_vendors_vendor ON product_vendors (vendor_id);
+
+-- Link products to the vendors they were bought from, at the last unit cost paid
+INSERT INTO product_vendors (product_id, vendor_id, inventory_id, last_cost)
+SELECT DISTINCT ON (pi.product_id, pu.vendor_id)
+       pi.product_id, pu.vendor_id, pu.inventory_id, pi.subtotal / NULLIF(pi.quantity, 0)
+FROM purchase_items pi
+JOIN purchases pu ON pu.id = pi.purchase_id
+WHERE pu.vendor_id IS NOT NULL AND pu.purchase_status <> 'cancelled'
+ORDER BY pi.product_id, pu.vendor_id, pu.purchase_date DESC, pu.created_at DESC
+ON CONFLICT (product_id, vendor_id) DO NOTHING;
+
+-- and prefer the vendor each product was last bought from
+UPDATE product_vendors pv SET is_preferred = TRUE
+FROM (
+    SELECT DISTINCT ON (pi.product_id) pi.product_id, pu.vendor_id
+    FROM purchase_items pi
+    JOIN purchases pu ON pu.id = pi.purchase_id
+    WHERE pu.vendor_id IS NOT NULL AND pu.purchase_status <> 'cancelled'
+    ORDER BY pi.product_id, pu.purchase_date DESC, pu.created_at DESC
+) latest
+WHERE pv.product_id = latest.product_id AND pv.vendor_id = latest.vendor_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_product_vendors_vendor;
+DROP INDEX IF EXISTS idx_product_vendors_preferred;
+
+DROP TABLE IF EXISTS product_vendors CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sale_returns (
+    id UUID PRIMARY KEY,
+    return_number VARCHAR(50) NOT NULL,
+    sale_id UUID NOT NULL,
+    inventory_id UUID NOT NULL,
+    warehouse_id UUID,
+    reason TEXT NOT NULL,
+    total_amount INTEGER NOT NULL DEFAULT 0,
+    refund_amount INTEGER NOT NULL DEFAULT 0 CHECK (refund_amount >= 0),
+    refund_method VARCHAR(20),
+    return_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    returned_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_sale_returns_sale FOREIGN KEY (sale_id) REFERENCES sales (id) ON DELETE CASCADE,
+    CONSTRAINT fk_sale_returns_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_sale_returns_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses (id) ON DELETE SET NULL,
+    CONSTRAINT uq_sale_returns_number UNIQUE (inventory_id, return_number)
+);
+
+CREATE TABLE IF NOT EXISTS sale_return_items (
+    id UUID PRIMARY KEY,
+    return_id UUID NOT NULL,
+    sale_item_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    condition VARCHAR(20) NOT NULL,
+    unit_price INTEGER NOT NULL DEFAULT 0,
+    unit_cost INTEGER NOT NULL DEFAULT 0,
+    amount INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_sale_return_items_return FOREIGN KEY (return_id) REFERENCES sale_returns (id) ON DELETE CASCADE,
+    CONSTRAINT fk_sale_return_items_sale_item FOREIGN KEY (sale_item_id) REFERENCES sale_items (id) ON DELETE CASCADE,
+    CONSTRAINT fk_sale_return_items_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_returns_sale_id ON sale_returns (sale_id);
+CREATE INDEX IF NOT EXISTS idx_sale_returns_inventory_date ON sale_returns (inventory_id, return_date);
+CREATE INDEX IF NOT EXISTS idx_sale_return_items_return_id ON sale_return_items (return_id);
+CREATE INDEX IF NOT EXISTS idx_sale_return_items_sale_item_id ON sale_return_items (sale_item_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sale_return_items_sale_item_id;
+DROP INDEX IF EXISTS idx_sale_return_items_return_id;
+DROP INDEX IF EXISTS idx_sale_returns_inventory_date;
+DROP INDEX IF EXISTS idx_sale_returns_sale_id;
+
+DROP TABLE IF EXISTS sale_return_items CASCADE;
+DROP TABLE IF EXISTS sale_returns CASCADE;
+
+DELETE FROM document_sequences WHERE document_type = 'sale_return';
+-- +goose StatementEnd
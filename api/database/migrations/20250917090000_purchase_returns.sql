@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+-- What is still owed to the vendor on each purchase
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS balance INTEGER NOT NULL DEFAULT 0;
+
+UPDATE purchases SET balance = total_amount
+WHERE payment_status NOT IN ('paid', 'cancelled') AND purchase_status <> 'cancelled';
+
+CREATE TABLE IF NOT EXISTS purchase_returns (
+    id UUID PRIMARY KEY,
+    return_number VARCHAR(50) NOT NULL,
+    purchase_id UUID NOT NULL,
+    inventory_id UUID NOT NULL,
+    vendor_id UUID,
+    warehouse_id UUID,
+    reason TEXT NOT NULL,
+    credit_amount INTEGER NOT NULL DEFAULT 0,
+    refund_due INTEGER NOT NULL DEFAULT 0 CHECK (refund_due >= 0),
+    return_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    returned_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_purchase_returns_purchase FOREIGN KEY (purchase_id) REFERENCES purchases (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_returns_inventory FOREIGN KEY (inventory_id) REFERENCES inventories (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_returns_vendor FOREIGN KEY (vendor_id) REFERENCES vendors (id) ON DELETE SET NULL,
+    CONSTRAINT fk_purchase_returns_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses (id) ON DELETE SET NULL,
+    CONSTRAINT uq_purchase_returns_number UNIQUE (inventory_id, return_number)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_return_items (
+    id UUID PRIMARY KEY,
+    return_id UUID NOT NULL,
+    purchase_item_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_cost INTEGER NOT NULL DEFAULT 0,
+    amount INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_purchase_return_items_return FOREIGN KEY (return_id) REFERENCES purchase_returns (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_return_items_purchase_item FOREIGN KEY (purchase_item_id) REFERENCES purchase_items (id) ON DELETE CASCADE,
+    CONSTRAINT fk_purchase_return_items_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_returns_purchase_id ON purchase_returns (purchase_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_returns_inventory_date ON purchase_returns (inventory_id, return_date);
+CREATE INDEX IF NOT EXISTS idx_purchase_returns_vendor_id ON purchase_returns (vendor_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_return_items_return_id ON purchase_return_items (return_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_return_items_purchase_item_id ON purchase_return_items (purchase_item_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_purchase_return_items_purchase_item_id;
+DROP INDEX IF EXISTS idx_purchase_return_items_return_id;
+DROP INDEX IF EXISTS idx_purchase_returns_vendor_id;
+DROP INDEX IF EXISTS idx_purchase_returns_inventory_date;
+DROP INDEX IF EXISTS idx_purchase_returns_purchase_id;
+
+DROP TABLE IF EXISTS purchase_return_items CASCADE;
+DROP TABLE IF EXISTS purchase_returns CASCADE;
+
+DELETE FROM document_sequences WHERE document_type = 'purchase_return';
+
+ALTER TABLE purchases DROP COLUMN IF EXISTS balance;
+-- +goose StatementEnd
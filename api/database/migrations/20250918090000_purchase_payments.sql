@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS due_date DATE;
+
+CREATE TABLE IF NOT EXISTS purchase_payments (
+    id UUID PRIMARY KEY,
+    purchase_id UUID NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    method VARCHAR(20) NOT NULL,
+    reference VARCHAR(100),
+    payment_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_purchase_payments_purchase FOREIGN KEY (purchase_id) REFERENCES purchases (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_payments_purchase_id ON purchase_payments (purchase_id);
+CREATE INDEX IF NOT EXISTS idx_purchases_vendor_id ON purchases (vendor_id);
+
+-- Purchases marked paid have nothing behind the status; record their total as
+-- a single opening payment so the ledger and the balances agree.
+INSERT INTO purchase_payments (id, purchase_id, amount, method, reference, payment_date, created_at)
+SELECT gen_random_uuid(), id, total_amount, 'transfer', 'Opening balance', purchase_date, created_at
+FROM purchases
+WHERE payment_status = 'paid' AND purchase_status <> 'cancelled' AND total_amount > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_purchases_vendor_id;
+DROP INDEX IF EXISTS idx_purchase_payments_purchase_id;
+
+DROP TABLE IF EXISTS purchase_payments CASCADE;
+
+ALTER TABLE purchases DROP COLUMN IF EXISTS due_date;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- How much a customer may owe across their open sales. NULL means no limit.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS credit_limit INTEGER CHECK (credit_limit >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_sales_customer_id ON sales (customer_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sales_customer_id;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS credit_limit;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The standard cost in force when a layer opened, so standard cost valuation
+-- of a past date does not move when the cost price changes later
+ALTER TABLE cost_layers ADD COLUMN IF NOT EXISTS standard_cost INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE cost_consumptions ADD COLUMN IF NOT EXISTS standard_cost BIGINT NOT NULL DEFAULT 0;
+
+UPDATE cost_layers cl
+SET standard_cost = p.cost_price
+FROM products p
+WHERE p.id = cl.product_id;
+
+UPDATE cost_consumptions cc
+SET standard_cost = cc.quantity::BIGINT * COALESCE(
+    (SELECT standard_cost FROM cost_layers WHERE id = cc.layer_id),
+    (SELECT cost_price FROM products WHERE id = cc.product_id),
+    0
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE cost_consumptions DROP COLUMN IF EXISTS standard_cost;
+ALTER TABLE cost_layers DROP COLUMN IF EXISTS standard_cost;
+-- +goose StatementEnd
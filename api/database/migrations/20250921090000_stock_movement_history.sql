@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The ledger outlives the products and warehouses it names, so their names
+-- are kept on every movement and the references are cleared on delete
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS product_name VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS warehouse_name VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE stock_movements sm
+SET product_name = p.name
+FROM products p
+WHERE p.id = sm.product_id;
+
+UPDATE stock_movements sm
+SET warehouse_name = w.name
+FROM warehouses w
+WHERE w.id = sm.warehouse_id;
+
+ALTER TABLE stock_movements ALTER COLUMN product_id DROP NOT NULL;
+ALTER TABLE stock_movements ALTER COLUMN warehouse_id DROP NOT NULL;
+
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS fk_stock_movements_product;
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS fk_stock_movements_warehouse;
+ALTER TABLE stock_movements
+    ADD CONSTRAINT fk_stock_movements_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_stock_movements_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses (id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM stock_movements WHERE product_id IS NULL OR warehouse_id IS NULL;
+
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS fk_stock_movements_product;
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS fk_stock_movements_warehouse;
+ALTER TABLE stock_movements
+    ADD CONSTRAINT fk_stock_movements_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_stock_movements_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses (id) ON DELETE CASCADE;
+
+ALTER TABLE stock_movements ALTER COLUMN product_id SET NOT NULL;
+ALTER TABLE stock_movements ALTER COLUMN warehouse_id SET NOT NULL;
+
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS warehouse_name;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS product_name;
+-- +goose StatementEnd
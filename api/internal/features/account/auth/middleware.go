@@ -6,6 +6,7 @@ import (
 	"github.com/app/venside/internal/models"
 	"github.com/app/venside/pkg/errors"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -33,6 +34,32 @@ func AuthMiddleware(service AuthService) echo.MiddlewareFunc {
 	}
 }
 
+// InventoryMiddleware scopes a request to the :inventoryId route parameter. It must
+// run after AuthMiddleware and rejects inventories the user is not an active member of.
+func InventoryMiddleware(service AuthService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			user, ok := ctx.Get("user").(*models.User)
+			if !ok {
+				return errors.UnauthorizedError("User not found in context")
+			}
+
+			inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+			if err != nil {
+				return errors.ValidationError("Invalid inventory ID")
+			}
+
+			member, err := service.GetInventoryMember(user.ID, inventoryID)
+			if err != nil {
+				return err
+			}
+
+			ctx.Set("member", member)
+			return next(ctx)
+		}
+	}
+}
+
 func CSRFMiddleware(service AuthService) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
@@ -53,6 +80,9 @@ func CSRFMiddleware(service AuthService) echo.MiddlewareFunc {
 	}
 }
 
+// RoleMiddleware allows the request through when the caller holds one of the given
+// roles. On inventory-scoped routes the member's per-inventory role is checked,
+// elsewhere the global user role is used.
 func RoleMiddleware(roles ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
@@ -61,8 +91,13 @@ func RoleMiddleware(roles ...string) echo.MiddlewareFunc {
 				return errors.UnauthorizedError("User not found in context")
 			}
 
+			current := user.Role
+			if member, ok := ctx.Get("member").(*models.InventoryMember); ok {
+				current = member.Role
+			}
+
 			for _, role := range roles {
-				if user.Role == role {
+				if current == role {
 					return next(ctx)
 				}
 			}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"database/sql"
+
 	"github.com/app/venside/internal/models"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
@@ -12,6 +14,7 @@ type AuthRepository interface {
 	GetUserByEmail(email string) (models.User, error)
 	GetUserByID(id uuid.UUID) (models.User, error)
 	GetUserInventories(userID uuid.UUID) ([]models.Inventory, error)
+	GetInventoryMember(userID, inventoryID uuid.UUID) (models.InventoryMember, error)
 }
 
 type Repository struct {
@@ -60,7 +63,10 @@ func (r *Repository) GetUserByID(id uuid.UUID) (models.User, error) {
 
 func (r *Repository) GetUserInventories(userID uuid.UUID) ([]models.Inventory, error) {
 	var inventories []models.Inventory
-	query := `SELECT * FROM inventories WHERE user_id = $1`
+	query := `SELECT i.* FROM inventories i
+              JOIN inventory_members m ON m.inventory_id = i.id
+              WHERE m.user_id = $1 AND m.status = 'active'
+              ORDER BY i.created_at ASC`
 
 	err := r.db.Select(&inventories, query, userID)
 	if err != nil {
@@ -69,3 +75,21 @@ func (r *Repository) GetUserInventories(userID uuid.UUID) ([]models.Inventory, e
 
 	return inventories, nil
 }
+
+func (r *Repository) GetInventoryMember(userID, inventoryID uuid.UUID) (models.InventoryMember, error) {
+	var member models.InventoryMember
+	query := `SELECT m.*, u.username, u.email
+              FROM inventory_members m
+              JOIN users u ON u.id = m.user_id
+              WHERE m.user_id = $1 AND m.inventory_id = $2 AND m.status = 'active'`
+
+	err := r.db.Get(&member, query, userID, inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return member, errors.NotFoundError("Inventory not found")
+		}
+		return member, errors.DatabaseError(err, "Get Inventory Member")
+	}
+
+	return member, nil
+}
@@ -20,6 +20,7 @@ type AuthService interface {
 	LoginUser(req *models.LoginRequest) (*models.AuthResponse, error)
 	ValidateToken(tokenString string) (*jwt.Token, error)
 	GetUserFromToken(tokenString string) (*models.User, error)
+	GetInventoryMember(userID, inventoryID uuid.UUID) (*models.InventoryMember, error)
 	GenerateCSRFToken() string
 	ValidateCSRFToken(token string) bool
 }
@@ -127,6 +128,17 @@ func (s *Service) GetUserFromToken(tokenString string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetInventoryMember loads the user's active membership of an inventory. Inventories
+// the user is not a member of are reported as not found so their existence is not leaked.
+func (s *Service) GetInventoryMember(userID, inventoryID uuid.UUID) (*models.InventoryMember, error) {
+	member, err := s.repo.GetInventoryMember(userID, inventoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
 // Helper methods
 func (s *Service) GenerateCSRFToken() string {
 	bytes := make([]byte, 32)
@@ -2,6 +2,8 @@ package inventories
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/app/venside/internal/mapper"
 	"github.com/app/venside/internal/models"
@@ -53,7 +55,7 @@ func (c *Controller) ListInventories(ctx echo.Context) error {
 }
 
 func (c *Controller) GetInventory(ctx echo.Context) error {
-	inventoryID, err := uuid.Parse(ctx.Param("id"))
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
 	if err != nil {
 		return errors.ValidationError("Invalid inventory ID")
 	}
@@ -96,7 +98,7 @@ func (c *Controller) CreateInventory(ctx echo.Context) error {
 }
 
 func (c *Controller) UpdateInventory(ctx echo.Context) error {
-	inventoryID, err := uuid.Parse(ctx.Param("id"))
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
 	if err != nil {
 		return errors.ValidationError("Invalid inventory ID")
 	}
@@ -129,7 +131,7 @@ func (c *Controller) UpdateInventory(ctx echo.Context) error {
 }
 
 func (c *Controller) DeleteInventory(ctx echo.Context) error {
-	inventoryID, err := uuid.Parse(ctx.Param("id"))
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
 	if err != nil {
 		return errors.ValidationError("Invalid inventory ID")
 	}
@@ -143,3 +145,251 @@ func (c *Controller) DeleteInventory(ctx echo.Context) error {
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+// Member handlers
+
+func (c *Controller) ListMembers(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	members, err := c.repo.ListMembers(inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch inventory members", err, logrus.Fields{
+			"inventory_id": inventoryID,
+		})
+	}
+
+	response := make([]*models.InventoryMemberResponse, len(members))
+	for i := range members {
+		response[i] = mapper.ToInventoryMemberResponse(&members[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) InviteMember(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	actor, ok := ctx.Get("member").(*models.InventoryMember)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	var req models.InviteMemberRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	if req.Role == models.RoleManager && actor.Role != models.RoleOwner {
+		return errors.ForbiddenError("Only the owner can invite managers")
+	}
+
+	member, err := c.repo.InviteMember(inventoryID, strings.ToLower(strings.TrimSpace(req.Email)), req.Role, actor.UserID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to invite member", err, logrus.Fields{
+			"inventory_id": inventoryID,
+		})
+	}
+
+	response := mapper.ToInventoryMemberResponse(member)
+	return ctx.JSON(http.StatusCreated, response)
+}
+
+func (c *Controller) AcceptInvitation(ctx echo.Context) error {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	if err := c.repo.AcceptInvitation(inventoryID, user.ID); err != nil {
+		return logger.Error(ctx, "Failed to accept invitation", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"user_id":      user.ID,
+		})
+	}
+
+	inventory, err := c.repo.GetInventory(inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to retrieve inventory", err, logrus.Fields{
+			"inventory_id": inventoryID,
+		})
+	}
+
+	response := mapper.ToInventoryResponse(inventory, inventory.Currency)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) RemoveMember(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	memberID, err := uuid.Parse(ctx.Param("memberId"))
+	if err != nil {
+		return errors.ValidationError("Invalid member ID")
+	}
+
+	actor, ok := ctx.Get("member").(*models.InventoryMember)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	member, err := c.repo.GetMember(memberID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Member not found", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"member_id":    memberID,
+		})
+	}
+
+	if member.Role == models.RoleOwner {
+		return errors.ForbiddenError("The inventory owner cannot be removed")
+	}
+
+	if member.Role == models.RoleManager && actor.Role != models.RoleOwner {
+		return errors.ForbiddenError("Only the owner can remove managers")
+	}
+
+	if err := c.repo.RemoveMember(memberID, inventoryID); err != nil {
+		return logger.Error(ctx, "Failed to remove member", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"member_id":    memberID,
+		})
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c *Controller) ListDocumentSequences(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	sequences, err := c.repo.ListDocumentSequences(inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch document numbering", err, logrus.Fields{
+			"inventory_id": inventoryID,
+		})
+	}
+
+	response := make([]*models.DocumentSequenceResponse, len(sequences))
+	for i := range sequences {
+		response[i] = mapper.ToDocumentSequenceResponse(&sequences[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) UpdateDocumentSequence(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	documentType := ctx.Param("documentType")
+	if _, ok := models.DefaultDocumentPrefixes[documentType]; !ok {
+		return errors.ValidationError("Invalid document type")
+	}
+
+	var req models.DocumentSequenceRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	if err := utils.ValidateNumberTemplate(req.Template); err != nil {
+		return err
+	}
+
+	sequence := &models.DocumentSequence{
+		InventoryID:  inventoryID,
+		DocumentType: documentType,
+		Prefix:       strings.TrimSpace(req.Prefix),
+		Template:     strings.TrimSpace(req.Template),
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := c.repo.UpdateDocumentSequence(sequence); err != nil {
+		return logger.Error(ctx, "Failed to update document numbering", err, logrus.Fields{
+			"inventory_id":  inventoryID,
+			"document_type": documentType,
+		})
+	}
+
+	response := mapper.ToDocumentSequenceResponse(sequence)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) ListDocumentTemplates(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	templates, err := c.repo.ListDocumentTemplates(inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch document templates", err, logrus.Fields{
+			"inventory_id": inventoryID,
+		})
+	}
+
+	response := make([]*models.DocumentTemplateResponse, len(templates))
+	for i := range templates {
+		response[i] = mapper.ToDocumentTemplateResponse(&templates[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) UpdateDocumentTemplate(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	layout := ctx.Param("layout")
+	if _, ok := models.DefaultDocumentTemplates[layout]; !ok {
+		return errors.ValidationError("Invalid document layout")
+	}
+
+	var req models.DocumentTemplateRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	if err := validatePaperSize(layout, req.PaperSize); err != nil {
+		return err
+	}
+
+	template := &models.DocumentTemplate{
+		InventoryID:  inventoryID,
+		Layout:       layout,
+		Title:        strings.TrimSpace(req.Title),
+		Header:       strings.TrimSpace(req.Header),
+		Footer:       strings.TrimSpace(req.Footer),
+		PaperSize:    req.PaperSize,
+		ShowPayments: req.ShowPayments,
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := c.repo.UpdateDocumentTemplate(template); err != nil {
+		return logger.Error(ctx, "Failed to update document template", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"layout":       layout,
+		})
+	}
+
+	response := mapper.ToDocumentTemplateResponse(template)
+	return ctx.JSON(http.StatusOK, response)
+}
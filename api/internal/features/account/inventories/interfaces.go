@@ -12,6 +12,18 @@ type InventoryRepository interface {
 	CreateInventory(inventory *models.Inventory, currency *models.Currency) error
 	UpdateInventory(inventory *models.Inventory, currency *models.Currency) error
 	DeleteInventory(inventoryId uuid.UUID) error
+
+	ListMembers(inventoryId uuid.UUID) ([]models.InventoryMember, error)
+	GetMember(memberId, inventoryId uuid.UUID) (models.InventoryMember, error)
+	InviteMember(inventoryId uuid.UUID, email, role string, invitedBy uuid.UUID) (*models.InventoryMember, error)
+	AcceptInvitation(inventoryId, userId uuid.UUID) error
+	RemoveMember(memberId, inventoryId uuid.UUID) error
+
+	ListDocumentSequences(inventoryId uuid.UUID) ([]models.DocumentSequence, error)
+	UpdateDocumentSequence(sequence *models.DocumentSequence) error
+
+	ListDocumentTemplates(inventoryId uuid.UUID) ([]models.DocumentTemplate, error)
+	UpdateDocumentTemplate(template *models.DocumentTemplate) error
 }
 
 type InventoryController interface {
@@ -20,4 +32,15 @@ type InventoryController interface {
 	CreateInventory(ctx echo.Context) error
 	UpdateInventory(ctx echo.Context) error
 	DeleteInventory(ctx echo.Context) error
+
+	ListMembers(ctx echo.Context) error
+	InviteMember(ctx echo.Context) error
+	AcceptInvitation(ctx echo.Context) error
+	RemoveMember(ctx echo.Context) error
+
+	ListDocumentSequences(ctx echo.Context) error
+	UpdateDocumentSequence(ctx echo.Context) error
+
+	ListDocumentTemplates(ctx echo.Context) error
+	UpdateDocumentTemplate(ctx echo.Context) error
 }
@@ -0,0 +1,106 @@
+package inventories
+
+import (
+	"time"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// NextDocumentNumber takes the next number of a document type inside the caller's
+// transaction. The sequence row stays locked until the transaction ends, so
+// concurrent documents of the same inventory are numbered one after the other.
+//
+// The counter restarts whenever the number rendered without its sequence changes,
+// which with the default template means every day.
+func NextDocumentNumber(tx *sqlx.Tx, inventoryID uuid.UUID, documentType string, date time.Time) (string, error) {
+	prefix, ok := models.DefaultDocumentPrefixes[documentType]
+	if !ok {
+		return "", errors.ValidationError("Unknown document type")
+	}
+
+	_, err := tx.Exec(
+		`INSERT INTO document_sequences (inventory_id, document_type, prefix, template)
+         VALUES ($1, $2, $3, $4)
+         ON CONFLICT (inventory_id, document_type) DO NOTHING`,
+		inventoryID, documentType, prefix, utils.DefaultNumberTemplate)
+	if err != nil {
+		return "", errors.DatabaseError(err, "Error creating document sequence")
+	}
+
+	var sequence models.DocumentSequence
+	err = tx.Get(&sequence,
+		`SELECT * FROM document_sequences
+         WHERE inventory_id = $1 AND document_type = $2
+         FOR UPDATE`,
+		inventoryID, documentType)
+	if err != nil {
+		return "", errors.DatabaseError(err, "Error locking document sequence")
+	}
+
+	periodKey := utils.FormatDocumentNumber(sequence.Template, sequence.Prefix, date, 0)
+
+	next := sequence.LastValue + 1
+	if periodKey != sequence.PeriodKey {
+		next = 1
+	}
+
+	_, err = tx.Exec(
+		`UPDATE document_sequences
+         SET last_value = $1, period_key = $2, updated_at = $3
+         WHERE inventory_id = $4 AND document_type = $5`,
+		next, periodKey, time.Now(), inventoryID, documentType)
+	if err != nil {
+		return "", errors.DatabaseError(err, "Error advancing document sequence")
+	}
+
+	return utils.FormatDocumentNumber(sequence.Template, sequence.Prefix, date, next), nil
+}
+
+func (r *Repository) ListDocumentSequences(inventoryId uuid.UUID) ([]models.DocumentSequence, error) {
+	var stored []models.DocumentSequence
+	err := r.db.Select(&stored, `SELECT * FROM document_sequences WHERE inventory_id = $1`, inventoryId)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "List Document Sequences")
+	}
+
+	byType := make(map[string]models.DocumentSequence, len(stored))
+	for _, sequence := range stored {
+		byType[sequence.DocumentType] = sequence
+	}
+
+	// Document types that were never numbered show their defaults
+	sequences := make([]models.DocumentSequence, 0, len(models.DefaultDocumentPrefixes))
+	for _, documentType := range []string{models.DocumentTypeSale, models.DocumentTypePurchase, models.DocumentTypeSaleReturn, models.DocumentTypePurchaseReturn} {
+		sequence, ok := byType[documentType]
+		if !ok {
+			sequence = models.DocumentSequence{
+				InventoryID:  inventoryId,
+				DocumentType: documentType,
+				Prefix:       models.DefaultDocumentPrefixes[documentType],
+				Template:     utils.DefaultNumberTemplate,
+			}
+		}
+		sequences = append(sequences, sequence)
+	}
+
+	return sequences, nil
+}
+
+func (r *Repository) UpdateDocumentSequence(sequence *models.DocumentSequence) error {
+	err := r.db.Get(sequence,
+		`INSERT INTO document_sequences (inventory_id, document_type, prefix, template, updated_at)
+         VALUES ($1, $2, $3, $4, $5)
+         ON CONFLICT (inventory_id, document_type)
+         DO UPDATE SET prefix = EXCLUDED.prefix, template = EXCLUDED.template, updated_at = EXCLUDED.updated_at
+         RETURNING *`,
+		sequence.InventoryID, sequence.DocumentType, sequence.Prefix, sequence.Template, sequence.UpdatedAt)
+	if err != nil {
+		return errors.DatabaseError(err, "Update Document Sequence")
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package inventories
 import (
 	"database/sql"
 
+	"github.com/app/venside/internal/mapper"
 	"github.com/app/venside/internal/models"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
@@ -19,7 +20,10 @@ func NewRepository(data *sqlx.DB) InventoryRepository {
 
 func (r *Repository) ListInventories(userId uuid.UUID) ([]models.Inventory, error) {
 	inventories := []models.Inventory{}
-	query := `SELECT * FROM inventories WHERE user_id = $1`
+	query := `SELECT i.* FROM inventories i
+			  JOIN inventory_members m ON m.inventory_id = i.id
+			  WHERE m.user_id = $1 AND m.status = 'active'
+			  ORDER BY i.created_at ASC`
 
 	err := r.db.Select(&inventories, query, userId)
 	if err != nil {
@@ -71,8 +75,8 @@ func (r *Repository) CreateInventory(inventory *models.Inventory, currency *mode
 	defer tx.Rollback()
 
 	// Create inventory
-	inventoryQuery := `INSERT INTO inventories (id, name, user_id, created_at, updated_at) 
-					   VALUES (:id, :name, :user_id, :created_at, :updated_at)`
+	inventoryQuery := `INSERT INTO inventories (id, name, user_id, valuation_method, created_at, updated_at) 
+					   VALUES (:id, :name, :user_id, :valuation_method, :created_at, :updated_at)`
 
 	_, err = tx.NamedExec(inventoryQuery, inventory)
 	if err != nil {
@@ -88,6 +92,16 @@ func (r *Repository) CreateInventory(inventory *models.Inventory, currency *mode
 		return errors.DatabaseError(err, "Create Currency")
 	}
 
+	// The creator becomes the inventory's owner member
+	owner := mapper.ToCreateInventoryMember(inventory.ID, inventory.UserID, models.RoleOwner, nil)
+	memberQuery := `INSERT INTO inventory_members (id, inventory_id, user_id, role, status, invited_by, created_at, updated_at)
+					VALUES (:id, :inventory_id, :user_id, :role, :status, :invited_by, :created_at, :updated_at)`
+
+	_, err = tx.NamedExec(memberQuery, owner)
+	if err != nil {
+		return errors.DatabaseError(err, "Create Owner Member")
+	}
+
 	if err := tx.Commit(); err != nil {
 		return errors.DatabaseError(err, "Commit transaction")
 	}
@@ -105,6 +119,7 @@ func (r *Repository) UpdateInventory(inventory *models.Inventory, currency *mode
 	// Update inventory
 	inventoryQuery := `UPDATE inventories SET 
 						name = :name, 
+						valuation_method = :valuation_method, 
 						updated_at = :updated_at 
 						WHERE id = :id`
 
@@ -158,3 +173,130 @@ func (r *Repository) DeleteInventory(inventoryId uuid.UUID) error {
 
 	return nil
 }
+
+// Member operations
+
+func (r *Repository) ListMembers(inventoryId uuid.UUID) ([]models.InventoryMember, error) {
+	members := []models.InventoryMember{}
+	query := `SELECT m.*, u.username, u.email
+			  FROM inventory_members m
+			  JOIN users u ON u.id = m.user_id
+			  WHERE m.inventory_id = $1
+			  ORDER BY m.created_at ASC`
+
+	err := r.db.Select(&members, query, inventoryId)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "List Members")
+	}
+
+	return members, nil
+}
+
+func (r *Repository) GetMember(memberId, inventoryId uuid.UUID) (models.InventoryMember, error) {
+	var member models.InventoryMember
+	query := `SELECT m.*, u.username, u.email
+			  FROM inventory_members m
+			  JOIN users u ON u.id = m.user_id
+			  WHERE m.id = $1 AND m.inventory_id = $2`
+
+	err := r.db.Get(&member, query, memberId, inventoryId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return member, errors.NotFoundError("Member not found")
+		}
+		return member, errors.DatabaseError(err, "Get Member")
+	}
+
+	return member, nil
+}
+
+func (r *Repository) InviteMember(inventoryId uuid.UUID, email, role string, invitedBy uuid.UUID) (*models.InventoryMember, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Begin transaction")
+	}
+	defer tx.Rollback()
+
+	// Invitations go to registered users only
+	var user models.User
+	err = tx.Get(&user, `SELECT * FROM users WHERE email = $1`, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("User not found")
+		}
+		return nil, errors.DatabaseError(err, "Get User By Email")
+	}
+
+	var exists bool
+	err = tx.Get(&exists,
+		`SELECT EXISTS(SELECT 1 FROM inventory_members WHERE inventory_id = $1 AND user_id = $2)`,
+		inventoryId, user.ID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Check Member")
+	}
+
+	if exists {
+		return nil, errors.ConflictError("User is already a member of this inventory")
+	}
+
+	member := mapper.ToCreateInventoryMember(inventoryId, user.ID, role, &invitedBy)
+	member.Username = user.Username
+	member.Email = user.Email
+
+	query := `INSERT INTO inventory_members (id, inventory_id, user_id, role, status, invited_by, created_at, updated_at)
+			  VALUES (:id, :inventory_id, :user_id, :role, :status, :invited_by, :created_at, :updated_at)`
+
+	_, err = tx.NamedExec(query, member)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Invite Member")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.DatabaseError(err, "Commit transaction")
+	}
+
+	return member, nil
+}
+
+func (r *Repository) AcceptInvitation(inventoryId, userId uuid.UUID) error {
+	var memberId uuid.UUID
+	query := `UPDATE inventory_members SET
+				status = 'active',
+				updated_at = CURRENT_TIMESTAMP
+			  WHERE inventory_id = $1 AND user_id = $2 AND status = 'pending'
+			  RETURNING id`
+
+	err := r.db.Get(&memberId, query, inventoryId, userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Invitation not found")
+		}
+		return errors.DatabaseError(err, "Accept Invitation")
+	}
+
+	return nil
+}
+
+func (r *Repository) RemoveMember(memberId, inventoryId uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM inventory_members WHERE id = $1 AND inventory_id = $2`, memberId, inventoryId)
+	if err != nil {
+		return errors.DatabaseError(err, "Remove Member")
+	}
+
+	return nil
+}
+
+// GetCurrency returns the currency of an inventory, which decides how its
+// amounts are written in documents and exports
+func GetCurrency(db sqlx.Queryer, inventoryId uuid.UUID) (models.Currency, error) {
+	var currency models.Currency
+	err := sqlx.Get(db, &currency, `SELECT * FROM currencies WHERE inventory_id = $1`, inventoryId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return currency, errors.NotFoundError("Currency not found")
+		}
+		return currency, errors.DatabaseError(err, "Get Currency")
+	}
+
+	return currency, nil
+}
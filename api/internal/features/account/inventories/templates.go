@@ -0,0 +1,72 @@
+package inventories
+
+import (
+	"database/sql"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetDocumentTemplate returns how an inventory prints a layout, falling back to
+// the layout's defaults when it was never configured
+func GetDocumentTemplate(db sqlx.Queryer, inventoryId uuid.UUID, layout string) (models.DocumentTemplate, error) {
+	var template models.DocumentTemplate
+	err := sqlx.Get(db, &template,
+		`SELECT * FROM document_templates WHERE inventory_id = $1 AND layout = $2`,
+		inventoryId, layout)
+	if err == sql.ErrNoRows {
+		template = models.DefaultDocumentTemplates[layout]
+		template.InventoryID = inventoryId
+		return template, nil
+	}
+	if err != nil {
+		return template, errors.DatabaseError(err, "Get Document Template")
+	}
+
+	return template, nil
+}
+
+func (r *Repository) ListDocumentTemplates(inventoryId uuid.UUID) ([]models.DocumentTemplate, error) {
+	var stored []models.DocumentTemplate
+	err := r.db.Select(&stored, `SELECT * FROM document_templates WHERE inventory_id = $1`, inventoryId)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "List Document Templates")
+	}
+
+	byLayout := make(map[string]models.DocumentTemplate, len(stored))
+	for _, template := range stored {
+		byLayout[template.Layout] = template
+	}
+
+	// Layouts that were never configured show their defaults
+	templates := make([]models.DocumentTemplate, 0, len(models.DefaultDocumentTemplates))
+	for _, layout := range []string{models.DocumentLayoutInvoice, models.DocumentLayoutReceipt, models.DocumentLayoutPurchaseOrder} {
+		template, ok := byLayout[layout]
+		if !ok {
+			template = models.DefaultDocumentTemplates[layout]
+			template.InventoryID = inventoryId
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+func (r *Repository) UpdateDocumentTemplate(template *models.DocumentTemplate) error {
+	err := r.db.Get(template,
+		`INSERT INTO document_templates (inventory_id, layout, title, header, footer, paper_size, show_payments, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+         ON CONFLICT (inventory_id, layout)
+         DO UPDATE SET title = EXCLUDED.title, header = EXCLUDED.header, footer = EXCLUDED.footer,
+            paper_size = EXCLUDED.paper_size, show_payments = EXCLUDED.show_payments, updated_at = EXCLUDED.updated_at
+         RETURNING *`,
+		template.InventoryID, template.Layout, template.Title, template.Header, template.Footer,
+		template.PaperSize, template.ShowPayments, template.UpdatedAt)
+	if err != nil {
+		return errors.DatabaseError(err, "Update Document Template")
+	}
+
+	return nil
+}
@@ -2,9 +2,11 @@ package inventories
 
 import (
 	"database/sql"
+	"slices"
 	"strings"
 
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -52,3 +54,25 @@ func (v *InventoryValidator) inventoryExists(name string, userId uuid.UUID, inve
 	}
 	return exists, nil
 }
+
+// validatePaperSize checks the paper of a layout: receipts print on rolls,
+// everything else on sheets
+func validatePaperSize(layout, paperSize string) error {
+	sizes := make([]string, 0, len(utils.PaperSizes))
+	if layout == models.DocumentLayoutReceipt {
+		for size := range utils.ReceiptWidths {
+			sizes = append(sizes, size)
+		}
+	} else {
+		for size := range utils.PaperSizes {
+			sizes = append(sizes, size)
+		}
+	}
+
+	if !slices.Contains(sizes, paperSize) {
+		slices.Sort(sizes)
+		return errors.ValidationError("paperSize must be one of " + strings.Join(sizes, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package notifications
+
+import (
+	"net/http"
+
+	"github.com/app/venside/internal/mapper"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+type Controller struct {
+	repo NotificationRepository
+}
+
+func NewController(repo NotificationRepository) NotificationController {
+	return &Controller{repo: repo}
+}
+
+// ListNotifications lists the notifications of an inventory, newest first.
+// ?unread=true keeps unread ones only and ?type= filters by type.
+func (c *Controller) ListNotifications(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	query, err := utils.ParseListQuery(ctx, notificationSortColumns, "createdAt", "unread", "type")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListNotifications(inventoryID, query)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch notifications", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, utils.MapPage(page, mapper.ToNotificationResponse))
+}
+
+func (c *Controller) CountUnread(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	unread, err := c.repo.CountUnread(inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to count unread notifications", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, models.UnreadNotificationsResponse{Unread: unread})
+}
+
+func (c *Controller) MarkAsRead(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	notificationID, err := uuid.Parse(ctx.Param("notificationId"))
+	if err != nil {
+		return errors.ValidationError("Invalid notification ID")
+	}
+
+	if err := c.repo.MarkAsRead(notificationID, inventoryID); err != nil {
+		return logger.Error(ctx, "Failed to mark notification as read", err, logrus.Fields{
+			"details":         err.Error(),
+			"notification_id": notificationID,
+		})
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c *Controller) MarkAllAsRead(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	if err := c.repo.MarkAllAsRead(inventoryID); err != nil {
+		return logger.Error(ctx, "Failed to mark notifications as read", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,22 @@
+package notifications
+
+import (
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationRepository interface {
+	ListNotifications(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Notification], error)
+	CountUnread(inventoryID uuid.UUID) (int, error)
+	MarkAsRead(notificationID, inventoryID uuid.UUID) error
+	MarkAllAsRead(inventoryID uuid.UUID) error
+}
+
+type NotificationController interface {
+	ListNotifications(ctx echo.Context) error
+	CountUnread(ctx echo.Context) error
+	MarkAsRead(ctx echo.Context) error
+	MarkAllAsRead(ctx echo.Context) error
+}
@@ -0,0 +1,118 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) NotificationRepository {
+	return &Repository{db: db}
+}
+
+// notificationSortColumns maps the sortable API fields of notifications to their columns
+var notificationSortColumns = map[string]string{
+	"createdAt": "created_at",
+}
+
+// Notify records a notification. It takes any executor so callers can raise
+// notifications inside their own transaction.
+func Notify(db sqlx.Execer, notification *models.Notification) error {
+	notification.ID = uuid.New()
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+
+	_, err := db.Exec(
+		`INSERT INTO notifications (id, inventory_id, type, title, message, product_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		notification.ID, notification.InventoryID, notification.Type, notification.Title,
+		notification.Message, notification.ProductID, notification.CreatedAt)
+	if err != nil {
+		return errors.DatabaseError(err, "Error creating notification")
+	}
+
+	return nil
+}
+
+func (r *Repository) ListNotifications(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Notification], error) {
+	var page utils.Page[models.Notification]
+
+	conditions := utils.NewConditions("inventory_id = ?", inventoryID)
+	if query.Filters["unread"] == "true" {
+		conditions.Add("read_at IS NULL")
+	}
+	if notificationType, ok := query.Filters["type"]; ok {
+		conditions.Add("type = ?", notificationType)
+	}
+	if query.From != nil {
+		conditions.Add("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("created_at <= ?", *query.To)
+	}
+
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM notifications` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting notifications")
+	}
+
+	listQuery := r.db.Rebind(`SELECT * FROM notifications` + conditions.Where() + query.OrderAndLimit("id"))
+	notifications := []models.Notification{}
+
+	if err := r.db.Select(&notifications, listQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching notifications")
+	}
+
+	return utils.NewPage(notifications, total, query), nil
+}
+
+func (r *Repository) CountUnread(inventoryID uuid.UUID) (int, error) {
+	var unread int
+	err := r.db.Get(&unread,
+		`SELECT COUNT(*) FROM notifications WHERE inventory_id = $1 AND read_at IS NULL`, inventoryID)
+	if err != nil {
+		return 0, errors.DatabaseError(err, "Error counting unread notifications")
+	}
+
+	return unread, nil
+}
+
+func (r *Repository) MarkAsRead(notificationID, inventoryID uuid.UUID) error {
+	result, err := r.db.Exec(
+		`UPDATE notifications SET read_at = COALESCE(read_at, $1) WHERE id = $2 AND inventory_id = $3`,
+		time.Now(), notificationID, inventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error marking notification as read")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError(err, "Error checking notification update")
+	}
+	if rows == 0 {
+		return errors.NotFoundError("Notification not found")
+	}
+
+	return nil
+}
+
+func (r *Repository) MarkAllAsRead(inventoryID uuid.UUID) error {
+	_, err := r.db.Exec(
+		`UPDATE notifications SET read_at = $1 WHERE inventory_id = $2 AND read_at IS NULL`,
+		time.Now(), inventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error marking notifications as read")
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package statistics
+
+import (
+	"time"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/pkg/errors"
+	"github.com/labstack/echo/v4"
+)
+
+// Helpers shared by the account statements and aging reports of vendors and
+// customers
+
+// ParseAgingBasis reads what open balances age from, due dates by default
+func ParseAgingBasis(ctx echo.Context) (string, error) {
+	switch basis := ctx.QueryParam("basis"); basis {
+	case "":
+		return models.AgingBasisDueDate, nil
+	case models.AgingBasisDueDate, models.AgingBasisDocumentDate:
+		return basis, nil
+	default:
+		return "", errors.ValidationError("Invalid basis. Must be one of: dueDate, documentDate")
+	}
+}
+
+// AgingStart returns the day a document's balance ages from under the basis.
+// Documents without a due date age from their own date.
+func AgingStart(date time.Time, dueDate *time.Time, basis string) time.Time {
+	if basis == models.AgingBasisDueDate && dueDate != nil {
+		return *dueDate
+	}
+	return date
+}
+
+// AddToAging counts amount into the bucket of its age at asOf and returns the
+// age in days. Balances that are not due yet have a negative age.
+func AddToAging(buckets *models.AgingBuckets, since, asOf time.Time, amount int) int {
+	from := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(to.Sub(from).Hours() / 24)
+
+	switch {
+	case days <= 30:
+		buckets.Days0To30 += amount
+	case days <= 60:
+		buckets.Days31To60 += amount
+	case days <= 90:
+		buckets.Days61To90 += amount
+	default:
+		buckets.Over90 += amount
+	}
+	buckets.Total += amount
+
+	return days
+}
+
+// BuildStatement carries the entries dated before from into the opening balance,
+// drops the ones after to and runs the balance through the rest. Entries must
+// be in date order. Open documents are aged at asOf.
+func BuildStatement(entries []models.StatementEntry, open []models.OpenDocument, from, to *time.Time, basis string, asOf time.Time) models.AccountStatement {
+	statement := models.AccountStatement{
+		From:          from,
+		To:            to,
+		Basis:         basis,
+		Entries:       []models.StatementEntry{},
+		OpenDocuments: open,
+	}
+
+	for _, entry := range entries {
+		switch {
+		case from != nil && entry.Date.Before(*from):
+			statement.OpeningBalance += entry.Amount
+			continue
+		case to != nil && entry.Date.After(*to):
+			continue
+		}
+		statement.Entries = append(statement.Entries, entry)
+	}
+
+	balance := statement.OpeningBalance
+	for i := range statement.Entries {
+		balance += statement.Entries[i].Amount
+		statement.Entries[i].Balance = balance
+	}
+	statement.ClosingBalance = balance
+
+	if statement.OpenDocuments == nil {
+		statement.OpenDocuments = []models.OpenDocument{}
+	}
+	for i := range statement.OpenDocuments {
+		doc := &statement.OpenDocuments[i]
+		doc.DaysOutstanding = AddToAging(&statement.Aging, AgingStart(doc.Date, doc.DueDate, basis), asOf, doc.Balance)
+	}
+
+	return statement
+}
@@ -0,0 +1,172 @@
+package statistics
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetPayablesAging buckets what is still owed on purchases by how long it has
+// been outstanding, per vendor. Drafts are not owed until they are ordered.
+func (c *Controller) GetPayablesAging(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	basis, err := ParseAgingBasis(ctx)
+	if err != nil {
+		return err
+	}
+
+	aging, err := c.repo.GetPayablesAging(inventoryID, basis, time.Now())
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch payables aging", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"basis":        basis,
+			"details":      err.Error(),
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, aging)
+}
+
+func (r *Repository) GetPayablesAging(inventoryID uuid.UUID, basis string, asOf time.Time) (*models.PayablesAgingResponse, error) {
+	var rows []struct {
+		VendorID     *uuid.UUID `db:"vendor_id"`
+		VendorName   string     `db:"vendor_name"`
+		PurchaseDate time.Time  `db:"purchase_date"`
+		DueDate      *time.Time `db:"due_date"`
+		Balance      int        `db:"balance"`
+	}
+	err := r.db.Select(&rows,
+		`SELECT p.vendor_id, COALESCE(v.company_name, '') AS vendor_name,
+                p.purchase_date, p.due_date, p.balance
+         FROM purchases p
+         LEFT JOIN vendors v ON v.id = p.vendor_id
+         WHERE p.inventory_id = $1
+           AND p.balance > 0
+           AND p.purchase_status NOT IN ('draft', 'cancelled')
+           AND p.payment_status <> 'cancelled'`,
+		inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching open purchases")
+	}
+
+	response := &models.PayablesAgingResponse{
+		AsOf:    asOf,
+		Basis:   basis,
+		Vendors: []models.VendorAging{},
+	}
+
+	byVendor := make(map[uuid.UUID]int)
+	for _, row := range rows {
+		key := uuid.Nil
+		if row.VendorID != nil {
+			key = *row.VendorID
+		}
+
+		index, ok := byVendor[key]
+		if !ok {
+			index = len(response.Vendors)
+			byVendor[key] = index
+			response.Vendors = append(response.Vendors, models.VendorAging{
+				VendorID:   row.VendorID,
+				VendorName: row.VendorName,
+			})
+		}
+
+		since := AgingStart(row.PurchaseDate, row.DueDate, basis)
+		AddToAging(&response.Vendors[index].AgingBuckets, since, asOf, row.Balance)
+		AddToAging(&response.Totals, since, asOf, row.Balance)
+	}
+
+	// Largest debts first
+	sort.SliceStable(response.Vendors, func(i, j int) bool {
+		return response.Vendors[i].Total > response.Vendors[j].Total
+	})
+
+	return response, nil
+}
+
+// GetReceivablesAging buckets what customers still owe on sales by how long it
+// has been outstanding, per customer
+func (c *Controller) GetReceivablesAging(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	aging, err := c.repo.GetReceivablesAging(inventoryID, time.Now())
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch receivables aging", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"details":      err.Error(),
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, aging)
+}
+
+func (r *Repository) GetReceivablesAging(inventoryID uuid.UUID, asOf time.Time) (*models.ReceivablesAgingResponse, error) {
+	var rows []struct {
+		CustomerID   *uuid.UUID `db:"customer_id"`
+		CustomerName string     `db:"customer_name"`
+		CreditLimit  *int       `db:"credit_limit"`
+		SaleDate     time.Time  `db:"sale_date"`
+		Balance      int        `db:"balance"`
+	}
+	err := r.db.Select(&rows,
+		`SELECT s.customer_id, COALESCE(c.name, '') AS customer_name, c.credit_limit,
+                s.sale_date, s.balance
+         FROM sales s
+         LEFT JOIN customers c ON c.id = s.customer_id
+         WHERE s.inventory_id = $1
+           AND s.balance > 0
+           AND s.payment_status <> 'cancelled'`,
+		inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching open sales")
+	}
+
+	response := &models.ReceivablesAgingResponse{
+		AsOf:      asOf,
+		Customers: []models.CustomerAging{},
+	}
+
+	byCustomer := make(map[uuid.UUID]int)
+	for _, row := range rows {
+		key := uuid.Nil
+		if row.CustomerID != nil {
+			key = *row.CustomerID
+		}
+
+		index, ok := byCustomer[key]
+		if !ok {
+			index = len(response.Customers)
+			byCustomer[key] = index
+			response.Customers = append(response.Customers, models.CustomerAging{
+				CustomerID:   row.CustomerID,
+				CustomerName: row.CustomerName,
+				CreditLimit:  row.CreditLimit,
+			})
+		}
+
+		AddToAging(&response.Customers[index].AgingBuckets, row.SaleDate, asOf, row.Balance)
+		AddToAging(&response.Totals, row.SaleDate, asOf, row.Balance)
+	}
+
+	// Largest debts first
+	sort.SliceStable(response.Customers, func(i, j int) bool {
+		return response.Customers[i].Total > response.Customers[j].Total
+	})
+
+	return response, nil
+}
@@ -3,6 +3,7 @@ package statistics
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/app/venside/pkg/errors"
 	"github.com/app/venside/pkg/logger"
@@ -53,11 +54,29 @@ func (c *Controller) GetStockTrend(ctx echo.Context) error {
 		return err
 	}
 
-	stockData, err := c.repo.GetStockTrend(inventoryID, timeRange)
+	granularity := ctx.QueryParam("granularity")
+	if granularity == "" {
+		granularity = "month"
+	}
+	if _, ok := stockTrendLabels[granularity]; !ok {
+		return errors.ValidationError("Invalid granularity. Must be one of: day, week, month")
+	}
+
+	var warehouseID *uuid.UUID
+	if raw := ctx.QueryParam("warehouseId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return errors.ValidationError("Invalid warehouse ID")
+		}
+		warehouseID = &id
+	}
+
+	stockData, err := c.repo.GetStockTrend(inventoryID, timeRange, granularity, warehouseID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to fetch stock trend data", err, logrus.Fields{
 			"inventory_id": inventoryID,
 			"time_range":   timeRange,
+			"granularity":  granularity,
 			"details":      err.Error(),
 		})
 	}
@@ -140,6 +159,32 @@ func (c *Controller) GetRecentSales(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, sales)
 }
 
+func (c *Controller) GetValuation(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	asOf := time.Now()
+	if raw := ctx.QueryParam("asOf"); raw != "" {
+		asOf, err = time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			return errors.ValidationError("Invalid asOf date. Use YYYY-MM-DD")
+		}
+	}
+
+	valuation, err := c.repo.GetValuation(inventoryID, asOf)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch inventory valuation", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"as_of":        asOf.Format("2006-01-02"),
+			"details":      err.Error(),
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, valuation)
+}
+
 // HELPER METHODS
 
 func (c *Controller) getValidatedTimeRange(ctx echo.Context) (string, error) {
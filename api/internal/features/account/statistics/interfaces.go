@@ -1,6 +1,8 @@
 package statistics
 
 import (
+	"time"
+
 	"github.com/app/venside/internal/models"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -8,10 +10,13 @@ import (
 
 type StatsRepository interface {
 	GetInventoryStats(inventoryID uuid.UUID, timeRange string) (*models.InventoryStats, error)
-	GetStockTrend(inventoryID uuid.UUID, timeRange string) (*models.StockDataResponse, error)
+	GetStockTrend(inventoryID uuid.UUID, timeRange, granularity string, warehouseID *uuid.UUID) (*models.StockDataResponse, error)
 	GetSalesTrend(inventoryID uuid.UUID, timeRange string) (*models.SalesDataResponse, error)
 	GetBestSellingProducts(inventoryID uuid.UUID, timeRange string, limit int) (*models.BestSellersResponse, error)
 	GetRecentSales(inventoryID uuid.UUID, limit int) ([]models.RecentSale, error)
+	GetValuation(inventoryID uuid.UUID, asOf time.Time) (*models.ValuationResponse, error)
+	GetPayablesAging(inventoryID uuid.UUID, basis string, asOf time.Time) (*models.PayablesAgingResponse, error)
+	GetReceivablesAging(inventoryID uuid.UUID, asOf time.Time) (*models.ReceivablesAgingResponse, error)
 }
 
 type StatsController interface {
@@ -20,4 +25,7 @@ type StatsController interface {
 	GetSalesTrend(ctx echo.Context) error
 	GetBestSellingProducts(ctx echo.Context) error
 	GetRecentSales(ctx echo.Context) error
+	GetValuation(ctx echo.Context) error
+	GetPayablesAging(ctx echo.Context) error
+	GetReceivablesAging(ctx echo.Context) error
 }
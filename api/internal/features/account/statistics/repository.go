@@ -2,9 +2,11 @@ package statistics
 
 import (
 	"database/sql"
+	"math"
 	"time"
 
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/cache"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
@@ -26,6 +28,93 @@ func NewRepository(db *sqlx.DB, cache cache.RedisService) StatsRepository {
 
 const StatsTTL = 60 * time.Minute
 
+// saleTotalsCTE totals each sale in an inventory and date range ($1, $2, $3)
+// once, so sale-level amounts are never repeated across their lines. Gross is
+// the list price of the lines before any discount and COGS uses the unit cost
+// captured when the sale was made. Returns come off the sale they belong to:
+// their credited value off the total, their list price off gross and the cost
+// of restocked units off COGS. Cancelled sales are left out.
+const saleTotalsCTE = `
+	WITH sale_totals AS (
+		SELECT
+			s.id,
+			s.sale_date,
+			s.total_amount - COALESCE(r.credited, 0) AS total_amount,
+			COALESCE(SUM(si.quantity * si.unit_price), 0) - COALESCE(r.gross, 0) AS gross,
+			COALESCE(SUM(si.quantity * si.unit_cost), 0) - COALESCE(r.cogs, 0) AS cogs
+		FROM sales s
+		LEFT JOIN sale_items si ON si.sale_id = s.id
+		LEFT JOIN LATERAL (
+			SELECT
+				SUM(sri.amount) AS credited,
+				SUM(sri.quantity * sri.unit_price) AS gross,
+				SUM(sri.quantity * sri.unit_cost) FILTER (WHERE sri.condition = 'restockable') AS cogs
+			FROM sale_returns sr
+			JOIN sale_return_items sri ON sri.return_id = sr.id
+			WHERE sr.sale_id = s.id
+		) r ON true
+		WHERE s.inventory_id = $1 AND s.sale_date BETWEEN $2 AND $3 AND s.payment_status <> 'cancelled'
+		GROUP BY s.id, r.credited, r.gross, r.cogs
+	)`
+
+// onHandValuationCTE values each product of an inventory ($1) as it stood
+// before a moment ($2): what its cost layers received less what was consumed
+// from them, at the cost the inventory's method ($3) assigns. Standard costing
+// uses the standard recorded when each layer was opened or consumed, so later
+// changes to a product's cost price never rewrite the past.
+const onHandValuationCTE = `
+	WITH received AS (
+		SELECT product_id, SUM(quantity) AS quantity, SUM(quantity::BIGINT * unit_cost) AS value,
+			SUM(quantity::BIGINT * standard_cost) AS standard_value
+		FROM cost_layers
+		WHERE inventory_id = $1 AND received_at < $2
+		GROUP BY product_id
+	),
+	consumed AS (
+		SELECT product_id, SUM(quantity) AS quantity, SUM(total_cost) AS value,
+			SUM(standard_cost) AS standard_value
+		FROM cost_consumptions
+		WHERE inventory_id = $1 AND consumed_at < $2
+		GROUP BY product_id
+	),
+	on_hand AS (
+		SELECT
+			p.id AS product_id,
+			p.name AS product_name,
+			p.inventory_id,
+			p.cost_price,
+			COALESCE(rc.quantity, 0) - COALESCE(c.quantity, 0) AS quantity,
+			CASE WHEN $3 = 'standard'
+				THEN COALESCE(rc.standard_value, 0) - COALESCE(c.standard_value, 0)
+				ELSE COALESCE(rc.value, 0) - COALESCE(c.value, 0)
+			END AS value
+		FROM products p
+		LEFT JOIN received rc ON rc.product_id = p.id
+		LEFT JOIN consumed c ON c.product_id = p.id
+		WHERE p.inventory_id = $1
+	)`
+
+type saleFigures struct {
+	Gross int `db:"gross"`
+	Net   int `db:"net"`
+	COGS  int `db:"cogs"`
+}
+
+// grossMargin returns profit as a percentage of revenue, to two decimals
+func grossMargin(profit, revenue int) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(profit)*10000/float64(revenue)) / 100
+}
+
+// stockTrendLabels maps each trend granularity to the label format of its buckets
+var stockTrendLabels = map[string]string{
+	"day":   "DD Mon YYYY",
+	"week":  "DD Mon YYYY",
+	"month": "Mon YYYY",
+}
+
 func (r *Repository) getDateRange(timeRange string) (time.Time, time.Time) {
 	now := time.Now()
 	endDate := now
@@ -76,40 +165,35 @@ func (r *Repository) GetInventoryStats(inventoryID uuid.UUID, timeRange string)
 		return nil, errors.DatabaseError(err, "Error fetching total stock quantity")
 	}
 
-	// 2. Get total inventory value
-	totalValueQuery := `
-		SELECT COALESCE(SUM(p.cost_price * p.total_quantity), 0) as total_value
-		FROM products p
-		WHERE p.inventory_id = $1
-	`
-	err = r.db.Get(&stats.TotalInventoryValue, totalValueQuery, inventoryID)
+	// 2. Get total inventory value under the inventory's valuation method
+	valuation, err := r.GetValuation(inventoryID, time.Now())
 	if err != nil {
-		return nil, errors.DatabaseError(err, "Error fetching total inventory value")
+		return nil, err
 	}
-
-	// 3. Get gross sales revenue
-	grossSalesQuery := `
-		SELECT COALESCE(SUM(s.total_amount + s.discount_amount), 0) as gross_sales
-		FROM sales s
-		WHERE s.inventory_id = $1 AND s.sale_date BETWEEN $2 AND $3
-	`
-	err = r.db.Get(&stats.GrossSalesRevenue, grossSalesQuery, inventoryID, startDate, endDate)
+	stats.TotalInventoryValue = valuation.TotalValue
+
+	// 3. Get revenue, discounts and cost of goods sold
+	var figures saleFigures
+	err = r.db.Get(&figures, saleTotalsCTE+`
+		SELECT
+			COALESCE(SUM(gross), 0) AS gross,
+			COALESCE(SUM(total_amount), 0) AS net,
+			COALESCE(SUM(cogs), 0) AS cogs
+		FROM sale_totals
+	`, inventoryID, startDate, endDate)
 	if err != nil {
-		return nil, errors.DatabaseError(err, "Error fetching gross sales revenue")
+		return nil, errors.DatabaseError(err, "Error fetching sales figures")
 	}
 
-	// 4. Get net profit (revenue - cost)
-	netProfitQuery := `
-		SELECT COALESCE(SUM(s.total_amount - (si.quantity * p.cost_price)), 0) as net_profit
-		FROM sales s
-		JOIN sale_items si ON s.id = si.sale_id
-		JOIN products p ON si.product_id = p.id
-		WHERE s.inventory_id = $1 AND s.sale_date BETWEEN $2 AND $3
-	`
-	err = r.db.Get(&stats.NetProfit, netProfitQuery, inventoryID, startDate, endDate)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, errors.DatabaseError(err, "Error fetching net profit")
-	}
+	stats.GrossSalesRevenue = figures.Gross
+	stats.Discounts = figures.Gross - figures.Net
+	stats.NetSalesRevenue = figures.Net
+	stats.COGS = figures.COGS
+	stats.GrossProfit = figures.Net - figures.COGS
+	stats.GrossMargin = grossMargin(stats.GrossProfit, figures.Net)
+
+	// Operating expenses are not tracked, so net profit is the gross profit
+	stats.NetProfit = stats.GrossProfit
 
 	// if err := r.cache.Set(key, stats, StatsTTL); err != nil {
 	// 	logger.Warn("Failed to cache inventory stats", logrus.Fields{
@@ -122,29 +206,49 @@ func (r *Repository) GetInventoryStats(inventoryID uuid.UUID, timeRange string)
 	return &stats, nil
 }
 
-func (r *Repository) GetStockTrend(inventoryID uuid.UUID, timeRange string) (*models.StockDataResponse, error) {
+func (r *Repository) GetStockTrend(inventoryID uuid.UUID, timeRange, granularity string, warehouseID *uuid.UUID) (*models.StockDataResponse, error) {
 	startDate, endDate := r.getDateRange(timeRange)
 
+	args := []any{inventoryID, startDate, endDate, granularity, stockTrendLabels[granularity]}
+	warehouseFilter := ""
+	if warehouseID != nil {
+		warehouseFilter = "AND warehouse_id = $6"
+		args = append(args, *warehouseID)
+	}
+
+	// Each bucket reports the last snapshot taken within it
 	query := `
-		SELECT 
-			TO_CHAR(date_trunc('month', created_at), 'Mon YYYY') as month,
-			SUM(total_quantity) as quantity
-		FROM products 
-		WHERE inventory_id = $1 AND created_at BETWEEN $2 AND $3
-		GROUP BY date_trunc('month', created_at)
-		ORDER BY date_trunc('month', created_at)
+		WITH daily AS (
+			SELECT
+				date_trunc($4, snapshot_date) AS period,
+				snapshot_date,
+				SUM(quantity) AS quantity,
+				SUM(value) AS value
+			FROM stock_snapshots
+			WHERE inventory_id = $1 AND snapshot_date BETWEEN $2::date AND $3::date ` + warehouseFilter + `
+			GROUP BY snapshot_date
+		)
+		SELECT DISTINCT ON (period)
+			period,
+			TO_CHAR(period, $5) AS month,
+			quantity,
+			value
+		FROM daily
+		ORDER BY period, snapshot_date DESC
 	`
 
-	var stockData []models.StockData
-	err := r.db.Select(&stockData, query, inventoryID, startDate, endDate)
+	stockData := []models.StockData{}
+	err := r.db.Select(&stockData, query, args...)
 	if err != nil {
 		return nil, errors.DatabaseError(err, "Error fetching stock trend data")
 	}
 
 	response := &models.StockDataResponse{
-		StartDate: startDate,
-		EndDate:   endDate,
-		StockData: stockData,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Granularity: granularity,
+		WarehouseID: warehouseID,
+		StockData:   stockData,
 	}
 
 	return response, nil
@@ -153,17 +257,16 @@ func (r *Repository) GetStockTrend(inventoryID uuid.UUID, timeRange string) (*mo
 func (r *Repository) GetSalesTrend(inventoryID uuid.UUID, timeRange string) (*models.SalesDataResponse, error) {
 	startDate, endDate := r.getDateRange(timeRange)
 
-	query := `
-		SELECT 
-			TO_CHAR(date_trunc('month', s.sale_date), 'Mon YYYY') as month,
-			SUM(s.total_amount) as revenue,
-			SUM(s.total_amount - (si.quantity * p.cost_price)) as profit
-		FROM sales s
-		JOIN sale_items si ON s.id = si.sale_id
-		JOIN products p ON si.product_id = p.id
-		WHERE s.inventory_id = $1 AND s.sale_date BETWEEN $2 AND $3
-		GROUP BY date_trunc('month', s.sale_date)
-		ORDER BY date_trunc('month', s.sale_date)
+	query := saleTotalsCTE + `
+		SELECT
+			TO_CHAR(date_trunc('month', sale_date), 'Mon YYYY') as month,
+			SUM(total_amount) as revenue,
+			SUM(gross - total_amount) as discounts,
+			SUM(cogs) as cogs,
+			SUM(total_amount - cogs) as profit
+		FROM sale_totals
+		GROUP BY date_trunc('month', sale_date)
+		ORDER BY date_trunc('month', sale_date)
 	`
 
 	var salesData []models.SalesData
@@ -172,6 +275,10 @@ func (r *Repository) GetSalesTrend(inventoryID uuid.UUID, timeRange string) (*mo
 		return nil, errors.DatabaseError(err, "Error fetching sales trend data")
 	}
 
+	for i := range salesData {
+		salesData[i].Margin = grossMargin(salesData[i].Profit, salesData[i].Revenue)
+	}
+
 	response := &models.SalesDataResponse{
 		StartDate: startDate,
 		EndDate:   endDate,
@@ -188,12 +295,17 @@ func (r *Repository) GetBestSellingProducts(inventoryID uuid.UUID, timeRange str
 		SELECT 
 			p.id as product_id,
 			p.name as product_name,
-			COALESCE(SUM(si.quantity), 0) as total_sold,
-			COALESCE(SUM(si.quantity * si.unit_price), 0) as revenue,
+			COALESCE(SUM(si.quantity - COALESCE(ri.quantity, 0)), 0) as total_sold,
+			COALESCE(SUM((si.quantity - COALESCE(ri.quantity, 0)) * si.unit_price), 0) as revenue,
 			COALESCE(pi.url, '') as image_url
 		FROM products p
 		LEFT JOIN sale_items si ON p.id = si.product_id
 		LEFT JOIN sales s ON si.sale_id = s.id AND s.sale_date BETWEEN $2 AND $3
+		LEFT JOIN (
+			SELECT sale_item_id, SUM(quantity) AS quantity
+			FROM sale_return_items
+			GROUP BY sale_item_id
+		) ri ON ri.sale_item_id = si.id
 		LEFT JOIN (
 			SELECT product_id, url 
 			FROM product_images 
@@ -244,3 +356,49 @@ func (r *Repository) GetRecentSales(inventoryID uuid.UUID, limit int) ([]models.
 
 	return recentSales, nil
 }
+
+// GetValuation values the inventory at the end of the asOf day from its cost
+// layers: what was received up to then less the cost of what had left. Standard
+// cost uses the standard costs the layers opened at instead of their actual
+// costs, so a later change of cost price does not revalue past dates.
+func (r *Repository) GetValuation(inventoryID uuid.UUID, asOf time.Time) (*models.ValuationResponse, error) {
+	asOf = time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location())
+	before := asOf.AddDate(0, 0, 1)
+
+	var method string
+	err := r.db.Get(&method, `SELECT valuation_method FROM inventories WHERE id = $1`, inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundError("Inventory not found")
+		}
+		return nil, errors.DatabaseError(err, "Error fetching valuation method")
+	}
+
+	query := onHandValuationCTE + `
+		SELECT product_id, product_name, quantity, value
+		FROM on_hand
+		WHERE quantity <> 0 OR value <> 0
+		ORDER BY product_name
+	`
+
+	products := []models.ProductValuation{}
+	err = r.db.Select(&products, query, inventoryID, before, method)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching inventory valuation")
+	}
+
+	response := &models.ValuationResponse{
+		AsOf:     asOf,
+		Method:   method,
+		Products: products,
+	}
+	for i := range products {
+		if products[i].Quantity > 0 {
+			products[i].UnitCost = utils.ShareOf(products[i].Value, 1, products[i].Quantity)
+		}
+		response.TotalQuantity += products[i].Quantity
+		response.TotalValue += products[i].Value
+	}
+
+	return response, nil
+}
@@ -0,0 +1,166 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type fixtureLine struct {
+	id              uuid.UUID
+	productID       uuid.UUID
+	quantity        int
+	unitPrice       int
+	unitCost        int
+	discountPercent int
+	discountAmount  int
+	subtotal        int
+}
+
+func seedSale(t *testing.T, db *sqlx.DB, inventoryID uuid.UUID, number string, date time.Time, status string, total, percent, fixed int, lines ...fixtureLine) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	testdb.Exec(t, db,
+		`INSERT INTO sales (id, sale_number, customer_name, sale_date, total_amount, payment_status,
+                            discount_percent, discount_amount, inventory_id)
+         VALUES ($1, $2, 'Walk-in', $3, $4, $5, $6, $7, $8)`,
+		id, number, date, total, status, percent, fixed, inventoryID)
+
+	for _, line := range lines {
+		testdb.Exec(t, db,
+			`INSERT INTO sale_items (id, sale_id, product_id, quantity, unit_price, unit_cost,
+                                     discount_percent, discount_amount, subtotal)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			line.id, id, line.productID, line.quantity, line.unitPrice, line.unitCost,
+			line.discountPercent, line.discountAmount, line.subtotal)
+	}
+
+	return id
+}
+
+// seedSalesFixture records two multi-line sales with line and order discounts,
+// a return against the first and two sales that must not count
+func seedSalesFixture(t *testing.T) (*sqlx.DB, uuid.UUID) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+
+	// Catalogue costs differ from the captured ones, which are what COGS uses
+	cable := testdb.SeedProduct(t, db, seed.InventoryID, "Cable", 250, 500)
+	router := testdb.SeedProduct(t, db, seed.InventoryID, "Router", 900, 5000)
+	today := time.Now()
+
+	// 4 cables at 500 less 10% = 1800, a router at 5000 less 200 = 4800.
+	// 6600 less 5% (330) less 20 = 6250.
+	cableLine := fixtureLine{id: uuid.New(), productID: cable, quantity: 4, unitPrice: 500, unitCost: 300, discountPercent: 10, subtotal: 1800}
+	routerLine := fixtureLine{id: uuid.New(), productID: router, quantity: 1, unitPrice: 5000, unitCost: 1100, discountAmount: 200, subtotal: 4800}
+	first := seedSale(t, db, seed.InventoryID, "S-1", today, "paid", 6250, 5, 20, cableLine, routerLine)
+
+	seedSale(t, db, seed.InventoryID, "S-2", today, "pending", 1000, 0, 0,
+		fixtureLine{id: uuid.New(), productID: cable, quantity: 2, unitPrice: 500, unitCost: 300, subtotal: 1000})
+
+	// Neither a cancelled sale nor one outside the range counts
+	seedSale(t, db, seed.InventoryID, "S-3", today, "cancelled", 5000, 0, 0,
+		fixtureLine{id: uuid.New(), productID: router, quantity: 1, unitPrice: 5000, unitCost: 1100, subtotal: 5000})
+	seedSale(t, db, seed.InventoryID, "S-4", today.AddDate(-2, 0, 0), "paid", 5000, 0, 0,
+		fixtureLine{id: uuid.New(), productID: router, quantity: 1, unitPrice: 5000, unitCost: 1100, subtotal: 5000})
+
+	// Two cables come back from the first sale, each credited 425. Only the
+	// restocked one takes its cost back off COGS.
+	returnID := uuid.New()
+	testdb.Exec(t, db,
+		`INSERT INTO sale_returns (id, return_number, sale_id, inventory_id, reason, total_amount, return_date)
+         VALUES ($1, 'RMA-1', $2, $3, 'Faulty', 850, $4)`,
+		returnID, first, seed.InventoryID, today)
+	testdb.Exec(t, db,
+		`INSERT INTO sale_return_items (id, return_id, sale_item_id, product_id, quantity, condition, unit_price, unit_cost, amount)
+         VALUES ($1, $3, $4, $5, 1, 'restockable', 500, 300, 425),
+                ($2, $3, $4, $5, 1, 'damaged', 500, 300, 425)`,
+		uuid.New(), uuid.New(), returnID, cableLine.id, cable)
+
+	return db, seed.InventoryID
+}
+
+// Expected figures of the fixture:
+//
+//	first sale:  net 6250 - 850 = 5400, gross 7000 - 1000 = 6000, COGS 2300 - 300 = 2000
+//	second sale: net 1000, gross 1000, COGS 600
+const (
+	wantGross    = 7000
+	wantNet      = 6400
+	wantDiscount = 600
+	wantCOGS     = 2600
+	wantProfit   = 3800
+	wantMargin   = 59.38
+)
+
+func TestGetInventoryStatsSalesFigures(t *testing.T) {
+	db, inventoryID := seedSalesFixture(t)
+	repo := NewRepository(db, testdb.Cache{})
+
+	stats, err := repo.GetInventoryStats(inventoryID, "1Y")
+	if err != nil {
+		t.Fatalf("GetInventoryStats: %v", err)
+	}
+
+	if stats.GrossSalesRevenue != wantGross {
+		t.Errorf("gross revenue = %d, want %d", stats.GrossSalesRevenue, wantGross)
+	}
+	if stats.Discounts != wantDiscount {
+		t.Errorf("discounts = %d, want %d", stats.Discounts, wantDiscount)
+	}
+	if stats.NetSalesRevenue != wantNet {
+		t.Errorf("net revenue = %d, want %d", stats.NetSalesRevenue, wantNet)
+	}
+	if stats.COGS != wantCOGS {
+		t.Errorf("COGS = %d, want %d", stats.COGS, wantCOGS)
+	}
+	if stats.GrossProfit != wantProfit {
+		t.Errorf("gross profit = %d, want %d", stats.GrossProfit, wantProfit)
+	}
+	if stats.GrossMargin != wantMargin {
+		t.Errorf("gross margin = %v, want %v", stats.GrossMargin, wantMargin)
+	}
+}
+
+func TestGetSalesTrendFigures(t *testing.T) {
+	db, inventoryID := seedSalesFixture(t)
+	repo := NewRepository(db, testdb.Cache{})
+
+	trend, err := repo.GetSalesTrend(inventoryID, "1Y")
+	if err != nil {
+		t.Fatalf("GetSalesTrend: %v", err)
+	}
+
+	if len(trend.SalesData) != 1 {
+		t.Fatalf("months = %+v, want only the current one", trend.SalesData)
+	}
+
+	month := trend.SalesData[0]
+	if month.Revenue != wantNet || month.Discounts != wantDiscount || month.COGS != wantCOGS ||
+		month.Profit != wantProfit || month.Margin != wantMargin {
+		t.Errorf("month = %+v, want revenue %d, discounts %d, COGS %d, profit %d, margin %v",
+			month, wantNet, wantDiscount, wantCOGS, wantProfit, wantMargin)
+	}
+}
+
+func TestGrossMargin(t *testing.T) {
+	tests := []struct {
+		profit, revenue int
+		want            float64
+	}{
+		{profit: 3800, revenue: 6400, want: 59.38},
+		{profit: 1, revenue: 3, want: 33.33},
+		{profit: -250, revenue: 1000, want: -25},
+		{profit: 100, revenue: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := grossMargin(tt.profit, tt.revenue); got != tt.want {
+			t.Errorf("grossMargin(%d, %d) = %v, want %v", tt.profit, tt.revenue, got, tt.want)
+		}
+	}
+}
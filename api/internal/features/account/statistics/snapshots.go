@@ -0,0 +1,100 @@
+package statistics
+
+import (
+	"context"
+	"time"
+
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// TakeStockSnapshot records the quantity and value of every product in every
+// warehouse for the given day. Stock is valued the way GetValuation values it,
+// shared out across warehouses by quantity. Products a warehouse held in the
+// previous snapshot but no longer links to are recorded at zero, so a sold-out
+// day still has rows. Running it again on the same day overwrites that day's
+// figures, so the last run of the day wins.
+func TakeStockSnapshot(db *sqlx.DB, date time.Time) error {
+	var inventories []struct {
+		ID     uuid.UUID `db:"id"`
+		Method string    `db:"valuation_method"`
+	}
+	if err := db.Select(&inventories, `SELECT id, valuation_method FROM inventories`); err != nil {
+		return errors.DatabaseError(err, "Error fetching inventories for stock snapshot")
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	query := onHandValuationCTE + `,
+		stock AS (
+			SELECT wpl.product_id, wpl.warehouse_id, wpl.quantity_in_stock AS quantity
+			FROM warehouse_product_link wpl
+			JOIN on_hand oh ON oh.product_id = wpl.product_id
+			UNION ALL
+			SELECT s.product_id, s.warehouse_id, 0
+			FROM stock_snapshots s
+			WHERE s.inventory_id = $1
+				AND s.snapshot_date = (
+					SELECT MAX(snapshot_date) FROM stock_snapshots
+					WHERE inventory_id = $1 AND snapshot_date < $4::date
+				)
+				AND NOT EXISTS (
+					SELECT 1 FROM warehouse_product_link wpl
+					WHERE wpl.product_id = s.product_id AND wpl.warehouse_id = s.warehouse_id
+				)
+		)
+		INSERT INTO stock_snapshots (snapshot_date, inventory_id, product_id, warehouse_id, quantity, value)
+		SELECT $4::date, oh.inventory_id, st.product_id, st.warehouse_id, st.quantity,
+			CASE WHEN oh.quantity > 0
+				THEN ROUND(st.quantity::NUMERIC * oh.value / oh.quantity)::BIGINT
+				ELSE st.quantity::BIGINT * oh.cost_price
+			END
+		FROM stock st
+		JOIN on_hand oh ON oh.product_id = st.product_id
+		ON CONFLICT (snapshot_date, product_id, warehouse_id)
+		DO UPDATE SET quantity = EXCLUDED.quantity, value = EXCLUDED.value, created_at = CURRENT_TIMESTAMP
+	`
+
+	for _, inventory := range inventories {
+		_, err := db.Exec(query, inventory.ID, day.AddDate(0, 0, 1), inventory.Method, day.Format("2006-01-02"))
+		if err != nil {
+			return errors.DatabaseError(err, "Error taking stock snapshot")
+		}
+	}
+
+	return nil
+}
+
+// StartSnapshotJob takes a snapshot immediately and then once a day until ctx
+// is cancelled.
+func StartSnapshotJob(ctx context.Context, db *sqlx.DB, interval time.Duration) {
+	run := func() {
+		now := time.Now()
+		if err := TakeStockSnapshot(db, now); err != nil {
+			logger.Warn("Stock snapshot failed", logrus.Fields{
+				"date":  now.Format("2006-01-02"),
+				"error": err.Error(),
+			})
+			return
+		}
+		logger.Info("Stock snapshot taken", logrus.Fields{"date": now.Format("2006-01-02")})
+	}
+
+	go func() {
+		run()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
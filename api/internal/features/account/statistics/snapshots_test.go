@@ -0,0 +1,53 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/google/uuid"
+)
+
+func TestTakeStockSnapshot(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, models.ValuationFIFO)
+	productID := testdb.SeedProduct(t, db, seed.InventoryID, "Widget", 150, 400)
+
+	// Ten units bought at 100, all in one warehouse, though the product lists at 150
+	testdb.Exec(t, db,
+		`INSERT INTO cost_layers (id, inventory_id, product_id, source, document_id, quantity, remaining_quantity, unit_cost, received_at)
+         VALUES ($1, $2, $3, 'receipt', NULL, 10, 10, 100, $4)`,
+		uuid.New(), seed.InventoryID, productID, time.Now().AddDate(0, 0, -3))
+	testdb.Exec(t, db, `INSERT INTO warehouse_product_link (product_id, warehouse_id, quantity_in_stock) VALUES ($1, $2, 10)`,
+		productID, seed.WarehouseID)
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	if err := TakeStockSnapshot(db, yesterday); err != nil {
+		t.Fatalf("TakeStockSnapshot: %v", err)
+	}
+
+	// Everything sells, so the warehouse no longer links to the product
+	testdb.Exec(t, db, `DELETE FROM warehouse_product_link WHERE product_id = $1`, productID)
+	if err := TakeStockSnapshot(db, time.Now()); err != nil {
+		t.Fatalf("TakeStockSnapshot: %v", err)
+	}
+
+	var rows []struct {
+		Quantity int `db:"quantity"`
+		Value    int `db:"value"`
+	}
+	err := db.Select(&rows, `SELECT quantity, value FROM stock_snapshots WHERE product_id = $1 ORDER BY snapshot_date`, productID)
+	if err != nil {
+		t.Fatalf("loading snapshots: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d snapshot rows, want one for each day", len(rows))
+	}
+	if rows[0].Quantity != 10 || rows[0].Value != 1000 {
+		t.Errorf("yesterday = %d units worth %d, want 10 worth 1000 at the layer cost", rows[0].Quantity, rows[0].Value)
+	}
+	if rows[1].Quantity != 0 || rows[1].Value != 0 {
+		t.Errorf("today = %d units worth %d, want a zero row for the sold-out product", rows[1].Quantity, rows[1].Value)
+	}
+}
@@ -0,0 +1,71 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/app/venside/internal/features/application/warehouses"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/google/uuid"
+)
+
+func TestGetValuationKeepsHistory(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, models.ValuationStandard)
+	productID := testdb.SeedProduct(t, db, seed.InventoryID, "Widget", 150, 400)
+	repo := NewRepository(db, testdb.Cache{})
+
+	// Ten units received and four sold three days ago, at a standard cost of 150
+	saleID := uuid.New()
+	tx := db.MustBegin()
+	err := warehouses.AddCostLayer(tx, models.CostLayer{
+		InventoryID: seed.InventoryID,
+		ProductID:   productID,
+		Source:      models.CostLayerReceipt,
+		Quantity:    10,
+		UnitCost:    100,
+	})
+	if err != nil {
+		t.Fatalf("AddCostLayer: %v", err)
+	}
+	if _, err := warehouses.ConsumeCostLayers(tx, seed.InventoryID, productID, 4, &saleID); err != nil {
+		t.Fatalf("ConsumeCostLayers: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	threeDaysAgo := time.Now().AddDate(0, 0, -3)
+	testdb.Exec(t, db, `UPDATE cost_layers SET received_at = $1 WHERE product_id = $2`, threeDaysAgo, productID)
+	testdb.Exec(t, db, `UPDATE cost_consumptions SET consumed_at = $1 WHERE product_id = $2`, threeDaysAgo, productID)
+
+	// The standard changes and the sale is then released, as an edit does
+	testdb.Exec(t, db, `UPDATE products SET cost_price = 200 WHERE id = $1`, productID)
+	tx = db.MustBegin()
+	if err := warehouses.ReleaseCostLayers(tx, saleID); err != nil {
+		t.Fatalf("ReleaseCostLayers: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	tests := []struct {
+		asOf          time.Time
+		quantity, val int
+	}{
+		{asOf: time.Now().AddDate(0, 0, -2), quantity: 6, val: 900},
+		{asOf: time.Now(), quantity: 10, val: 1500},
+	}
+
+	for _, tt := range tests {
+		valuation, err := repo.GetValuation(seed.InventoryID, tt.asOf)
+		if err != nil {
+			t.Fatalf("GetValuation: %v", err)
+		}
+		if valuation.TotalQuantity != tt.quantity || valuation.TotalValue != tt.val {
+			t.Errorf("as of %s: %d units worth %d, want %d worth %d", tt.asOf.Format(time.DateOnly),
+				valuation.TotalQuantity, valuation.TotalValue, tt.quantity, tt.val)
+		}
+	}
+}
@@ -31,7 +31,12 @@ func (c *Controller) ListCustomers(ctx echo.Context) error {
 		return errors.ValidationError("Invalid inventory ID")
 	}
 
-	customers, err := c.repo.ListCustomers(inventoryID)
+	query, err := utils.ParseListQuery(ctx, customerSortColumns, "createdAt", "customerType")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListCustomers(inventoryID, query)
 	if err != nil {
 		return logger.Error(ctx, "Failed to fetch customers", err, logrus.Fields{
 			"details":      err.Error(),
@@ -39,21 +44,23 @@ func (c *Controller) ListCustomers(ctx echo.Context) error {
 		})
 	}
 
-	response := make([]models.CustomerResponse, len(customers))
-	for i, customer := range customers {
-		response[i] = *mapper.ToCustomerResponse(&customer)
-	}
+	response := utils.MapPage(page, mapper.ToCustomerResponse)
 
 	return ctx.JSON(http.StatusOK, response)
 }
 
 func (c *Controller) GetCustomer(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	customerID, err := uuid.Parse(ctx.Param("customerId"))
 	if err != nil {
 		return errors.ValidationError("Invalid customer ID")
 	}
 
-	customer, err := c.repo.GetCustomer(customerID)
+	customer, err := c.repo.GetCustomer(customerID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to retrieve customer", err, logrus.Fields{
 			"details":     err.Error(),
@@ -76,6 +83,10 @@ func (c *Controller) CreateCustomer(ctx echo.Context) error {
 		return err
 	}
 
+	if err := checkCreditLimitChange(ctx, nil, req.CreditLimit); err != nil {
+		return err
+	}
+
 	newCustomer := mapper.ToCreateCustomer(&req, inventoryID)
 	if err := c.validator.ValidateCustomer(newCustomer); err != nil {
 		return err
@@ -93,6 +104,11 @@ func (c *Controller) CreateCustomer(ctx echo.Context) error {
 }
 
 func (c *Controller) UpdateCustomer(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	customerID, err := uuid.Parse(ctx.Param("customerId"))
 	if err != nil {
 		return errors.ValidationError("Invalid customer ID")
@@ -103,7 +119,7 @@ func (c *Controller) UpdateCustomer(ctx echo.Context) error {
 		return err
 	}
 
-	existingCustomer, err := c.repo.GetCustomer(customerID)
+	existingCustomer, err := c.repo.GetCustomer(customerID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Customer not found", err, logrus.Fields{
 			"details":     err.Error(),
@@ -111,6 +127,10 @@ func (c *Controller) UpdateCustomer(ctx echo.Context) error {
 		})
 	}
 
+	if err := checkCreditLimitChange(ctx, existingCustomer.CreditLimit, req.CreditLimit); err != nil {
+		return err
+	}
+
 	updatedCustomer := mapper.ToUpdateCustomer(&req, &existingCustomer)
 	if err := c.validator.ValidateCustomer(updatedCustomer); err != nil {
 		return err
@@ -147,3 +167,24 @@ func (c *Controller) DeleteCustomer(ctx echo.Context) error {
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+// checkCreditLimitChange keeps setting and changing credit limits to owners and
+// managers, since clerks take the sales the limit is meant to hold back
+func checkCreditLimitChange(ctx echo.Context, current, requested *int) error {
+	unchanged := current == nil && requested == nil ||
+		(current != nil && requested != nil && *current == *requested)
+	if unchanged {
+		return nil
+	}
+
+	actor, ok := ctx.Get("member").(*models.InventoryMember)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	if actor.Role != models.RoleOwner && actor.Role != models.RoleManager {
+		return errors.ForbiddenError("Only owners and managers can change a credit limit")
+	}
+
+	return nil
+}
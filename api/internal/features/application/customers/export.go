@@ -0,0 +1,62 @@
+package customers
+
+import (
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+var customerExportColumns = []utils.ExportColumn[models.Customer]{
+	{Header: "Name", Key: "name", Value: func(c *models.Customer) any { return c.Name }},
+	{Header: "Type", Key: "customerType", Value: func(c *models.Customer) any { return c.CustomerType }},
+	{Header: "Email", Key: "email", Value: func(c *models.Customer) any { return c.Email }},
+	{Header: "Phone", Key: "phone", Value: func(c *models.Customer) any { return c.Phone }},
+	{Header: "Address", Key: "address", Value: func(c *models.Customer) any { return c.Address }},
+	{Header: "Credit limit", Key: "creditLimit", Money: true, Value: func(c *models.Customer) any { return c.CreditLimit }},
+	{Header: "Created at", Key: "createdAt", Value: func(c *models.Customer) any { return c.CreatedAt }},
+}
+
+// ExportCustomers downloads every customer matching the list filters as CSV, XLSX or JSON
+func (c *Controller) ExportCustomers(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	query, err := utils.ParseListQuery(ctx, customerSortColumns, "createdAt", "customerType")
+	if err != nil {
+		return err
+	}
+
+	format, err := utils.ParseExportFormat(ctx)
+	if err != nil {
+		return err
+	}
+
+	exporter := utils.NewExporter(ctx, format, "customers", customerExportColumns, "", "")
+	if err := c.repo.ExportCustomers(inventoryID, query, exporter.Write); err != nil {
+		return logger.Error(ctx, "Failed to export customers", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	return exporter.Close()
+}
+
+// ExportCustomers streams the customers matching the list filters to write, in list
+// order and without paging
+func (r *Repository) ExportCustomers(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Customer) error) error {
+	conditions := customerListConditions(inventoryID, query)
+
+	rows, err := r.db.Queryx(r.db.Rebind(`SELECT * FROM customers`+conditions.Where()+query.OrderBy("id")), conditions.Args()...)
+	if err != nil {
+		return errors.DatabaseError(err, "Error exporting customers")
+	}
+
+	return utils.EachRow(rows, write)
+}
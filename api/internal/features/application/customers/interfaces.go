@@ -1,14 +1,19 @@
 package customers
 
 import (
+	"time"
+
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type CustomerRepository interface {
-	ListCustomers(inventoryID uuid.UUID) ([]models.Customer, error)
-	GetCustomer(customerID uuid.UUID) (models.Customer, error)
+	ListCustomers(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Customer], error)
+	ExportCustomers(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Customer) error) error
+	GetCustomer(customerID, inventoryID uuid.UUID) (models.Customer, error)
+	GetCustomerStatement(customerID, inventoryID uuid.UUID, from, to *time.Time) (*models.CustomerStatementResponse, error)
 	CreateCustomer(customer *models.Customer) error
 	UpdateCustomer(customer *models.Customer) error
 	DeleteCustomer(customerID, inventoryID uuid.UUID) error
@@ -16,7 +21,9 @@ type CustomerRepository interface {
 
 type CustomerController interface {
 	ListCustomers(ctx echo.Context) error
+	ExportCustomers(ctx echo.Context) error
 	GetCustomer(ctx echo.Context) error
+	GetCustomerStatement(ctx echo.Context) error
 	CreateCustomer(ctx echo.Context) error
 	UpdateCustomer(ctx echo.Context) error
 	DeleteCustomer(ctx echo.Context) error
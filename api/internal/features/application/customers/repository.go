@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/cache"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
@@ -32,41 +33,74 @@ const (
 	TTL = 30 * 24 * time.Hour
 )
 
-func (r *Repository) ListCustomers(inventoryID uuid.UUID) ([]models.Customer, error) {
-	key := customerListCacheKey(inventoryID)
+// customerSortColumns maps the sortable API fields of customers to their columns
+var customerSortColumns = map[string]string{
+	"createdAt":    "created_at",
+	"name":         "name",
+	"customerType": "customer_type",
+}
+
+// customerListConditions builds the WHERE clause of a customer list, shared by
+// the paged list and the export
+func customerListConditions(inventoryID uuid.UUID, query utils.ListQuery) *utils.Conditions {
+	conditions := utils.NewConditions("inventory_id = ?", inventoryID)
+	if customerType, ok := query.Filters["customerType"]; ok {
+		conditions.Add("customer_type = ?", customerType)
+	}
+	if query.From != nil {
+		conditions.Add("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("created_at <= ?", *query.To)
+	}
+
+	return conditions
+}
+
+func (r *Repository) ListCustomers(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Customer], error) {
+	var page utils.Page[models.Customer]
+	key := utils.PageCacheKey(r.cache, customerListCacheKey(inventoryID), query, TTL)
 
-	var cachedCustomers []models.Customer
-	if err := r.cache.Get(key, &cachedCustomers); err == nil {
-		return cachedCustomers, nil
+	if err := r.cache.Get(key, &page); err == nil {
+		return page, nil
 	}
 
-	query := `SELECT * FROM customers WHERE inventory_id = $1 ORDER BY created_at DESC`
+	conditions := customerListConditions(inventoryID, query)
+
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM customers` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting customers")
+	}
+
+	listQuery := r.db.Rebind(`SELECT * FROM customers` + conditions.Where() + query.OrderAndLimit("id"))
 	customers := []models.Customer{}
 
-	err := r.db.Select(&customers, query, inventoryID)
-	if err != nil {
-		return nil, errors.DatabaseError(err, "Error fetching customers")
+	if err := r.db.Select(&customers, listQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching customers")
 	}
 
-	if err := r.cache.Set(key, customers, TTL); err != nil {
-		return customers, errors.CacheError(err, "Error caching customers")
+	page = utils.NewPage(customers, total, query)
+
+	if err := r.cache.Set(key, page, TTL); err != nil {
+		return page, errors.CacheError(err, "Error caching customers")
 	}
 
-	return customers, nil
+	return page, nil
 }
 
-func (r *Repository) GetCustomer(customerID uuid.UUID) (models.Customer, error) {
+func (r *Repository) GetCustomer(customerID, inventoryID uuid.UUID) (models.Customer, error) {
 	key := customerCacheKey(customerID)
 
 	var cachedCustomer models.Customer
-	if err := r.cache.Get(key, &cachedCustomer); err == nil {
+	if err := r.cache.Get(key, &cachedCustomer); err == nil && cachedCustomer.InventoryID == inventoryID {
 		return cachedCustomer, nil
 	}
 
 	var customer models.Customer
-	query := `SELECT * FROM customers WHERE id = $1`
+	query := `SELECT * FROM customers WHERE id = $1 AND inventory_id = $2`
 
-	err := r.db.Get(&customer, query, customerID)
+	err := r.db.Get(&customer, query, customerID, inventoryID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return customer, errors.NotFoundError("Customer not found")
@@ -85,11 +119,11 @@ func (r *Repository) CreateCustomer(customer *models.Customer) error {
 	query := `
 		INSERT INTO customers (
 			id, name, email, phone, address, 
-			customer_type, inventory_id, 
+			customer_type, credit_limit, inventory_id, 
 			created_at, updated_at
 		) VALUES (
 			:id, :name, :email, :phone, :address,
-			:customer_type, :inventory_id,
+			:customer_type, :credit_limit, :inventory_id,
 			:created_at, :updated_at
 		)
 	`
@@ -111,8 +145,9 @@ func (r *Repository) UpdateCustomer(customer *models.Customer) error {
 			phone = :phone,
 			address = :address,
 			customer_type = :customer_type,
+			credit_limit = :credit_limit,
 			updated_at = :updated_at
-		WHERE id = :id
+		WHERE id = :id AND inventory_id = :inventory_id
 	`
 	_, err := r.db.NamedExec(query, customer)
 	if err != nil {
@@ -125,8 +160,12 @@ func (r *Repository) UpdateCustomer(customer *models.Customer) error {
 }
 
 func (r *Repository) DeleteCustomer(customerID, inventoryID uuid.UUID) error {
-	query := `DELETE FROM customers WHERE id = $1`
-	_, err := r.db.Exec(query, customerID)
+	if _, err := r.GetCustomer(customerID, inventoryID); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM customers WHERE id = $1 AND inventory_id = $2`
+	_, err := r.db.Exec(query, customerID, inventoryID)
 	if err != nil {
 		return errors.DatabaseError(err, "Error deleting customer")
 	}
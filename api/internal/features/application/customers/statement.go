@@ -0,0 +1,110 @@
+package customers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/app/venside/internal/features/account/statistics"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetCustomerStatement lists the sales, payments and returns on a customer's
+// account over an optional from/to period, with what is still open
+func (c *Controller) GetCustomerStatement(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	customerID, err := uuid.Parse(ctx.Param("customerId"))
+	if err != nil {
+		return errors.ValidationError("Invalid customer ID")
+	}
+
+	from, to, err := utils.ParseDateRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	statement, err := c.repo.GetCustomerStatement(customerID, inventoryID, from, to)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch customer statement", err, logrus.Fields{
+			"customer_id":  customerID,
+			"inventory_id": inventoryID,
+			"details":      err.Error(),
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, statement)
+}
+
+func (r *Repository) GetCustomerStatement(customerID, inventoryID uuid.UUID, from, to *time.Time) (*models.CustomerStatementResponse, error) {
+	customer, err := r.GetCustomer(customerID, inventoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sales owe their total, payments and returns settle it and refunds paid
+	// back on a return put it back
+	var entries []models.StatementEntry
+	err = r.db.Select(&entries,
+		`WITH customer_sales AS (
+             SELECT id, sale_number, sale_date, total_amount, created_at
+             FROM sales
+             WHERE customer_id = $1 AND inventory_id = $2
+               AND payment_status <> 'cancelled'
+         )
+         SELECT sale_date AS entry_date, 'sale' AS entry_type, id AS document_id,
+                sale_number AS document_number, NULL AS reference,
+                total_amount AS amount, created_at
+         FROM customer_sales
+         UNION ALL
+         SELECT sp.payment_date, 'payment', cs.id, cs.sale_number, sp.reference,
+                -sp.amount, sp.created_at
+         FROM sale_payments sp
+         JOIN customer_sales cs ON cs.id = sp.sale_id
+         UNION ALL
+         SELECT sr.return_date, 'return', sr.id, sr.return_number, sr.reason,
+                -sr.total_amount, sr.created_at
+         FROM sale_returns sr
+         JOIN customer_sales cs ON cs.id = sr.sale_id
+         UNION ALL
+         SELECT sr.return_date, 'refund', sr.id, sr.return_number, sr.refund_method,
+                sr.refund_amount, sr.created_at
+         FROM sale_returns sr
+         JOIN customer_sales cs ON cs.id = sr.sale_id
+         WHERE sr.refund_amount > 0
+         ORDER BY entry_date, created_at, entry_type DESC`,
+		customerID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching customer statement entries")
+	}
+
+	// Sales carry no due date, so they age from the day they were made
+	var open []models.OpenDocument
+	err = r.db.Select(&open,
+		`SELECT id, sale_number AS number, sale_date AS document_date,
+                NULL AS due_date, total_amount, balance
+         FROM sales
+         WHERE customer_id = $1 AND inventory_id = $2
+           AND balance > 0
+           AND payment_status <> 'cancelled'
+         ORDER BY sale_date, created_at`,
+		customerID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching open customer sales")
+	}
+
+	return &models.CustomerStatementResponse{
+		CustomerID:       customer.ID,
+		CustomerName:     customer.Name,
+		CreditLimit:      customer.CreditLimit,
+		AccountStatement: statistics.BuildStatement(entries, open, from, to, models.AgingBasisDocumentDate, time.Now()),
+	}, nil
+}
@@ -1,10 +1,16 @@
 package products
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/app/venside/internal/features/application/warehouses"
 	"github.com/app/venside/internal/mapper"
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/cloudflare"
 	"github.com/app/venside/pkg/errors"
 	"github.com/app/venside/pkg/logger"
@@ -37,7 +43,12 @@ func (c *Controller) ListProducts(ctx echo.Context) error {
 		return errors.ValidationError("Invalid inventory ID")
 	}
 
-	products, err := c.repo.ListProducts(inventoryID)
+	query, err := utils.ParseListQuery(ctx, productSortColumns, "createdAt", "categoryId", "warehouseId")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListProducts(inventoryID, query)
 	if err != nil {
 		return logger.Error(ctx, "Failed to fetch products", err, logrus.Fields{
 			"inventory_id": inventoryID,
@@ -45,28 +56,52 @@ func (c *Controller) ListProducts(ctx echo.Context) error {
 		})
 	}
 
-	response := make([]models.ProductResponse, len(products))
-	for i, product := range products {
-		detailedProduct, err := c.repo.GetProductWithDetails(product.ID)
-		if err != nil {
-			return logger.Error(ctx, "Failed to fetch product details", err, logrus.Fields{
-				"product_id": product.ID,
-				"details":    err.Error(),
-			})
-		}
-		response[i] = *mapper.ToProductResponse(&detailedProduct)
+	response := utils.MapPage(page, mapper.ToProductResponse)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) SearchProducts(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	term := strings.TrimSpace(ctx.QueryParam("q"))
+	if term == "" {
+		return errors.ValidationError("Search query is required")
+	}
+
+	// Results are ranked by relevance, so only paging and filters apply
+	query, err := utils.ParseListQuery(ctx, productSortColumns, "createdAt", "categoryId", "warehouseId")
+	if err != nil {
+		return err
 	}
 
+	page, err := c.repo.SearchProducts(inventoryID, term, query)
+	if err != nil {
+		return logger.Error(ctx, "Failed to search products", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"query":        term,
+			"details":      err.Error(),
+		})
+	}
+
+	response := utils.MapPage(page, mapper.ToProductResponse)
 	return ctx.JSON(http.StatusOK, response)
 }
 
 func (c *Controller) GetProduct(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	productID, err := uuid.Parse(ctx.Param("productId"))
 	if err != nil {
 		return errors.ValidationError("Invalid product ID")
 	}
 
-	product, err := c.repo.GetProductWithDetails(productID)
+	product, err := c.repo.GetProductWithDetails(productID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to retrieve product", err, logrus.Fields{
 			"product_id": productID,
@@ -78,6 +113,35 @@ func (c *Controller) GetProduct(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, response)
 }
 
+func (c *Controller) ListProductMovements(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	productID, err := uuid.Parse(ctx.Param("productId"))
+	if err != nil {
+		return errors.ValidationError("Invalid product ID")
+	}
+
+	query, err := warehouses.ParseStockMovementQuery(ctx, "warehouseId")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListProductMovements(productID, inventoryID, query)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch product stock movements", err, logrus.Fields{
+			"product_id": productID,
+			"details":    err.Error(),
+		})
+	}
+
+	response := utils.MapPage(page, mapper.ToStockMovementResponse)
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
 func (c *Controller) CreateProduct(ctx echo.Context) error {
 	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
 	if err != nil {
@@ -113,13 +177,135 @@ func (c *Controller) CreateProduct(ctx echo.Context) error {
 	return ctx.JSON(http.StatusCreated, response)
 }
 
+// ImportProducts creates or updates products from an uploaded .csv or .xlsx file.
+// The multipart form carries the file, an optional JSON column mapping and a
+// dryRun flag. A dry run validates every row without writing anything. A real
+// run writes the valid rows in batches; either way the response reports the
+// outcome of each row. Initial stock columns only apply to new products.
+func (c *Controller) ImportProducts(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return errors.ValidationError("Missing file field")
+	}
+
+	dryRun := false
+	if value := ctx.FormValue("dryRun"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return errors.ValidationError("Invalid dryRun value")
+		}
+	}
+
+	var mapping map[string]string
+	if value := ctx.FormValue("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			return errors.ValidationError("Invalid JSON in mapping field")
+		}
+	}
+
+	// One extra row for the header
+	rows, err := utils.ReadSpreadsheet(file, maxImportRows+1)
+	if err != nil {
+		return err
+	}
+	if len(rows) < 2 {
+		return errors.ValidationError("The file has no product rows")
+	}
+	if len(rows)-1 > maxImportRows {
+		return errors.ValidationError(fmt.Sprintf("The file has %d rows. Import at most %d at a time", len(rows)-1, maxImportRows))
+	}
+
+	layout, err := resolveImportLayout(rows[0], mapping)
+	if err != nil {
+		return err
+	}
+
+	warehouseIDs, err := c.repo.ListWarehouseIDsByName(inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch warehouses", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"details":      err.Error(),
+		})
+	}
+	for warehouse := range layout.stock {
+		if _, ok := warehouseIDs[warehouse]; !ok {
+			return errors.ValidationError(fmt.Sprintf("Warehouse \"%s\" not found", warehouse))
+		}
+	}
+
+	plan, response, err := c.planImport(ctx, inventoryID, rows[1:], layout, warehouseIDs)
+	if err != nil {
+		return logger.Error(ctx, "Failed to validate product import", err, logrus.Fields{
+			"inventory_id": inventoryID,
+			"file":         file.Filename,
+			"details":      err.Error(),
+		})
+	}
+	response.DryRun = dryRun
+
+	if dryRun {
+		return ctx.JSON(http.StatusOK, response)
+	}
+
+	// Results are in file order, so each planned row is found by its row number
+	resultIndex := make(map[int]int, len(response.Results))
+	for i, result := range response.Results {
+		resultIndex[result.Row] = i
+	}
+
+	for start := 0; start < len(plan); start += importBatchSize {
+		batch := plan[start:min(start+importBatchSize, len(plan))]
+		if err := c.repo.ImportProducts(batch, inventoryID, user.ID); err != nil {
+			logger.Warn("Product import batch failed", logrus.Fields{
+				"inventory_id": inventoryID,
+				"first_row":    batch[0].Row,
+				"error":        err.Error(),
+			})
+
+			message := "Failed to save this row's batch"
+			if appErr, ok := err.(*errors.AppError); ok && appErr.Type == errors.ValidationErr {
+				message = appErr.Message
+			}
+
+			for _, row := range batch {
+				result := &response.Results[resultIndex[row.Row]]
+				if row.Update {
+					response.Updated--
+				} else {
+					response.Created--
+					result.ProductID = nil
+				}
+				response.Failed++
+				result.Action = models.ImportActionSkip
+				result.Errors = append(result.Errors, message)
+			}
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
 func (c *Controller) UpdateProduct(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	productID, err := uuid.Parse(ctx.Param("productId"))
 	if err != nil {
 		return errors.ValidationError("Invalid product ID")
 	}
 
-	existingProduct, err := c.repo.GetProduct(productID)
+	existingProduct, err := c.repo.GetProduct(productID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Product not found", err, logrus.Fields{
 			"product_id": productID,
@@ -148,11 +334,11 @@ func (c *Controller) UpdateProduct(ctx echo.Context) error {
 		})
 	}
 
-	if err := c.updateProductImages(ctx, productID, req.NewImages, req.ExistingImages); err != nil {
+	if err := c.updateProductImages(ctx, productID, inventoryID, req.NewImages, req.ExistingImages); err != nil {
 		return err
 	}
 
-	finalProduct, err := c.repo.GetProductWithDetails(productID)
+	finalProduct, err := c.repo.GetProductWithDetails(productID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to retrieve updated product", err, logrus.Fields{
 			"product_id": productID,
@@ -165,12 +351,17 @@ func (c *Controller) UpdateProduct(ctx echo.Context) error {
 }
 
 func (c *Controller) DeleteProduct(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	productID, err := uuid.Parse(ctx.Param("productId"))
 	if err != nil {
 		return errors.ValidationError("Invalid product ID")
 	}
 
-	images, err := c.repo.GetProductImages(productID)
+	images, err := c.repo.GetProductImages(productID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to get product images for cleanup", err, logrus.Fields{
 			"product_id": productID,
@@ -178,7 +369,7 @@ func (c *Controller) DeleteProduct(ctx echo.Context) error {
 		})
 	}
 
-	if err := c.repo.DeleteProduct(productID); err != nil {
+	if err := c.repo.DeleteProduct(productID, inventoryID); err != nil {
 		return logger.Error(ctx, "Failed to delete product", err, logrus.Fields{
 			"product_id": productID,
 			"details":    err.Error(),
@@ -232,7 +423,7 @@ func (c *Controller) DeleteMultipleProducts(ctx echo.Context) error {
 		productIDs[i] = pid
 	}
 
-	images, err := c.repo.GetImagesOfMultipleProducts(productIDs)
+	images, err := c.repo.GetImagesOfMultipleProducts(productIDs, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to get product images for cleanup", err, logrus.Fields{
 			"product_ids": productIDs,
@@ -0,0 +1,83 @@
+package products
+
+import (
+	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+var productExportColumns = []utils.ExportColumn[models.Product]{
+	{Header: "Name", Key: "name", Value: func(p *models.Product) any { return p.Name }},
+	{Header: "Code", Key: "code", Value: func(p *models.Product) any { return p.Code }},
+	{Header: "SKU", Key: "sku", Value: func(p *models.Product) any { return p.SKU }},
+	{Header: "Brand", Key: "brand", Value: func(p *models.Product) any { return p.Brand }},
+	{Header: "Model", Key: "model", Value: func(p *models.Product) any { return p.Model }},
+	{Header: "Total quantity", Key: "totalQuantity", Value: func(p *models.Product) any { return p.TotalQuantity }},
+	{Header: "Total stock", Key: "totalStock", Value: func(p *models.Product) any { return p.TotalStock }},
+	{Header: "Restock level", Key: "restockLevel", Value: func(p *models.Product) any { return p.RestockLevel }},
+	{Header: "Optimal level", Key: "optimalLevel", Value: func(p *models.Product) any { return p.OptimalLevel }},
+	{Header: "Cost price", Key: "costPrice", Money: true, Value: func(p *models.Product) any { return p.CostPrice }},
+	{Header: "Selling price", Key: "sellingPrice", Money: true, Value: func(p *models.Product) any { return p.SellingPrice }},
+	{Header: "Created at", Key: "createdAt", Value: func(p *models.Product) any { return p.CreatedAt }},
+}
+
+// ExportProducts downloads every product matching the list filters as CSV, XLSX or JSON
+func (c *Controller) ExportProducts(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	query, err := utils.ParseListQuery(ctx, productSortColumns, "createdAt", "categoryId", "warehouseId")
+	if err != nil {
+		return err
+	}
+
+	format, err := utils.ParseExportFormat(ctx)
+	if err != nil {
+		return err
+	}
+
+	currency, err := c.repo.GetCurrency(inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch inventory currency", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	exporter := utils.NewExporter(ctx, format, "products", productExportColumns, currency.Code, currency.Locale)
+	if err := c.repo.ExportProducts(inventoryID, query, exporter.Write); err != nil {
+		return logger.Error(ctx, "Failed to export products", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	return exporter.Close()
+}
+
+// ExportProducts streams the products matching the list filters to write, in list
+// order and without paging
+func (r *Repository) ExportProducts(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Product) error) error {
+	conditions, err := productListConditions(inventoryID, query)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.db.Queryx(r.db.Rebind(`SELECT * FROM products`+conditions.Where()+query.OrderBy("id")), conditions.Args()...)
+	if err != nil {
+		return errors.DatabaseError(err, "Error exporting products")
+	}
+
+	return utils.EachRow(rows, write)
+}
+
+func (r *Repository) GetCurrency(inventoryID uuid.UUID) (models.Currency, error) {
+	return inventories.GetCurrency(r.db, inventoryID)
+}
@@ -4,25 +4,171 @@ import (
 	"database/sql"
 	"encoding/json"
 	"mime/multipart"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/app/venside/internal/features/application/warehouses"
 	"github.com/app/venside/internal/mapper"
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/errors"
 	"github.com/app/venside/pkg/logger"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
 // REPOSITORY HELPERS
+
+// productSearchDocument must match the expression of the idx_products_search index
+const productSearchDocument = `product_search_vector(name, code, sku, brand, model, description)`
+
+// addProductFilters applies the category and warehouse filters shared by listing and search
+func addProductFilters(conditions *utils.Conditions, query utils.ListQuery) error {
+	categoryID, err := query.UUIDFilter("categoryId")
+	if err != nil {
+		return err
+	}
+	if categoryID != nil {
+		conditions.Add(`EXISTS (SELECT 1 FROM product_category_link pcl
+			WHERE pcl.product_id = products.id AND pcl.category_id = ?)`, *categoryID)
+	}
+
+	warehouseID, err := query.UUIDFilter("warehouseId")
+	if err != nil {
+		return err
+	}
+	if warehouseID != nil {
+		conditions.Add(`EXISTS (SELECT 1 FROM warehouse_product_link wpl
+			WHERE wpl.product_id = products.id AND wpl.warehouse_id = ?)`, *warehouseID)
+	}
+
+	return nil
+}
+
+// productListConditions builds the WHERE clause of a product list, shared by the
+// paged list and the export
+func productListConditions(inventoryID uuid.UUID, query utils.ListQuery) (*utils.Conditions, error) {
+	conditions := utils.NewConditions("inventory_id = ?", inventoryID)
+	if err := addProductFilters(conditions, query); err != nil {
+		return nil, err
+	}
+
+	if query.From != nil {
+		conditions.Add("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("created_at <= ?", *query.To)
+	}
+
+	return conditions, nil
+}
+
+// toPrefixTsQuery turns free text into a tsquery where every word may be a prefix,
+// e.g. "lapt dell" becomes "lapt:* & dell:*". Operators in user input are dropped.
+func toPrefixTsQuery(term string) string {
+	words := strings.FieldsFunc(strings.ToLower(term), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
 func (r *Repository) invalidateProductCaches(productID, inventoryID uuid.UUID) {
 	r.cache.Delete(productCacheKey(productID))
 	r.cache.Delete(productListCacheKey(inventoryID))
 	r.cache.Delete(categoryListCacheKey(inventoryID))
 }
 
+// insertProduct writes a new product with its opening cost layer and categories
+func (r *Repository) insertProduct(tx *sqlx.Tx, product *models.Product, categories []string) error {
+	query := `
+		INSERT INTO products (
+			id, name, code, sku, brand, model, description,
+			total_quantity, total_stock, restock_level, optimal_level,
+			cost_price, selling_price, inventory_id,
+			created_at, updated_at
+		) VALUES (
+			:id, :name, :code, :sku, :brand, :model, :description,
+			:total_quantity, :total_stock, :restock_level, :optimal_level,
+			:cost_price, :selling_price, :inventory_id,
+			:created_at, :updated_at
+		)
+	`
+	_, err := tx.NamedExec(query, product)
+	if err != nil {
+		return errors.DatabaseError(err, "Error creating product")
+	}
+
+	// Stock the product starts with opens at its cost price
+	err = warehouses.AddCostLayer(tx, models.CostLayer{
+		InventoryID: product.InventoryID,
+		ProductID:   product.ID,
+		Source:      models.CostLayerOpening,
+		Quantity:    product.TotalQuantity,
+		UnitCost:    product.CostPrice,
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.handleProductCategories(tx, product.ID, product.InventoryID, categories)
+}
+
+// applyProductUpdate overwrites a product and its categories. Cost layers follow
+// any change of the owned quantity.
+func (r *Repository) applyProductUpdate(tx *sqlx.Tx, product *models.Product, categories []string) error {
+	var previousQuantity int
+	err := tx.Get(&previousQuantity,
+		`SELECT total_quantity FROM products WHERE id = $1 AND inventory_id = $2 FOR UPDATE`,
+		product.ID, product.InventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Product not found")
+		}
+		return errors.DatabaseError(err, "Error getting product by ID")
+	}
+
+	query := `
+		UPDATE products SET 
+			name = :name,
+			code = :code,
+			sku = :sku,
+			brand = :brand,
+			model = :model,
+			description = :description,
+			total_quantity = :total_quantity,
+			total_stock = :total_stock,
+			restock_level = :restock_level,
+			optimal_level = :optimal_level,
+			cost_price = :cost_price,
+			selling_price = :selling_price,
+			updated_at = :updated_at
+		WHERE id = :id AND inventory_id = :inventory_id
+	`
+	_, err = tx.NamedExec(query, product)
+	if err != nil {
+		return errors.DatabaseError(err, "Error updating product")
+	}
+
+	// Layers follow manual changes of the owned quantity
+	if delta := product.TotalQuantity - previousQuantity; delta != 0 {
+		if err := warehouses.AdjustCostLayers(tx, product.InventoryID, product.ID, delta); err != nil {
+			return err
+		}
+	}
+
+	// Update categories
+	return r.updateProductCategories(tx, product.ID, product.InventoryID, categories)
+}
+
 func (r *Repository) handleProductCategories(tx *sqlx.Tx, productID, inventoryID uuid.UUID, categories []string) error {
 	if len(categories) == 0 {
 		return nil
@@ -87,52 +233,85 @@ func (r *Repository) updateProductCategories(tx *sqlx.Tx, productID, inventoryID
 	return nil
 }
 
-func (r *Repository) loadProductImages(product *models.Product) error {
+// loadProductDetails attaches images, categories and warehouse storages to a batch
+// of products. It issues one query per relation regardless of the batch size.
+func (r *Repository) loadProductDetails(products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	productIDs := make([]uuid.UUID, len(products))
+	for i := range products {
+		productIDs[i] = products[i].ID
+	}
+
+	// Images
 	var images []models.ProductImage
 	err := r.db.Select(&images, `
 		SELECT * FROM product_images 
-		WHERE product_id = $1 
+		WHERE product_id = ANY($1) 
 		ORDER BY is_primary DESC, created_at ASC
-	`, product.ID)
+	`, pq.Array(productIDs))
 	if err != nil {
 		return errors.DatabaseError(err, "Error fetching product images")
 	}
-	product.Images = images
-	return nil
-}
 
-func (r *Repository) loadProductCategories(product *models.Product) error {
-	var categories []models.ProductCategory
-	err := r.db.Select(&categories, `
-		SELECT pc.* FROM product_categories pc
+	imagesByProduct := make(map[uuid.UUID][]models.ProductImage, len(products))
+	for _, image := range images {
+		imagesByProduct[image.ProductID] = append(imagesByProduct[image.ProductID], image)
+	}
+
+	// Categories
+	var categories []struct {
+		ProductID uuid.UUID `db:"product_id"`
+		models.ProductCategory
+	}
+	err = r.db.Select(&categories, `
+		SELECT pcm.product_id, pc.* FROM product_categories pc
 		JOIN product_category_link pcm ON pc.id = pcm.category_id
-		WHERE pcm.product_id = $1
-	`, product.ID)
+		WHERE pcm.product_id = ANY($1)
+	`, pq.Array(productIDs))
 	if err != nil {
 		return errors.DatabaseError(err, "Error fetching product categories")
 	}
-	product.Categories = categories
-	return nil
-}
 
-func (r *Repository) loadWarehouseStock(product *models.Product) error {
-	var warehouses []models.WarehouseWithStock
+	categoriesByProduct := make(map[uuid.UUID][]models.ProductCategory, len(products))
+	for _, category := range categories {
+		categoriesByProduct[category.ProductID] = append(categoriesByProduct[category.ProductID], category.ProductCategory)
+	}
+
+	// Warehouse stock
+	var warehouses []struct {
+		ProductID uuid.UUID `db:"product_id"`
+		models.WarehouseWithStock
+	}
 	query := `
         SELECT 
+            wpl.product_id,
             w.id, w.name, w.location, w.capacity, w.storage_type,
             w.is_main, w.manager, w.phone, w.email,
             w.created_at, w.updated_at, 
             wpl.quantity_in_stock
         FROM warehouse_product_link wpl
         JOIN warehouses w ON wpl.warehouse_id = w.id
-        WHERE wpl.product_id = $1
+        WHERE wpl.product_id = ANY($1)
     `
-	err := r.db.Select(&warehouses, query, product.ID)
+	err = r.db.Select(&warehouses, query, pq.Array(productIDs))
 	if err != nil {
 		return errors.DatabaseError(err, "Error fetching warehouse stock")
 	}
 
-	product.Storages = mapper.ToWarehouseStock(warehouses)
+	stockByProduct := make(map[uuid.UUID][]models.WarehouseWithStock, len(products))
+	for _, warehouse := range warehouses {
+		stockByProduct[warehouse.ProductID] = append(stockByProduct[warehouse.ProductID], warehouse.WarehouseWithStock)
+	}
+
+	for i := range products {
+		products[i].Images = imagesByProduct[products[i].ID]
+		products[i].Categories = categoriesByProduct[products[i].ID]
+		products[i].Storages = mapper.ToWarehouseStock(stockByProduct[products[i].ID])
+	}
+
 	return nil
 }
 
@@ -204,8 +383,8 @@ func (c *Controller) uploadProductImages(ctx echo.Context, productID uuid.UUID,
 	return nil
 }
 
-func (c *Controller) updateProductImages(ctx echo.Context, productID uuid.UUID, newImages []*multipart.FileHeader, existingImages []models.ProductImageRequest) error {
-	currentImages, err := c.repo.GetProductImages(productID)
+func (c *Controller) updateProductImages(ctx echo.Context, productID, inventoryID uuid.UUID, newImages []*multipart.FileHeader, existingImages []models.ProductImageRequest) error {
+	currentImages, err := c.repo.GetProductImages(productID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to get current product images", err, logrus.Fields{
 			"product_id": productID,
@@ -228,7 +407,7 @@ func (c *Controller) updateProductImages(ctx echo.Context, productID uuid.UUID,
 			}
 
 			for _, img := range currentImages {
-				if err := c.repo.DeleteProductImage(img.ID); err != nil {
+				if err := c.repo.DeleteProductImage(img.ID, inventoryID); err != nil {
 					return logger.Error(ctx, "Failed to delete image record", err, logrus.Fields{
 						"product_id": productID,
 						"image_id":   img.ID,
@@ -265,7 +444,7 @@ func (c *Controller) updateProductImages(ctx echo.Context, productID uuid.UUID,
 			}
 
 			for _, img := range imagesToDelete {
-				if err := c.repo.DeleteProductImage(img.ID); err != nil {
+				if err := c.repo.DeleteProductImage(img.ID, inventoryID); err != nil {
 					return logger.Error(ctx, "Failed to delete image record", err, logrus.Fields{
 						"product_id": productID,
 						"image_id":   img.ID,
@@ -0,0 +1,73 @@
+package products
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/google/uuid"
+)
+
+// benchmarkPageSize matches a full product list page
+const benchmarkPageSize = 50
+
+// BenchmarkLoadProductDetails compares loading the details of a list page in
+// one query per relation with the per-product queries it replaced
+func BenchmarkLoadProductDetails(b *testing.B) {
+	db := testdb.Open(b)
+	seed := testdb.SeedInventory(b, db, "")
+	repo := &Repository{db: db, cache: testdb.Cache{}}
+
+	secondWarehouse := uuid.New()
+	testdb.Exec(b, db, `INSERT INTO warehouses (id, name, storage_type, inventory_id) VALUES ($1, 'Overflow', 'general', $2)`,
+		secondWarehouse, seed.InventoryID)
+
+	categoryIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for i, id := range categoryIDs {
+		testdb.Exec(b, db, `INSERT INTO product_categories (id, name, inventory_id) VALUES ($1, $2, $3)`,
+			id, fmt.Sprintf("Category %d", i+1), seed.InventoryID)
+	}
+
+	// Every product has two images, two categories and stock in both warehouses
+	for i := 0; i < benchmarkPageSize; i++ {
+		productID := testdb.SeedProduct(b, db, seed.InventoryID, fmt.Sprintf("Product %d", i+1), 100, 200)
+
+		for j := 0; j < 2; j++ {
+			testdb.Exec(b, db,
+				`INSERT INTO product_images (id, url, name, file_key, is_primary, product_id) VALUES ($1, 'https://example.com/i.webp', 'i.webp', 'i.webp', $2, $3)`,
+				uuid.New(), j == 0, productID)
+			testdb.Exec(b, db, `INSERT INTO product_category_link (product_id, category_id) VALUES ($1, $2)`,
+				productID, categoryIDs[(i+j)%len(categoryIDs)])
+		}
+		for _, warehouseID := range []uuid.UUID{seed.WarehouseID, secondWarehouse} {
+			testdb.Exec(b, db, `INSERT INTO warehouse_product_link (product_id, warehouse_id, quantity_in_stock) VALUES ($1, $2, 10)`,
+				productID, warehouseID)
+		}
+	}
+
+	var page []models.Product
+	if err := db.Select(&page, `SELECT * FROM products WHERE inventory_id = $1 ORDER BY name`, seed.InventoryID); err != nil {
+		b.Fatalf("loading page: %v", err)
+	}
+
+	b.Run("batched", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			if err := repo.loadProductDetails(page); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// One round of image, category and stock queries per product, as list
+	// pages did before details were batched
+	b.Run("per-product", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for i := range page {
+				if err := repo.loadProductDetails(page[i : i+1]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
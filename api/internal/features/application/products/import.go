@@ -0,0 +1,401 @@
+package products
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/app/venside/internal/mapper"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/pkg/errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxImportRows   = 5000
+	importBatchSize = 100
+
+	// Columns headed "stock:<warehouse name>" carry a new product's initial stock
+	importStockPrefix = "stock:"
+)
+
+// importFields are the product fields a spreadsheet column can be mapped to
+var importFields = []string{
+	"name", "sku", "code", "brand", "model", "description",
+	"costPrice", "sellingPrice", "restockLevel", "optimalLevel", "categories",
+}
+
+// importLayout locates each mapped field and warehouse stock column in a row
+type importLayout struct {
+	fields map[string]int
+	stock  map[string]int
+}
+
+// resolveImportLayout matches the header row against the column mapping, a
+// field-to-header object such as {"name": "Product", "stock:Main": "Qty"}.
+// Fields left out of the mapping are found by a header of their own name.
+// Headers are compared ignoring case and surrounding spaces.
+func resolveImportLayout(header []string, mapping map[string]string) (importLayout, error) {
+	layout := importLayout{fields: map[string]int{}, stock: map[string]int{}}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[key]; !ok && key != "" {
+			columns[key] = i
+		}
+	}
+
+	known := make(map[string]bool, len(importFields))
+	for _, field := range importFields {
+		known[field] = true
+	}
+
+	var errorMessages []string
+	for field, headerName := range mapping {
+		index, ok := columns[strings.ToLower(strings.TrimSpace(headerName))]
+		switch {
+		case strings.HasPrefix(strings.ToLower(field), importStockPrefix):
+			warehouse := strings.ToLower(strings.TrimSpace(field[len(importStockPrefix):]))
+			if !ok {
+				errorMessages = append(errorMessages, fmt.Sprintf("Column \"%s\" mapped to %s not found", headerName, field))
+				continue
+			}
+			layout.stock[warehouse] = index
+		case known[field]:
+			if !ok {
+				errorMessages = append(errorMessages, fmt.Sprintf("Column \"%s\" mapped to %s not found", headerName, field))
+				continue
+			}
+			layout.fields[field] = index
+		default:
+			errorMessages = append(errorMessages, fmt.Sprintf("Unknown import field \"%s\"", field))
+		}
+	}
+
+	for _, field := range importFields {
+		if _, mapped := layout.fields[field]; mapped {
+			continue
+		}
+		if _, explicit := mapping[field]; explicit {
+			continue
+		}
+		if index, ok := columns[strings.ToLower(field)]; ok {
+			layout.fields[field] = index
+		}
+	}
+
+	for key, index := range columns {
+		if strings.HasPrefix(key, importStockPrefix) {
+			warehouse := strings.TrimSpace(key[len(importStockPrefix):])
+			if _, ok := layout.stock[warehouse]; !ok {
+				layout.stock[warehouse] = index
+			}
+		}
+	}
+
+	if _, ok := layout.fields["name"]; !ok {
+		errorMessages = append(errorMessages, "The file has no column for the product name")
+	}
+
+	if len(errorMessages) > 0 {
+		return layout, errors.ValidationError(strings.Join(errorMessages, "; "))
+	}
+
+	return layout, nil
+}
+
+// cell returns the trimmed value of a field in a row, and whether the file maps it
+func (l importLayout) cell(row []string, field string) (string, bool) {
+	index, ok := l.fields[field]
+	if !ok {
+		return "", false
+	}
+	if index >= len(row) {
+		return "", true
+	}
+	return strings.TrimSpace(row[index]), true
+}
+
+// importRequest seeds the request of a row from the product it updates, so
+// columns the file does not map keep their current values
+func importRequest(existing *models.Product) models.ProductRequest {
+	if existing == nil {
+		return models.ProductRequest{}
+	}
+
+	categories := make([]string, len(existing.Categories))
+	for i, category := range existing.Categories {
+		categories[i] = category.Name
+	}
+
+	return models.ProductRequest{
+		Name:          existing.Name,
+		Code:          existing.Code,
+		SKU:           existing.SKU,
+		Brand:         existing.Brand,
+		Model:         existing.Model,
+		Description:   existing.Description,
+		TotalQuantity: existing.TotalQuantity,
+		TotalStock:    existing.TotalStock,
+		RestockLevel:  existing.RestockLevel,
+		OptimalLevel:  existing.OptimalLevel,
+		CostPrice:     existing.CostPrice,
+		SellingPrice:  existing.SellingPrice,
+		Categories:    categories,
+	}
+}
+
+// applyImportCells overwrites the request with the mapped cells of a row and
+// returns the problems found in them
+func applyImportCells(req *models.ProductRequest, row []string, layout importLayout) []string {
+	var errorMessages []string
+
+	text := map[string]*string{
+		"name": &req.Name, "sku": &req.SKU, "code": &req.Code,
+		"brand": &req.Brand, "model": &req.Model, "description": &req.Description,
+	}
+	for field, dest := range text {
+		if value, ok := layout.cell(row, field); ok {
+			*dest = value
+		}
+	}
+
+	numbers := []struct {
+		field  string
+		dest   *int
+		amount bool
+	}{
+		{"costPrice", &req.CostPrice, true},
+		{"sellingPrice", &req.SellingPrice, true},
+		{"restockLevel", &req.RestockLevel, false},
+		{"optimalLevel", &req.OptimalLevel, false},
+	}
+	for _, number := range numbers {
+		value, ok := layout.cell(row, number.field)
+		if !ok || value == "" {
+			continue
+		}
+
+		if number.amount {
+			amount, err := parseImportAmount(value)
+			if err != nil {
+				errorMessages = append(errorMessages, fmt.Sprintf("%s: %s", number.field, err.Error()))
+				continue
+			}
+			*number.dest = amount
+			continue
+		}
+
+		level, err := strconv.Atoi(value)
+		if err != nil || level < 0 {
+			errorMessages = append(errorMessages, fmt.Sprintf("%s: \"%s\" is not a whole number of 0 or more", number.field, value))
+			continue
+		}
+		*number.dest = level
+	}
+
+	if value, ok := layout.cell(row, "categories"); ok {
+		req.Categories = []string{}
+		for _, name := range strings.Split(value, ";") {
+			if name = strings.TrimSpace(name); name != "" {
+				req.Categories = append(req.Categories, name)
+			}
+		}
+	}
+
+	return errorMessages
+}
+
+// parseImportStock reads the initial stock cells of a row, keyed by warehouse ID
+func parseImportStock(row []string, layout importLayout, warehouseIDs map[string]uuid.UUID) (map[uuid.UUID]int, []string) {
+	var errorMessages []string
+	stock := map[uuid.UUID]int{}
+
+	for warehouse, index := range layout.stock {
+		if index >= len(row) || strings.TrimSpace(row[index]) == "" {
+			continue
+		}
+
+		value := strings.TrimSpace(row[index])
+		quantity, err := strconv.Atoi(value)
+		if err != nil || quantity < 0 {
+			errorMessages = append(errorMessages, fmt.Sprintf("stock:%s: \"%s\" is not a whole number of 0 or more", warehouse, value))
+			continue
+		}
+		if quantity > 0 {
+			stock[warehouseIDs[warehouse]] += quantity
+		}
+	}
+
+	return stock, errorMessages
+}
+
+// parseImportAmount reads a price written in major units with up to two
+// decimals, e.g. "12.5", into minor units
+func parseImportAmount(value string) (int, error) {
+	whole, fraction, _ := strings.Cut(strings.ReplaceAll(value, " ", ""), ".")
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("\"%s\" has more than two decimals", value)
+	}
+
+	units, err := strconv.Atoi(whole + (fraction + "00")[:2])
+	if err != nil || units < 0 || strings.HasPrefix(whole, "+") {
+		return 0, fmt.Errorf("\"%s\" is not a valid amount", value)
+	}
+
+	return units, nil
+}
+
+// fieldErrors spells out struct validation failures of a row
+func fieldErrors(err error) []string {
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	messages := make([]string, len(validationErrors))
+	for i, fe := range validationErrors {
+		messages[i] = fmt.Sprintf("%s failed the %s rule", fe.Field(), fe.Tag())
+	}
+	return messages
+}
+
+// planImport validates every data row and decides whether it creates or
+// updates a product. Rows match existing products by SKU first, then by code.
+// Rows that fail validation are reported and left out of the plan.
+func (c *Controller) planImport(ctx echo.Context, inventoryID uuid.UUID, rows [][]string, layout importLayout, warehouseIDs map[string]uuid.UUID) ([]models.ProductImportRow, *models.ProductImportResponse, error) {
+	var skus, codes []string
+	for _, row := range rows {
+		if sku, _ := layout.cell(row, "sku"); sku != "" {
+			skus = append(skus, sku)
+		}
+		if code, _ := layout.cell(row, "code"); code != "" {
+			codes = append(codes, code)
+		}
+	}
+
+	matches, err := c.repo.FindImportMatches(inventoryID, skus, codes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bySKU := make(map[string]*models.Product, len(matches))
+	byCode := make(map[string]*models.Product, len(matches))
+	for i := range matches {
+		if matches[i].SKU != "" {
+			bySKU[matches[i].SKU] = &matches[i]
+		}
+		byCode[matches[i].Code] = &matches[i]
+	}
+
+	response := &models.ProductImportResponse{Results: make([]models.ProductImportResult, 0, len(rows))}
+	plan := make([]models.ProductImportRow, 0, len(rows))
+	seen := map[string]int{}
+
+	for i, row := range rows {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		response.Total++
+
+		// Row numbers follow the file, where the header is row 1
+		result := models.ProductImportResult{Row: i + 2, Action: models.ImportActionCreate}
+
+		sku, _ := layout.cell(row, "sku")
+		code, _ := layout.cell(row, "code")
+		existing := bySKU[sku]
+		if existing == nil && code != "" {
+			existing = byCode[code]
+		}
+		if existing != nil {
+			result.Action = models.ImportActionUpdate
+			result.ProductID = &existing.ID
+		}
+
+		req := importRequest(existing)
+		result.Errors = applyImportCells(&req, row, layout)
+
+		stock, stockErrors := parseImportStock(row, layout, warehouseIDs)
+		result.Errors = append(result.Errors, stockErrors...)
+		if existing != nil && len(stock) > 0 {
+			result.Warnings = append(result.Warnings, "Initial stock is ignored for existing products")
+			stock = nil
+		}
+
+		var product *models.Product
+		if len(result.Errors) == 0 {
+			if existing != nil {
+				product = mapper.ToUpdateProduct(&req, existing)
+			} else {
+				// Initial stock is owned and put away once the product exists
+				for _, quantity := range stock {
+					req.TotalQuantity += quantity
+				}
+				product = mapper.ToCreateProduct(&req, inventoryID)
+			}
+
+			if err := ctx.Validate(&req); err != nil {
+				result.Errors = append(result.Errors, fieldErrors(err)...)
+			}
+		}
+
+		if len(result.Errors) == 0 {
+			if err := c.validator.ValidateProduct(product); err != nil {
+				appErr, ok := err.(*errors.AppError)
+				if !ok || appErr.Type != errors.ValidationErr {
+					return nil, nil, err
+				}
+				result.Errors = append(result.Errors, strings.Split(appErr.Message, "; ")...)
+			}
+		}
+
+		// The database cannot catch two rows of the same file claiming one identity
+		if len(result.Errors) == 0 {
+			keys := []string{"name:" + strings.ToLower(product.Name), "code:" + product.Code}
+			if product.SKU != "" {
+				keys = append(keys, "sku:"+product.SKU)
+			}
+			for _, key := range keys {
+				if first, ok := seen[key]; ok {
+					field, _, _ := strings.Cut(key, ":")
+					result.Errors = append(result.Errors, fmt.Sprintf("Same %s as row %d", field, first))
+				}
+			}
+			for _, key := range keys {
+				if _, ok := seen[key]; !ok {
+					seen[key] = result.Row
+				}
+			}
+		}
+
+		if len(result.Errors) > 0 {
+			result.Action = models.ImportActionSkip
+			result.Name, result.SKU, result.Code = req.Name, req.SKU, req.Code
+			response.Failed++
+			response.Results = append(response.Results, result)
+			continue
+		}
+
+		result.Name, result.SKU, result.Code = product.Name, product.SKU, product.Code
+		if existing != nil {
+			response.Updated++
+		} else {
+			response.Created++
+			result.ProductID = &product.ID
+		}
+		response.Results = append(response.Results, result)
+
+		plan = append(plan, models.ProductImportRow{
+			Row:        result.Row,
+			Product:    product,
+			Categories: req.Categories,
+			Stock:      stock,
+			Update:     existing != nil,
+		})
+	}
+
+	return plan, response, nil
+}
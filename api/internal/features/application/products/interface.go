@@ -2,34 +2,54 @@ package products
 
 import (
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type ProductRepository interface {
-	ListProducts(inventoryID uuid.UUID) ([]models.Product, error)
-	GetProduct(productID uuid.UUID) (models.Product, error)
-	GetProductWithDetails(productID uuid.UUID) (models.Product, error)
+	ListProducts(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Product], error)
+	ExportProducts(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Product) error) error
+	GetCurrency(inventoryID uuid.UUID) (models.Currency, error)
+	SearchProducts(inventoryID uuid.UUID, term string, query utils.ListQuery) (utils.Page[models.Product], error)
+	GetProduct(productID, inventoryID uuid.UUID) (models.Product, error)
+	GetProductWithDetails(productID, inventoryID uuid.UUID) (models.Product, error)
+	ListProductMovements(productID, inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.StockMovement], error)
+	ListLowStockProducts(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.LowStockProduct], error)
+	ListProductVendors(productID, inventoryID uuid.UUID) ([]models.ProductVendor, error)
+	SetProductVendor(link *models.ProductVendor) error
+	DeleteProductVendor(productID, vendorID, inventoryID uuid.UUID) error
 	CreateProduct(product *models.Product, categories []string) error
 	UpdateProduct(product *models.Product, categories []string) error
-	DeleteProduct(productID uuid.UUID) error
+	DeleteProduct(productID, inventoryID uuid.UUID) error
 	DeleteMultipleProducts(productIDs []uuid.UUID, inventoryID uuid.UUID) error
+	FindImportMatches(inventoryID uuid.UUID, skus, codes []string) ([]models.Product, error)
+	ListWarehouseIDsByName(inventoryID uuid.UUID) (map[string]uuid.UUID, error)
+	ImportProducts(rows []models.ProductImportRow, inventoryID, userID uuid.UUID) error
 
 	ListProductCategories(inventoryID uuid.UUID) ([]models.ProductCategory, error)
-	GetProductImages(productID uuid.UUID) ([]models.ProductImage, error)
-	GetImagesOfMultipleProducts(productIDs []uuid.UUID) ([]models.ProductImage, error)
+	GetProductImages(productID, inventoryID uuid.UUID) ([]models.ProductImage, error)
+	GetImagesOfMultipleProducts(productIDs []uuid.UUID, inventoryID uuid.UUID) ([]models.ProductImage, error)
 	CreateProductImage(image *models.ProductImage) error
-	DeleteProductImage(imageID uuid.UUID) error
+	DeleteProductImage(imageID, inventoryID uuid.UUID) error
 	SetPrimaryImage(imageId, inventoryID uuid.UUID) error
 }
 
 type ProductController interface {
 	ListProducts(ctx echo.Context) error
+	ExportProducts(ctx echo.Context) error
+	SearchProducts(ctx echo.Context) error
 	GetProduct(ctx echo.Context) error
+	ListProductMovements(ctx echo.Context) error
+	ListLowStockProducts(ctx echo.Context) error
+	ListProductVendors(ctx echo.Context) error
+	SetProductVendor(ctx echo.Context) error
+	DeleteProductVendor(ctx echo.Context) error
 	CreateProduct(ctx echo.Context) error
 	UpdateProduct(ctx echo.Context) error
 	DeleteProduct(ctx echo.Context) error
 	DeleteMultipleProducts(ctx echo.Context) error
+	ImportProducts(ctx echo.Context) error
 
 	ListProductCategories(ctx echo.Context) error
 	SetPrimaryImage(ctx echo.Context) error
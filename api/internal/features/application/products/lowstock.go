@@ -0,0 +1,253 @@
+package products
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/app/venside/internal/features/account/notifications"
+	"github.com/app/venside/internal/mapper"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// lowStockSortColumns maps the sortable API fields of the low-stock list to its columns
+var lowStockSortColumns = map[string]string{
+	"shortage":          "restock_level - stock",
+	"stock":             "stock",
+	"name":              "name",
+	"suggestedQuantity": "suggested_quantity",
+}
+
+// suggestedQuantitySQL is the quantity that brings a stock, given as the
+// format argument, back to the optimal level. Products without an optimal
+// level are brought back to their restock level.
+const suggestedQuantitySQL = `GREATEST(GREATEST(optimal_level, restock_level) - %s, 0)`
+
+// WarehouseStockJoin joins the stock a product has in one warehouse as
+// ws.quantity onto the products table given. Selling out removes the stock
+// row, so products that ever moved through the warehouse count at zero there;
+// products never stocked in it are left out.
+func WarehouseStockJoin(table string, warehouseID uuid.UUID) (string, []interface{}) {
+	join := fmt.Sprintf(`
+		JOIN LATERAL (
+			SELECT COALESCE(SUM(wpl.quantity_in_stock), 0) AS quantity
+			FROM warehouse_product_link wpl
+			WHERE wpl.product_id = %[1]s.id AND wpl.warehouse_id = ?
+			HAVING COUNT(*) > 0 OR EXISTS (
+				SELECT 1 FROM stock_movements sm
+				WHERE sm.product_id = %[1]s.id AND sm.warehouse_id = ?
+			)
+		) ws ON TRUE`, table)
+
+	return join, []interface{}{warehouseID, warehouseID}
+}
+
+// ListLowStockProducts lists the products at or below their restock level with
+// the quantity to order. With ?warehouseId= the stock of that warehouse is
+// checked against the product's levels instead of its total stock.
+func (c *Controller) ListLowStockProducts(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	query, err := utils.ParseListQuery(ctx, lowStockSortColumns, "shortage", "categoryId", "warehouseId")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListLowStockProducts(inventoryID, query)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch low-stock products", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, utils.MapPage(page, mapper.ToLowStockProductResponse))
+}
+
+func (r *Repository) ListLowStockProducts(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.LowStockProduct], error) {
+	var page utils.Page[models.LowStockProduct]
+
+	warehouseID, err := query.UUIDFilter("warehouseId")
+	if err != nil {
+		return page, err
+	}
+
+	conditions := utils.NewConditions("inventory_id = ?", inventoryID)
+	if err := addProductFilters(conditions, query); err != nil {
+		return page, err
+	}
+
+	// Products only count as low in the warehouses that carry them
+	stock, from, args := "total_stock", "products", []interface{}{}
+	if warehouseID != nil {
+		join, joinArgs := WarehouseStockJoin("products", *warehouseID)
+		stock, from = "ws.quantity", from+join
+		args = append(args, joinArgs...)
+	}
+	conditions.Add("restock_level > 0")
+	conditions.Add(stock + " <= restock_level")
+	args = append(args, conditions.Args()...)
+
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM ` + from + conditions.Where())
+	if err := r.db.Get(&total, countQuery, args...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting low-stock products")
+	}
+
+	listQuery := r.db.Rebind(fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT products.id, name, code, sku, %s AS stock, total_stock,
+			       restock_level, optimal_level, cost_price, %s AS suggested_quantity
+			FROM %s%s
+		) low_stock`, stock, fmt.Sprintf(suggestedQuantitySQL, stock), from, conditions.Where()) + query.OrderAndLimit("id"))
+
+	lowStock := []models.LowStockProduct{}
+	if err := r.db.Select(&lowStock, listQuery, args...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching low-stock products")
+	}
+
+	if err := r.attachLowStockWarehouses(lowStock); err != nil {
+		return page, err
+	}
+
+	return utils.NewPage(lowStock, total, query), nil
+}
+
+// CheckLowStock notifies every inventory of the products that dropped to or
+// below their restock level since the last check. A product is only notified
+// again once it was restocked above the level and dropped again.
+func CheckLowStock(db *sqlx.DB) (int, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM low_stock_alerts a
+		USING products p
+		WHERE p.id = a.product_id AND (p.restock_level = 0 OR p.total_stock > p.restock_level)`)
+	if err != nil {
+		return 0, errors.DatabaseError(err, "Error clearing restocked products")
+	}
+
+	var crossed []struct {
+		models.LowStockProduct
+		InventoryID uuid.UUID `db:"inventory_id"`
+	}
+	err = tx.Select(&crossed, fmt.Sprintf(`
+		WITH crossed AS (
+			INSERT INTO low_stock_alerts (product_id, inventory_id)
+			SELECT id, inventory_id FROM products
+			WHERE restock_level > 0 AND total_stock <= restock_level
+			ON CONFLICT (product_id) DO NOTHING
+			RETURNING product_id
+		)
+		SELECT p.id, p.inventory_id, p.name, p.code, p.sku, p.total_stock AS stock, p.total_stock,
+		       p.restock_level, p.optimal_level, p.cost_price, `+suggestedQuantitySQL+` AS suggested_quantity
+		FROM products p
+		JOIN crossed c ON c.product_id = p.id`, "p.total_stock"))
+	if err != nil {
+		return 0, errors.DatabaseError(err, "Error recording low-stock products")
+	}
+
+	for _, product := range crossed {
+		productID := product.ID
+		notification := models.Notification{
+			InventoryID: product.InventoryID,
+			Type:        models.NotificationLowStock,
+			Title:       fmt.Sprintf("Low stock: %s", product.Name),
+			Message: fmt.Sprintf("%s is down to %d in stock, at or below its restock level of %d. Order %d to get back to the optimal level.",
+				product.Name, product.Stock, product.RestockLevel, product.SuggestedQuantity),
+			ProductID: &productID,
+		}
+		if err := notifications.Notify(tx, &notification); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.DatabaseError(err, "Error committing low-stock check")
+	}
+
+	return len(crossed), nil
+}
+
+// StartLowStockJob checks stock levels immediately and then at every interval
+// until ctx is cancelled.
+func StartLowStockJob(ctx context.Context, db *sqlx.DB, interval time.Duration) {
+	run := func() {
+		notified, err := CheckLowStock(db)
+		if err != nil {
+			logger.Warn("Low-stock check failed", logrus.Fields{"error": err.Error()})
+			return
+		}
+		if notified > 0 {
+			logger.Info("Low-stock notifications created", logrus.Fields{"products": notified})
+		}
+	}
+
+	go func() {
+		run()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
+
+// REPOSITORY HELPERS
+
+// attachLowStockWarehouses loads where each listed product is stocked, main
+// warehouse first
+func (r *Repository) attachLowStockWarehouses(lowStock []models.LowStockProduct) error {
+	if len(lowStock) == 0 {
+		return nil
+	}
+
+	productIDs := make([]uuid.UUID, len(lowStock))
+	for i := range lowStock {
+		productIDs[i] = lowStock[i].ID
+	}
+
+	var storages []models.LowStockWarehouse
+	err := r.db.Select(&storages, `
+		SELECT wpl.product_id, w.id AS warehouse_id, w.name, wpl.quantity_in_stock
+		FROM warehouse_product_link wpl
+		JOIN warehouses w ON w.id = wpl.warehouse_id
+		WHERE wpl.product_id = ANY($1)
+		ORDER BY w.is_main DESC, w.name`, pq.Array(productIDs))
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching low-stock warehouses")
+	}
+
+	byProduct := make(map[uuid.UUID][]models.LowStockWarehouse)
+	for _, storage := range storages {
+		byProduct[storage.ProductID] = append(byProduct[storage.ProductID], storage)
+	}
+	for i := range lowStock {
+		lowStock[i].Warehouses = byProduct[lowStock[i].ID]
+	}
+
+	return nil
+}
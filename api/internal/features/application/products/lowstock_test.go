@@ -0,0 +1,47 @@
+package products
+
+import (
+	"testing"
+
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/google/uuid"
+)
+
+func TestListLowStockProductsInWarehouse(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+	repo := &Repository{db: db, cache: testdb.Cache{}}
+
+	// Cables sold out of the main warehouse, so their stock row is gone. Hubs
+	// were never stocked there and lamps still have plenty.
+	cable := testdb.SeedProduct(t, db, seed.InventoryID, "Cable", 100, 200)
+	hub := testdb.SeedProduct(t, db, seed.InventoryID, "Hub", 100, 200)
+	lamp := testdb.SeedProduct(t, db, seed.InventoryID, "Lamp", 100, 200)
+	testdb.Exec(t, db, `UPDATE products SET restock_level = 5, optimal_level = 20 WHERE inventory_id = $1`, seed.InventoryID)
+
+	testdb.Exec(t, db,
+		`INSERT INTO stock_movements (id, inventory_id, product_id, warehouse_id, quantity, reason)
+         VALUES ($1, $3, $4, $5, 10, 'receipt'), ($2, $3, $4, $5, -10, 'sale')`,
+		uuid.New(), uuid.New(), seed.InventoryID, cable, seed.WarehouseID)
+	testdb.Exec(t, db, `INSERT INTO warehouse_product_link (product_id, warehouse_id, quantity_in_stock) VALUES ($1, $2, 50)`,
+		lamp, seed.WarehouseID)
+
+	query := utils.ListQuery{
+		Limit:   50,
+		Sort:    "name",
+		Order:   "ASC",
+		Filters: map[string]string{"warehouseId": seed.WarehouseID.String()},
+	}
+	page, err := repo.ListLowStockProducts(seed.InventoryID, query)
+	if err != nil {
+		t.Fatalf("ListLowStockProducts: %v", err)
+	}
+
+	if page.Total != 1 || len(page.Data) != 1 || page.Data[0].ID != cable {
+		t.Fatalf("low stock = %+v, want only the sold-out cable, not the hub %s", page.Data, hub)
+	}
+	if page.Data[0].Stock != 0 || page.Data[0].SuggestedQuantity != 20 {
+		t.Errorf("cable = %+v, want no stock and 20 to order", page.Data[0])
+	}
+}
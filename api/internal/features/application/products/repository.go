@@ -2,9 +2,13 @@ package products
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/app/venside/internal/features/application/warehouses"
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/cache"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
@@ -38,41 +42,112 @@ const (
 )
 
 // Product operations
-func (r *Repository) ListProducts(inventoryID uuid.UUID) ([]models.Product, error) {
-	key := productListCacheKey(inventoryID)
+// productSortColumns maps the sortable API fields of products to their columns
+var productSortColumns = map[string]string{
+	"createdAt":     "created_at",
+	"name":          "name",
+	"code":          "code",
+	"sku":           "sku",
+	"totalQuantity": "total_quantity",
+	"totalStock":    "total_stock",
+	"costPrice":     "cost_price",
+	"sellingPrice":  "selling_price",
+}
+
+func (r *Repository) ListProducts(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Product], error) {
+	var page utils.Page[models.Product]
+	key := utils.PageCacheKey(r.cache, productListCacheKey(inventoryID), query, TTL)
+
+	if err := r.cache.Get(key, &page); err == nil {
+		return page, nil
+	}
 
-	var cachedProducts []models.Product
-	if err := r.cache.Get(key, &cachedProducts); err == nil {
-		return cachedProducts, nil
+	conditions, err := productListConditions(inventoryID, query)
+	if err != nil {
+		return page, err
 	}
 
-	query := `SELECT * FROM products WHERE inventory_id = $1 ORDER BY created_at DESC`
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM products` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting products")
+	}
+
+	listQuery := r.db.Rebind(`SELECT * FROM products` + conditions.Where() + query.OrderAndLimit("id"))
 	products := []models.Product{}
 
-	err := r.db.Select(&products, query, inventoryID)
-	if err != nil {
-		return nil, errors.DatabaseError(err, "Error fetching products")
+	if err := r.db.Select(&products, listQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching products")
 	}
 
-	if err := r.cache.Set(key, products, TTL); err != nil {
-		return products, errors.CacheError(err, "Error caching products")
+	// The cached page carries the details, so a hit serves the whole response
+	if err := r.loadProductDetails(products); err != nil {
+		return page, err
 	}
 
-	return products, nil
+	page = utils.NewPage(products, total, query)
+
+	if err := r.cache.Set(key, page, TTL); err != nil {
+		return page, errors.CacheError(err, "Error caching products")
+	}
+
+	return page, nil
+}
+
+// SearchProducts ranks products by full-text relevance, falling back to trigram
+// similarity so misspelled terms still match
+func (r *Repository) SearchProducts(inventoryID uuid.UUID, term string, query utils.ListQuery) (utils.Page[models.Product], error) {
+	var page utils.Page[models.Product]
+
+	tsQuery := toPrefixTsQuery(term)
+	if tsQuery == "" {
+		return page, errors.ValidationError("Search query must contain letters or digits")
+	}
+
+	conditions := utils.NewConditions("inventory_id = ?", inventoryID)
+	conditions.Add(`(`+productSearchDocument+` @@ to_tsquery('simple', ?)
+		OR name % ? OR sku % ? OR code % ?)`, tsQuery, term, term, term)
+	if err := addProductFilters(conditions, query); err != nil {
+		return page, err
+	}
+
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM products` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting product search results")
+	}
+
+	searchQuery := r.db.Rebind(`SELECT * FROM products` + conditions.Where() + fmt.Sprintf(`
+		ORDER BY ts_rank(`+productSearchDocument+`, to_tsquery('simple', ?)) DESC,
+			GREATEST(similarity(name, ?), similarity(coalesce(sku, ''), ?), similarity(coalesce(code, ''), ?)) DESC,
+			id ASC
+		LIMIT %d OFFSET %d`, query.Limit, query.Offset))
+	args := append(conditions.Args(), tsQuery, term, term, term)
+
+	products := []models.Product{}
+	if err := r.db.Select(&products, searchQuery, args...); err != nil {
+		return page, errors.DatabaseError(err, "Error searching products")
+	}
+
+	if err := r.loadProductDetails(products); err != nil {
+		return page, err
+	}
+
+	return utils.NewPage(products, total, query), nil
 }
 
-func (r *Repository) GetProduct(productID uuid.UUID) (models.Product, error) {
+func (r *Repository) GetProduct(productID, inventoryID uuid.UUID) (models.Product, error) {
 	key := productCacheKey(productID)
 
 	var cachedProduct models.Product
-	if err := r.cache.Get(key, &cachedProduct); err == nil {
+	if err := r.cache.Get(key, &cachedProduct); err == nil && cachedProduct.InventoryID == inventoryID {
 		return cachedProduct, nil
 	}
 
 	var product models.Product
-	query := `SELECT * FROM products WHERE id = $1`
+	query := `SELECT * FROM products WHERE id = $1 AND inventory_id = $2`
 
-	err := r.db.Get(&product, query, productID)
+	err := r.db.Get(&product, query, productID, inventoryID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return product, errors.NotFoundError("Product not found")
@@ -87,28 +162,37 @@ func (r *Repository) GetProduct(productID uuid.UUID) (models.Product, error) {
 	return product, nil
 }
 
-func (r *Repository) GetProductWithDetails(productID uuid.UUID) (models.Product, error) {
-	product, err := r.GetProduct(productID)
-	if err != nil {
-		return product, err
+func (r *Repository) ListProductMovements(productID, inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.StockMovement], error) {
+	if _, err := r.GetProduct(productID, inventoryID); err != nil {
+		return utils.Page[models.StockMovement]{}, err
 	}
 
-	// Get product images
-	if err := r.loadProductImages(&product); err != nil {
-		return product, err
+	conditions := utils.NewConditions("sm.inventory_id = ?", inventoryID)
+	conditions.Add("sm.product_id = ?", productID)
+
+	warehouseID, err := query.UUIDFilter("warehouseId")
+	if err != nil {
+		return utils.Page[models.StockMovement]{}, err
 	}
+	if warehouseID != nil {
+		conditions.Add("sm.warehouse_id = ?", *warehouseID)
+	}
+
+	return warehouses.ListStockMovements(r.db, conditions, query)
+}
 
-	// Get product categories
-	if err := r.loadProductCategories(&product); err != nil {
+func (r *Repository) GetProductWithDetails(productID, inventoryID uuid.UUID) (models.Product, error) {
+	product, err := r.GetProduct(productID, inventoryID)
+	if err != nil {
 		return product, err
 	}
 
-	// Get warehouse stock
-	if err := r.loadWarehouseStock(&product); err != nil {
+	products := []models.Product{product}
+	if err := r.loadProductDetails(products); err != nil {
 		return product, err
 	}
 
-	return product, nil
+	return products[0], nil
 }
 
 func (r *Repository) CreateProduct(product *models.Product, categories []string) error {
@@ -118,25 +202,7 @@ func (r *Repository) CreateProduct(product *models.Product, categories []string)
 	}
 	defer tx.Rollback()
 
-	query := `
-		INSERT INTO products (
-			id, name, code, sku, brand, model, description,
-			total_quantity, total_stock, restock_level, optimal_level,
-			cost_price, selling_price, inventory_id,
-			created_at, updated_at
-		) VALUES (
-			:id, :name, :code, :sku, :brand, :model, :description,
-			:total_quantity, :total_stock, :restock_level, :optimal_level,
-			:cost_price, :selling_price, :inventory_id,
-			:created_at, :updated_at
-		)
-	`
-	_, err = tx.NamedExec(query, product)
-	if err != nil {
-		return errors.DatabaseError(err, "Error creating product")
-	}
-
-	if err := r.handleProductCategories(tx, product.ID, product.InventoryID, categories); err != nil {
+	if err := r.insertProduct(tx, product, categories); err != nil {
 		return err
 	}
 
@@ -155,49 +221,114 @@ func (r *Repository) UpdateProduct(product *models.Product, categories []string)
 	}
 	defer tx.Rollback()
 
-	query := `
-		UPDATE products SET 
-			name = :name,
-			code = :code,
-			sku = :sku,
-			brand = :brand,
-			model = :model,
-			description = :description,
-			total_quantity = :total_quantity,
-			total_stock = :total_stock,
-			restock_level = :restock_level,
-			optimal_level = :optimal_level,
-			cost_price = :cost_price,
-			selling_price = :selling_price,
-			updated_at = :updated_at
-		WHERE id = :id
-	`
-	_, err = tx.NamedExec(query, product)
+	if err := r.applyProductUpdate(tx, product, categories); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Error committing transaction")
+	}
+
+	r.invalidateProductCaches(product.ID, product.InventoryID)
+	return nil
+}
+
+// FindImportMatches returns the products of an inventory whose SKU or code is
+// among the given ones, so imported rows can update them instead
+func (r *Repository) FindImportMatches(inventoryID uuid.UUID, skus, codes []string) ([]models.Product, error) {
+	products := []models.Product{}
+	if len(skus) == 0 && len(codes) == 0 {
+		return products, nil
+	}
+
+	err := r.db.Select(&products,
+		`SELECT * FROM products
+         WHERE inventory_id = $1 AND (sku = ANY($2) OR code = ANY($3))`,
+		inventoryID, pq.Array(skus), pq.Array(codes))
 	if err != nil {
-		return errors.DatabaseError(err, "Error updating product")
+		return nil, errors.DatabaseError(err, "Error fetching products to import over")
 	}
 
-	// Update categories
-	if err := r.updateProductCategories(tx, product.ID, product.InventoryID, categories); err != nil {
-		return err
+	// Updates keep the categories of products whose file has no categories column
+	if err := r.loadProductDetails(products); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// ListWarehouseIDsByName maps the lower-cased warehouse names of an inventory to their IDs
+func (r *Repository) ListWarehouseIDsByName(inventoryID uuid.UUID) (map[string]uuid.UUID, error) {
+	var rows []struct {
+		ID   uuid.UUID `db:"id"`
+		Name string    `db:"name"`
+	}
+	err := r.db.Select(&rows, `SELECT id, name FROM warehouses WHERE inventory_id = $1`, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching warehouses")
+	}
+
+	ids := make(map[string]uuid.UUID, len(rows))
+	for _, row := range rows {
+		ids[strings.ToLower(strings.TrimSpace(row.Name))] = row.ID
+	}
+
+	return ids, nil
+}
+
+// ImportProducts writes a batch of imported rows in one transaction. New
+// products get their initial stock put away as adjustments by userID.
+func (r *Repository) ImportProducts(rows []models.ProductImportRow, inventoryID, userID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		if row.Update {
+			if err := r.applyProductUpdate(tx, row.Product, row.Categories); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := r.insertProduct(tx, row.Product, row.Categories); err != nil {
+			return err
+		}
+
+		for warehouseID, quantity := range row.Stock {
+			items := []models.StockItemRequest{{ProductID: row.Product.ID, QuantityInStock: quantity}}
+			movement := models.StockMovement{
+				InventoryID: inventoryID,
+				Reason:      models.MovementAdjustment,
+				UserID:      &userID,
+			}
+			if err := warehouses.AddStock(tx, warehouseID, items, movement); err != nil {
+				return err
+			}
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
 		return errors.DatabaseError(err, "Error committing transaction")
 	}
 
-	r.invalidateProductCaches(product.ID, product.InventoryID)
+	for _, row := range rows {
+		r.invalidateProductCaches(row.Product.ID, inventoryID)
+	}
+
 	return nil
 }
 
-func (r *Repository) DeleteProduct(productID uuid.UUID) error {
-	product, err := r.GetProduct(productID)
+func (r *Repository) DeleteProduct(productID, inventoryID uuid.UUID) error {
+	product, err := r.GetProduct(productID, inventoryID)
 	if err != nil {
 		return err
 	}
 
-	query := `DELETE FROM products WHERE id = $1`
-	_, err = r.db.Exec(query, productID)
+	query := `DELETE FROM products WHERE id = $1 AND inventory_id = $2`
+	_, err = r.db.Exec(query, productID, inventoryID)
 	if err != nil {
 		return errors.DatabaseError(err, "Error deleting product")
 	}
@@ -211,9 +342,9 @@ func (r *Repository) DeleteMultipleProducts(productIDs []uuid.UUID, inventoryID
 		return nil
 	}
 
-	query := `DELETE FROM products WHERE id = ANY($1)`
+	query := `DELETE FROM products WHERE id = ANY($1) AND inventory_id = $2`
 
-	_, err := r.db.Exec(query, pq.Array(productIDs))
+	_, err := r.db.Exec(query, pq.Array(productIDs), inventoryID)
 	if err != nil {
 		return errors.DatabaseError(err, "Error deleting multiple products")
 	}
@@ -253,11 +384,14 @@ func (r *Repository) ListProductCategories(inventoryID uuid.UUID) ([]models.Prod
 }
 
 // Image operations
-func (r *Repository) GetProductImages(productID uuid.UUID) ([]models.ProductImage, error) {
+func (r *Repository) GetProductImages(productID, inventoryID uuid.UUID) ([]models.ProductImage, error) {
 	images := []models.ProductImage{}
-	query := `SELECT * FROM product_images WHERE product_id = $1 ORDER BY is_primary DESC, created_at ASC`
+	query := `SELECT pi.* FROM product_images pi
+		JOIN products p ON p.id = pi.product_id
+		WHERE pi.product_id = $1 AND p.inventory_id = $2
+		ORDER BY pi.is_primary DESC, pi.created_at ASC`
 
-	err := r.db.Select(&images, query, productID)
+	err := r.db.Select(&images, query, productID, inventoryID)
 	if err != nil {
 		return nil, errors.DatabaseError(err, "Get Product Images")
 	}
@@ -265,15 +399,17 @@ func (r *Repository) GetProductImages(productID uuid.UUID) ([]models.ProductImag
 	return images, nil
 }
 
-func (r *Repository) GetImagesOfMultipleProducts(productIDs []uuid.UUID) ([]models.ProductImage, error) {
+func (r *Repository) GetImagesOfMultipleProducts(productIDs []uuid.UUID, inventoryID uuid.UUID) ([]models.ProductImage, error) {
 	if len(productIDs) == 0 {
 		return nil, nil
 	}
 
-	query := `SELECT file_key FROM product_images WHERE product_id = ANY($1)`
+	query := `SELECT pi.file_key FROM product_images pi
+		JOIN products p ON p.id = pi.product_id
+		WHERE pi.product_id = ANY($1) AND p.inventory_id = $2`
 
 	var images []models.ProductImage
-	err := r.db.Select(&images, query, pq.Array(productIDs))
+	err := r.db.Select(&images, query, pq.Array(productIDs), inventoryID)
 	if err != nil {
 		return nil, errors.DatabaseError(err, "Error getting multiple product images")
 	}
@@ -295,10 +431,12 @@ func (r *Repository) CreateProductImage(image *models.ProductImage) error {
 	return nil
 }
 
-func (r *Repository) DeleteProductImage(imageID uuid.UUID) error {
-	query := `DELETE FROM product_images WHERE id = $1`
+func (r *Repository) DeleteProductImage(imageID, inventoryID uuid.UUID) error {
+	query := `DELETE FROM product_images pi
+		USING products p
+		WHERE pi.id = $1 AND p.id = pi.product_id AND p.inventory_id = $2`
 
-	_, err := r.db.Exec(query, imageID)
+	_, err := r.db.Exec(query, imageID, inventoryID)
 	if err != nil {
 		return errors.DatabaseError(err, "Delete Product Image")
 	}
@@ -314,10 +452,15 @@ func (r *Repository) SetPrimaryImage(imageID, inventoryID uuid.UUID) error {
 
 	// get the product ID for this image
 	var productID uuid.UUID
-	getProductQuery := `SELECT product_id FROM product_images WHERE id = $1`
-	err = tx.Get(&productID, getProductQuery, imageID)
+	getProductQuery := `SELECT pi.product_id FROM product_images pi
+		JOIN products p ON p.id = pi.product_id
+		WHERE pi.id = $1 AND p.inventory_id = $2`
+	err = tx.Get(&productID, getProductQuery, imageID, inventoryID)
 	if err != nil {
 		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Image not found")
+		}
 		return errors.DatabaseError(err, "Get product ID for image")
 	}
 
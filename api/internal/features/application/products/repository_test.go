@@ -0,0 +1,51 @@
+package products
+
+import (
+	"testing"
+
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/google/uuid"
+)
+
+func TestProductImagesScopedToInventory(t *testing.T) {
+	db := testdb.Open(t)
+	owner := testdb.SeedInventory(t, db, "")
+	other := testdb.SeedInventory(t, db, "")
+	repo := &Repository{db: db, cache: testdb.Cache{}}
+
+	productID := testdb.SeedProduct(t, db, owner.InventoryID, "Lamp", 100, 200)
+	imageID := uuid.New()
+	testdb.Exec(t, db,
+		`INSERT INTO product_images (id, url, name, file_key, is_primary, product_id) VALUES ($1, 'https://example.com/i.webp', 'i.webp', 'i.webp', true, $2)`,
+		imageID, productID)
+
+	images, err := repo.GetProductImages(productID, other.InventoryID)
+	if err != nil {
+		t.Fatalf("GetProductImages: %v", err)
+	}
+	if len(images) != 0 {
+		t.Errorf("images = %+v, want none from another inventory", images)
+	}
+
+	if err := repo.DeleteProductImage(imageID, other.InventoryID); err != nil {
+		t.Fatalf("DeleteProductImage: %v", err)
+	}
+	images, err = repo.GetProductImages(productID, owner.InventoryID)
+	if err != nil {
+		t.Fatalf("GetProductImages: %v", err)
+	}
+	if len(images) != 1 || images[0].ID != imageID {
+		t.Fatalf("images = %+v, want the image kept after a delete from another inventory", images)
+	}
+
+	if err := repo.DeleteProductImage(imageID, owner.InventoryID); err != nil {
+		t.Fatalf("DeleteProductImage: %v", err)
+	}
+	images, err = repo.GetProductImages(productID, owner.InventoryID)
+	if err != nil {
+		t.Fatalf("GetProductImages: %v", err)
+	}
+	if len(images) != 0 {
+		t.Errorf("images = %+v, want the image deleted by its own inventory", images)
+	}
+}
@@ -0,0 +1,234 @@
+package products
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/app/venside/internal/mapper"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// ListProductVendors lists the vendors a product can be bought from, the
+// preferred one first
+func (c *Controller) ListProductVendors(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	productID, err := uuid.Parse(ctx.Param("productId"))
+	if err != nil {
+		return errors.ValidationError("Invalid product ID")
+	}
+
+	links, err := c.repo.ListProductVendors(productID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch product vendors", err, logrus.Fields{
+			"details":    err.Error(),
+			"product_id": productID,
+		})
+	}
+
+	response := make([]*models.ProductVendorResponse, len(links))
+	for i := range links {
+		response[i] = mapper.ToProductVendorResponse(&links[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// SetProductVendor links a product to a vendor or updates the link. Marking a
+// vendor preferred takes the preference away from the product's other vendors.
+func (c *Controller) SetProductVendor(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	productID, err := uuid.Parse(ctx.Param("productId"))
+	if err != nil {
+		return errors.ValidationError("Invalid product ID")
+	}
+
+	vendorID, err := uuid.Parse(ctx.Param("vendorId"))
+	if err != nil {
+		return errors.ValidationError("Invalid vendor ID")
+	}
+
+	var req models.ProductVendorRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	link := mapper.ToProductVendor(&req, productID, vendorID, inventoryID)
+	if err := c.repo.SetProductVendor(link); err != nil {
+		return logger.Error(ctx, "Failed to save product vendor", err, logrus.Fields{
+			"details":    err.Error(),
+			"product_id": productID,
+			"vendor_id":  vendorID,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, mapper.ToProductVendorResponse(link))
+}
+
+func (c *Controller) DeleteProductVendor(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	productID, err := uuid.Parse(ctx.Param("productId"))
+	if err != nil {
+		return errors.ValidationError("Invalid product ID")
+	}
+
+	vendorID, err := uuid.Parse(ctx.Param("vendorId"))
+	if err != nil {
+		return errors.ValidationError("Invalid vendor ID")
+	}
+
+	if err := c.repo.DeleteProductVendor(productID, vendorID, inventoryID); err != nil {
+		return logger.Error(ctx, "Failed to delete product vendor", err, logrus.Fields{
+			"details":    err.Error(),
+			"product_id": productID,
+			"vendor_id":  vendorID,
+		})
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (r *Repository) ListProductVendors(productID, inventoryID uuid.UUID) ([]models.ProductVendor, error) {
+	links := []models.ProductVendor{}
+	err := r.db.Select(&links, `
+		SELECT pv.*, v.company_name AS vendor_name
+		FROM product_vendors pv
+		JOIN vendors v ON v.id = pv.vendor_id
+		WHERE pv.product_id = $1 AND pv.inventory_id = $2
+		ORDER BY pv.is_preferred DESC, v.company_name`, productID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching product vendors")
+	}
+
+	return links, nil
+}
+
+func (r *Repository) SetProductVendor(link *models.ProductVendor) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	var productExists bool
+	err = tx.Get(&productExists,
+		`SELECT EXISTS(SELECT 1 FROM products WHERE id = $1 AND inventory_id = $2)`,
+		link.ProductID, link.InventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error validating product")
+	}
+	if !productExists {
+		return errors.NotFoundError("Product not found")
+	}
+
+	err = tx.Get(&link.VendorName,
+		`SELECT company_name FROM vendors WHERE id = $1 AND inventory_id = $2`,
+		link.VendorID, link.InventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ValidationError(fmt.Sprintf("Vendor with ID %s not found", link.VendorID))
+		}
+		return errors.DatabaseError(err, "Error validating vendor")
+	}
+
+	if link.IsPreferred {
+		_, err = tx.Exec(
+			`UPDATE product_vendors SET is_preferred = FALSE, updated_at = $1
+			 WHERE product_id = $2 AND vendor_id <> $3 AND is_preferred`,
+			time.Now(), link.ProductID, link.VendorID)
+		if err != nil {
+			return errors.DatabaseError(err, "Error clearing preferred vendor")
+		}
+	}
+
+	// The last cost is kept up to date by receipts, so it is only overwritten when sent
+	query := `
+		INSERT INTO product_vendors (
+			product_id, vendor_id, inventory_id, vendor_sku, lead_time_days,
+			last_cost, is_preferred, created_at, updated_at
+		) VALUES (
+			:product_id, :vendor_id, :inventory_id, :vendor_sku, :lead_time_days,
+			:last_cost, :is_preferred, :created_at, :updated_at
+		)
+		ON CONFLICT (product_id, vendor_id) DO UPDATE SET
+			vendor_sku = EXCLUDED.vendor_sku,
+			lead_time_days = EXCLUDED.lead_time_days,
+			last_cost = COALESCE(EXCLUDED.last_cost, product_vendors.last_cost),
+			is_preferred = EXCLUDED.is_preferred,
+			updated_at = EXCLUDED.updated_at
+	`
+	if _, err := tx.NamedExec(query, link); err != nil {
+		return errors.DatabaseError(err, "Error saving product vendor")
+	}
+
+	err = tx.Get(link, `
+		SELECT pv.*, v.company_name AS vendor_name
+		FROM product_vendors pv
+		JOIN vendors v ON v.id = pv.vendor_id
+		WHERE pv.product_id = $1 AND pv.vendor_id = $2`, link.ProductID, link.VendorID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching product vendor")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Error committing transaction")
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteProductVendor(productID, vendorID, inventoryID uuid.UUID) error {
+	result, err := r.db.Exec(
+		`DELETE FROM product_vendors WHERE product_id = $1 AND vendor_id = $2 AND inventory_id = $3`,
+		productID, vendorID, inventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error deleting product vendor")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError(err, "Error checking product vendor deletion")
+	}
+	if rows == 0 {
+		return errors.NotFoundError("Product vendor not found")
+	}
+
+	return nil
+}
+
+// RecordVendorCost remembers the unit cost last paid to a vendor for a product,
+// linking the two if they were not yet. It runs inside the caller's transaction.
+func RecordVendorCost(tx *sqlx.Tx, inventoryID, productID, vendorID uuid.UUID, unitCost int) error {
+	_, err := tx.Exec(`
+		INSERT INTO product_vendors (product_id, vendor_id, inventory_id, last_cost)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (product_id, vendor_id) DO UPDATE SET
+			last_cost = EXCLUDED.last_cost,
+			updated_at = CURRENT_TIMESTAMP`,
+		productID, vendorID, inventoryID, unitCost)
+	if err != nil {
+		return errors.DatabaseError(err, "Error recording vendor cost")
+	}
+
+	return nil
+}
@@ -2,24 +2,28 @@ package purchases
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/app/venside/internal/mapper"
 	"github.com/app/venside/internal/models"
 	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/errors"
 	"github.com/app/venside/pkg/logger"
+	"github.com/app/venside/pkg/mailer"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
 
 type Controller struct {
-	repo PurchaseRepository
+	repo   PurchaseRepository
+	mailer mailer.Mailer
 }
 
-func NewController(repo PurchaseRepository) PurchaseController {
+func NewController(repo PurchaseRepository, mailer mailer.Mailer) PurchaseController {
 	return &Controller{
-		repo: repo,
+		repo:   repo,
+		mailer: mailer,
 	}
 }
 
@@ -29,7 +33,12 @@ func (c *Controller) ListPurchases(ctx echo.Context) error {
 		return errors.ValidationError("Invalid inventory ID")
 	}
 
-	purchases, err := c.repo.ListPurchases(inventoryID)
+	query, err := utils.ParseListQuery(ctx, purchaseSortColumns, "createdAt", "paymentStatus", "purchaseStatus", "vendorId")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListPurchases(inventoryID, query)
 	if err != nil {
 		return logger.Error(ctx, "Failed to fetch purchases", err, logrus.Fields{
 			"details":      err.Error(),
@@ -37,21 +46,23 @@ func (c *Controller) ListPurchases(ctx echo.Context) error {
 		})
 	}
 
-	response := make([]models.PurchaseResponse, len(purchases))
-	for i, purchase := range purchases {
-		response[i] = *mapper.ToPurchaseResponse(&purchase)
-	}
+	response := utils.MapPage(page, mapper.ToPurchaseResponse)
 
 	return ctx.JSON(http.StatusOK, response)
 }
 
 func (c *Controller) GetPurchase(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
 	if err != nil {
 		return errors.ValidationError("Invalid purchase ID")
 	}
 
-	purchase, err := c.repo.GetPurchase(purchaseID)
+	purchase, err := c.repo.GetPurchase(purchaseID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to retrieve purchase", err, logrus.Fields{
 			"details":     err.Error(),
@@ -75,6 +86,9 @@ func (c *Controller) CreatePurchase(ctx echo.Context) error {
 	}
 
 	newPurchase := mapper.ToCreatePurchase(&req, inventoryID)
+	if err := pricePurchase(newPurchase, &req); err != nil {
+		return err
+	}
 
 	if err := c.repo.CreatePurchase(newPurchase); err != nil {
 		return logger.Error(ctx, "Failed to create purchase", err, logrus.Fields{
@@ -88,6 +102,90 @@ func (c *Controller) CreatePurchase(ctx echo.Context) error {
 	return ctx.JSON(http.StatusCreated, response)
 }
 
+func (c *Controller) UpdatePurchase(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid purchase ID")
+	}
+
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	var req models.PurchaseRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	existing, err := c.repo.GetPurchase(purchaseID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to retrieve purchase", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	if err := checkEditedItems(req.Items, &existing); err != nil {
+		return err
+	}
+
+	updatedPurchase := mapper.ToEditPurchase(&req, &existing)
+	if err := pricePurchase(updatedPurchase, &req); err != nil {
+		return err
+	}
+
+	if err := c.repo.UpdatePurchase(updatedPurchase, user.ID); err != nil {
+		return logger.Error(ctx, "Failed to update purchase", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	purchase, err := c.repo.GetPurchase(purchaseID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to retrieve purchase", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	response := mapper.ToPurchaseResponse(&purchase)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) ListPurchaseRevisions(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid purchase ID")
+	}
+
+	revisions, err := c.repo.ListPurchaseRevisions(purchaseID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch purchase revisions", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	response := make([]*models.DocumentRevisionResponse, len(revisions))
+	for i := range revisions {
+		response[i] = mapper.ToDocumentRevisionResponse(&revisions[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
 func (c *Controller) DeletePurchase(ctx echo.Context) error {
 	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
 	if err != nil {
@@ -108,3 +206,102 @@ func (c *Controller) DeletePurchase(ctx echo.Context) error {
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+func (c *Controller) ReceivePurchase(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid purchase ID")
+	}
+
+	var req models.ReceivePurchaseRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	receipt := mapper.ToPurchaseReceipt(&req, user.ID)
+
+	if err := c.repo.ReceivePurchase(purchaseID, inventoryID, receipt); err != nil {
+		return logger.Error(ctx, "Failed to receive purchase", err, logrus.Fields{
+			"details":      err.Error(),
+			"purchase_id":  purchaseID,
+			"warehouse_id": receipt.WarehouseID,
+		})
+	}
+
+	purchase, err := c.repo.GetPurchase(purchaseID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to retrieve purchase", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	response := mapper.ToPurchaseResponse(&purchase)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) ListPurchasePayments(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid purchase ID")
+	}
+
+	payments, err := c.repo.ListPurchasePayments(purchaseID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch purchase payments", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	response := make([]*models.PurchasePaymentResponse, len(payments))
+	for i := range payments {
+		response[i] = mapper.ToPurchasePaymentResponse(&payments[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) CreatePurchasePayment(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid purchase ID")
+	}
+
+	var req models.PurchasePaymentRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	payment := mapper.ToCreatePurchasePayment(&req, purchaseID, time.Now())
+
+	if err := c.repo.CreatePurchasePayment(payment, inventoryID); err != nil {
+		return logger.Error(ctx, "Failed to record purchase payment", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	response := mapper.ToPurchasePaymentResponse(payment)
+	return ctx.JSON(http.StatusCreated, response)
+}
@@ -0,0 +1,84 @@
+package purchases
+
+import (
+	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+var purchaseExportColumns = []utils.ExportColumn[models.Purchase]{
+	{Header: "Number", Key: "purchaseNumber", Value: func(p *models.Purchase) any { return p.PurchaseNumber }},
+	{Header: "Date", Key: "purchaseDate", Value: func(p *models.Purchase) any { return p.PurchaseDate }},
+	{Header: "Vendor", Key: "vendorName", Value: func(p *models.Purchase) any { return p.VendorName }},
+	{Header: "ETA", Key: "eta", Value: func(p *models.Purchase) any { return p.Eta }},
+	{Header: "Delivery date", Key: "deliveryDate", Value: func(p *models.Purchase) any { return p.DeliveryDate }},
+	{Header: "Status", Key: "purchaseStatus", Value: func(p *models.Purchase) any { return p.PurchaseStatus }},
+	{Header: "Payment status", Key: "paymentStatus", Value: func(p *models.Purchase) any { return p.PaymentStatus }},
+	{Header: "Shipping", Key: "shippingCost", Money: true, Value: func(p *models.Purchase) any { return p.ShippingCost }},
+	{Header: "Discount", Key: "discountAmount", Money: true, Value: func(p *models.Purchase) any { return p.DiscountAmount }},
+	{Header: "Discount %", Key: "discountPercent", Value: func(p *models.Purchase) any { return p.DiscountPercent }},
+	{Header: "Total", Key: "totalAmount", Money: true, Value: func(p *models.Purchase) any { return p.TotalAmount }},
+	{Header: "Balance", Key: "balance", Money: true, Value: func(p *models.Purchase) any { return p.Balance }},
+	{Header: "Created at", Key: "createdAt", Value: func(p *models.Purchase) any { return p.CreatedAt }},
+}
+
+// ExportPurchases downloads every purchase matching the list filters as CSV, XLSX or JSON
+func (c *Controller) ExportPurchases(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	query, err := utils.ParseListQuery(ctx, purchaseSortColumns, "createdAt", "paymentStatus", "purchaseStatus", "vendorId")
+	if err != nil {
+		return err
+	}
+
+	format, err := utils.ParseExportFormat(ctx)
+	if err != nil {
+		return err
+	}
+
+	currency, err := c.repo.GetCurrency(inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch inventory currency", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	exporter := utils.NewExporter(ctx, format, "purchases", purchaseExportColumns, currency.Code, currency.Locale)
+	if err := c.repo.ExportPurchases(inventoryID, query, exporter.Write); err != nil {
+		return logger.Error(ctx, "Failed to export purchases", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	return exporter.Close()
+}
+
+// ExportPurchases streams the purchases matching the list filters to write, in list
+// order and without paging
+func (r *Repository) ExportPurchases(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Purchase) error) error {
+	conditions, err := purchaseListConditions(inventoryID, query)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.db.Queryx(r.db.Rebind(`SELECT `+purchaseColumns+` FROM purchases`+conditions.Where()+query.OrderBy("id")), conditions.Args()...)
+	if err != nil {
+		return errors.DatabaseError(err, "Error exporting purchases")
+	}
+
+	return utils.EachRow(rows, write)
+}
+
+func (r *Repository) GetCurrency(inventoryID uuid.UUID) (models.Currency, error) {
+	return inventories.GetCurrency(r.db, inventoryID)
+}
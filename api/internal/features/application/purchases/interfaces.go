@@ -2,20 +2,47 @@ package purchases
 
 import (
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type PurchaseRepository interface {
-	ListPurchases(inventoryID uuid.UUID) ([]models.Purchase, error)
-	GetPurchase(PurchaseID uuid.UUID) (models.Purchase, error)
+	ListPurchases(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Purchase], error)
+	ExportPurchases(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Purchase) error) error
+	GetCurrency(inventoryID uuid.UUID) (models.Currency, error)
+	GetPurchase(PurchaseID, inventoryID uuid.UUID) (models.Purchase, error)
 	CreatePurchase(Purchase *models.Purchase) error
+	UpdatePurchase(purchase *models.Purchase, editedBy uuid.UUID) error
 	DeletePurchase(PurchaseID, inventoryID uuid.UUID) error
+	ListPurchaseRevisions(purchaseID, inventoryID uuid.UUID) ([]models.DocumentRevision, error)
+	ReceivePurchase(purchaseID, inventoryID uuid.UUID, receipt *models.PurchaseReceipt) error
+	ListPurchasePayments(purchaseID, inventoryID uuid.UUID) ([]models.PurchasePayment, error)
+	CreatePurchasePayment(payment *models.PurchasePayment, inventoryID uuid.UUID) error
+	GetPurchaseDocument(purchaseID, inventoryID uuid.UUID) (models.PurchaseDocument, error)
+	ListReorderLines(inventoryID uuid.UUID, warehouseID *uuid.UUID, productIDs []uuid.UUID) ([]models.ReorderLine, error)
+	ListReturns(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.PurchaseReturn], error)
+	GetPurchaseReturn(returnID, inventoryID uuid.UUID) (models.PurchaseReturn, error)
+	ListPurchaseReturns(purchaseID, inventoryID uuid.UUID) ([]models.PurchaseReturn, error)
+	CreatePurchaseReturn(ret *models.PurchaseReturn) error
 }
 
 type PurchaseController interface {
 	ListPurchases(ctx echo.Context) error
+	ExportPurchases(ctx echo.Context) error
 	GetPurchase(ctx echo.Context) error
 	CreatePurchase(ctx echo.Context) error
+	UpdatePurchase(ctx echo.Context) error
 	DeletePurchase(ctx echo.Context) error
+	ListPurchaseRevisions(ctx echo.Context) error
+	ReceivePurchase(ctx echo.Context) error
+	ListPurchasePayments(ctx echo.Context) error
+	CreatePurchasePayment(ctx echo.Context) error
+	GetPurchaseDocument(ctx echo.Context) error
+	EmailPurchase(ctx echo.Context) error
+	GenerateDraftPurchases(ctx echo.Context) error
+	ListReturns(ctx echo.Context) error
+	GetPurchaseReturn(ctx echo.Context) error
+	ListPurchaseReturns(ctx echo.Context) error
+	CreatePurchaseReturn(ctx echo.Context) error
 }
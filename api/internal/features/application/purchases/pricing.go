@@ -0,0 +1,54 @@
+package purchases
+
+import (
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/google/uuid"
+)
+
+// pricePurchase derives every amount of a purchase from its quantities and prices.
+//
+// Each line is quantity * unit price, less its percentage discount, less its
+// fixed discount. The purchase total is the sum of the lines, less the order
+// percentage discount, less the order fixed discount, plus shipping. Shipping
+// is never discounted. Percentages round half up.
+//
+// Totals sent by the client are optional but must agree with the computed ones.
+func pricePurchase(purchase *models.Purchase, req *models.PurchaseRequest) error {
+	lines := make([]utils.LinePrice, len(purchase.Items))
+	for i, item := range purchase.Items {
+		lines[i] = utils.LinePrice{
+			Quantity:        item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			DiscountPercent: item.DiscountPercent,
+			DiscountAmount:  item.DiscountAmount,
+			Subtotal:        req.Items[i].Subtotal,
+		}
+	}
+
+	subtotals, sum, err := utils.PriceLines(lines)
+	if err != nil {
+		return err
+	}
+	for i := range purchase.Items {
+		purchase.Items[i].Subtotal = subtotals[i]
+	}
+
+	purchase.TotalAmount, err = utils.PriceTotal(sum, purchase.DiscountPercent, purchase.DiscountAmount,
+		purchase.ShippingCost, req.TotalAmount, "purchase")
+	return err
+}
+
+// checkEditedItems makes sure every line ID sent with an edit belongs to the purchase
+func checkEditedItems(items []models.PurchaseItemRequest, existing *models.Purchase) error {
+	existingIDs := make([]uuid.UUID, len(existing.Items))
+	for i, item := range existing.Items {
+		existingIDs[i] = item.ID
+	}
+	editedIDs := make([]*string, len(items))
+	for i, item := range items {
+		editedIDs[i] = item.ID
+	}
+
+	return utils.CheckEditedLines(existingIDs, editedIDs, "purchase")
+}
@@ -0,0 +1,106 @@
+package purchases
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/app/venside/internal/models"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestPricePurchase(t *testing.T) {
+	tests := []struct {
+		name          string
+		items         []models.PurchaseItemRequest
+		percent       int
+		fixed         int
+		shipping      int
+		total         *int
+		wantSubtotals []int
+		wantTotal     int
+		wantErr       string
+	}{
+		{
+			name: "line and order discounts with shipping",
+			items: []models.PurchaseItemRequest{
+				{Quantity: 10, UnitPrice: 125, DiscountPercent: 3},
+				{Quantity: 4, UnitPrice: 999, DiscountAmount: 96},
+			},
+			percent:       10,
+			fixed:         50,
+			shipping:      1500,
+			wantSubtotals: []int{1212, 3900}, // 1250 - 37.5 rounded up
+			wantTotal:     6051,              // 5112 - 511 - 50 + 1500
+		},
+		{
+			name: "shipping is never discounted",
+			items: []models.PurchaseItemRequest{
+				{Quantity: 1, UnitPrice: 1000},
+			},
+			percent:   100,
+			shipping:  700,
+			total:     intPtr(700),
+			wantTotal: 700,
+		},
+		{
+			name: "client total that does not match",
+			items: []models.PurchaseItemRequest{
+				{Quantity: 1, UnitPrice: 1000},
+			},
+			shipping: 200,
+			total:    intPtr(1000),
+			wantErr:  "Total amount 1000 does not match the computed 1200",
+		},
+		{
+			name: "client subtotal that does not match",
+			items: []models.PurchaseItemRequest{
+				{Quantity: 3, UnitPrice: 100, DiscountPercent: 50, Subtotal: intPtr(149)},
+			},
+			wantErr: "Item 1: subtotal 149 does not match the computed 150",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.PurchaseRequest{
+				DiscountPercent: tt.percent,
+				DiscountAmount:  tt.fixed,
+				ShippingCost:    tt.shipping,
+				TotalAmount:     tt.total,
+				Items:           tt.items,
+			}
+			purchase := &models.Purchase{DiscountPercent: tt.percent, DiscountAmount: tt.fixed, ShippingCost: tt.shipping}
+			for _, item := range tt.items {
+				purchase.Items = append(purchase.Items, models.PurchaseItem{
+					Quantity:        item.Quantity,
+					UnitPrice:       item.UnitPrice,
+					DiscountPercent: item.DiscountPercent,
+					DiscountAmount:  item.DiscountAmount,
+				})
+			}
+
+			err := pricePurchase(purchase, req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pricePurchase: %v", err)
+			}
+
+			for i, want := range tt.wantSubtotals {
+				if purchase.Items[i].Subtotal != want {
+					t.Errorf("item %d subtotal = %d, want %d", i+1, purchase.Items[i].Subtotal, want)
+				}
+			}
+			if purchase.TotalAmount != tt.wantTotal {
+				t.Errorf("total = %d, want %d", purchase.TotalAmount, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package purchases
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/app/venside/pkg/mailer"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetPurchaseDocument renders a purchase as a PDF purchase order for the vendor
+func (c *Controller) GetPurchaseDocument(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid purchase ID")
+	}
+
+	doc, err := c.repo.GetPurchaseDocument(purchaseID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to print purchase order", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s"`, purchaseOrderFilename(&doc)))
+
+	return ctx.Blob(http.StatusOK, "application/pdf", utils.RenderDocumentPDF(purchaseOrder(&doc)))
+}
+
+// EmailPurchase sends the purchase order PDF to the vendor, or to the given
+// recipients. Replies go to the member who sent it.
+func (c *Controller) EmailPurchase(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid purchase ID")
+	}
+
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	var req models.PurchaseEmailRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	doc, err := c.repo.GetPurchaseDocument(purchaseID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to print purchase order", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	message := purchaseOrderEmail(&doc, &req)
+	message.ReplyTo = user.Email
+	if len(message.To) == 0 {
+		return errors.ValidationError("The vendor has no email address; add recipients to send the purchase order")
+	}
+
+	if err := c.mailer.Send(ctx.Request().Context(), message); err != nil {
+		return logger.Error(ctx, "Failed to email purchase order", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	logger.Info("Purchase order emailed", logrus.Fields{
+		"purchase_id": purchaseID,
+		"to":          message.To,
+		"sent_by":     user.ID,
+	})
+
+	return ctx.JSON(http.StatusOK, models.PurchaseEmailResponse{
+		To:      message.To,
+		Cc:      message.Cc,
+		Subject: message.Subject,
+		SentAt:  time.Now(),
+	})
+}
+
+// GetPurchaseDocument loads a purchase with everything its purchase order shows
+func (r *Repository) GetPurchaseDocument(purchaseID, inventoryID uuid.UUID) (models.PurchaseDocument, error) {
+	var doc models.PurchaseDocument
+
+	purchase, err := r.GetPurchase(purchaseID, inventoryID)
+	if err != nil {
+		return doc, err
+	}
+	doc.Purchase = purchase
+
+	if err := r.db.Get(&doc.InventoryName, `SELECT name FROM inventories WHERE id = $1`, inventoryID); err != nil {
+		return doc, errors.DatabaseError(err, "Error fetching inventory name")
+	}
+
+	// The purchase keeps the vendor's name even if the vendor was deleted since
+	if purchase.VendorID != nil {
+		var vendor models.Vendor
+		err := r.db.Get(&vendor, `SELECT * FROM vendors WHERE id = $1 AND inventory_id = $2`, *purchase.VendorID, inventoryID)
+		if err != nil && err != sql.ErrNoRows {
+			return doc, errors.DatabaseError(err, "Error fetching purchase vendor")
+		}
+		if err == nil {
+			doc.Vendor = &vendor
+		}
+	}
+
+	if doc.Currency, err = inventories.GetCurrency(r.db, inventoryID); err != nil {
+		return doc, err
+	}
+
+	if doc.Template, err = inventories.GetDocumentTemplate(r.db, inventoryID, models.DocumentLayoutPurchaseOrder); err != nil {
+		return doc, err
+	}
+
+	return doc, nil
+}
+
+// CONTROLLER HELPERS
+
+func purchaseOrder(doc *models.PurchaseDocument) utils.PrintedDocument {
+	purchase := &doc.Purchase
+	money := func(amount int) string {
+		return utils.PrintedMoney(amount, doc.Currency.Code, doc.Currency.Locale)
+	}
+
+	expected := "-"
+	if purchase.Eta != nil {
+		expected = purchase.Eta.Format("2006-01-02")
+	}
+
+	printed := utils.PrintedDocument{
+		PaperSize:   doc.Template.PaperSize,
+		Title:       doc.Template.Title,
+		Issuer:      doc.InventoryName,
+		IssuerLines: utils.TextLines(doc.Template.Header),
+		Details: [][2]string{
+			{"PO number", purchase.PurchaseNumber},
+			{"Order date", purchase.PurchaseDate.Format("2006-01-02")},
+			{"Expected", expected},
+		},
+		PartyTitle: "Vendor",
+		PartyLines: purchaseVendorLines(doc),
+		Items: utils.PrintedTable{
+			Columns: []utils.PrintedColumn{
+				{Header: "Item", Width: 0.33},
+				{Header: "SKU", Width: 0.14},
+				{Header: "Qty", Width: 0.08, Right: true},
+				{Header: "Unit price", Width: 0.15, Right: true},
+				{Header: "Discount", Width: 0.13, Right: true},
+				{Header: "Amount", Width: 0.17, Right: true},
+			},
+		},
+		Footer: doc.Template.Footer,
+	}
+
+	subtotal := 0
+	for _, item := range purchase.Items {
+		name, sku := "Deleted product", ""
+		if item.Product != nil {
+			name, sku = item.Product.Name, item.Product.SKU
+		}
+
+		printed.Items.Rows = append(printed.Items.Rows, []string{
+			name,
+			sku,
+			strconv.Itoa(item.Quantity),
+			money(item.UnitPrice),
+			utils.DiscountText(item.DiscountPercent, item.DiscountAmount, money),
+			money(item.Subtotal),
+		})
+		subtotal += item.Subtotal
+	}
+
+	printed.Totals = []utils.PrintedTotal{{Label: "Subtotal", Value: money(subtotal)}}
+	if discount := subtotal + purchase.ShippingCost - purchase.TotalAmount; discount != 0 {
+		label := "Discount"
+		if purchase.DiscountPercent > 0 {
+			label = fmt.Sprintf("Discount (%d%%)", purchase.DiscountPercent)
+		}
+		printed.Totals = append(printed.Totals, utils.PrintedTotal{Label: label, Value: money(-discount)})
+	}
+	if purchase.ShippingCost > 0 {
+		printed.Totals = append(printed.Totals, utils.PrintedTotal{Label: "Shipping", Value: money(purchase.ShippingCost)})
+	}
+	printed.Totals = append(printed.Totals, utils.PrintedTotal{Label: "Total", Value: money(purchase.TotalAmount), Bold: true})
+
+	return printed
+}
+
+func purchaseVendorLines(doc *models.PurchaseDocument) []string {
+	if doc.Vendor == nil {
+		if doc.Purchase.VendorName == "" {
+			return nil
+		}
+		return []string{doc.Purchase.VendorName}
+	}
+
+	lines := []string{doc.Vendor.CompanyName}
+	if doc.Vendor.ContactName != nil && strings.TrimSpace(*doc.Vendor.ContactName) != "" {
+		lines = append(lines, "Attn: "+strings.TrimSpace(*doc.Vendor.ContactName))
+	}
+	for _, value := range []*string{doc.Vendor.Address, doc.Vendor.Phone, doc.Vendor.Email} {
+		if value != nil {
+			lines = append(lines, utils.TextLines(*value)...)
+		}
+	}
+	return lines
+}
+
+func purchaseOrderFilename(doc *models.PurchaseDocument) string {
+	return fmt.Sprintf("purchase-order-%s.pdf", doc.Purchase.PurchaseNumber)
+}
+
+// purchaseOrderEmail builds the email carrying a purchase order. The request
+// may replace the recipients, subject and message.
+func purchaseOrderEmail(doc *models.PurchaseDocument, req *models.PurchaseEmailRequest) mailer.Message {
+	purchase := &doc.Purchase
+
+	to := req.To
+	if len(to) == 0 && doc.Vendor != nil && doc.Vendor.Email != nil && strings.TrimSpace(*doc.Vendor.Email) != "" {
+		to = []string{strings.TrimSpace(*doc.Vendor.Email)}
+	}
+
+	subject := strings.TrimSpace(req.Subject)
+	if subject == "" {
+		subject = fmt.Sprintf("Purchase order %s from %s", purchase.PurchaseNumber, doc.InventoryName)
+	}
+
+	body := strings.TrimSpace(req.Message)
+	if body == "" {
+		greeting := purchase.VendorName
+		if doc.Vendor != nil && doc.Vendor.ContactName != nil && strings.TrimSpace(*doc.Vendor.ContactName) != "" {
+			greeting = strings.TrimSpace(*doc.Vendor.ContactName)
+		}
+
+		body = fmt.Sprintf("Hello %s,\n\nPlease find attached purchase order %s.", greeting, purchase.PurchaseNumber)
+		if purchase.Eta != nil {
+			body += fmt.Sprintf(" We expect delivery by %s.", purchase.Eta.Format("2006-01-02"))
+		}
+		body += fmt.Sprintf("\n\nKind regards,\n%s", doc.InventoryName)
+	}
+
+	return mailer.Message{
+		To:      to,
+		Cc:      req.Cc,
+		Subject: subject,
+		Body:    body,
+		Attachments: []mailer.Attachment{{
+			Filename:    purchaseOrderFilename(doc),
+			ContentType: "application/pdf",
+			Data:        utils.RenderDocumentPDF(purchaseOrder(doc)),
+		}},
+	}
+}
@@ -0,0 +1,196 @@
+package purchases
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/app/venside/internal/features/application/products"
+	"github.com/app/venside/internal/mapper"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// GenerateDraftPurchases turns the low-stock products into draft purchases, one
+// per preferred vendor. Quantities bring stock back to the optimal level less
+// what is already on open purchases, so generating twice does not order twice.
+// Lines are priced at the last unit price paid to the vendor.
+func (c *Controller) GenerateDraftPurchases(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	var req models.GenerateDraftsRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	var warehouseID *uuid.UUID
+	if req.WarehouseID != nil {
+		parsedID, _ := uuid.Parse(*req.WarehouseID)
+		warehouseID = &parsedID
+	}
+
+	productIDs := make([]uuid.UUID, len(req.ProductIDs))
+	for i, id := range req.ProductIDs {
+		productIDs[i], _ = uuid.Parse(id)
+	}
+
+	lines, err := c.repo.ListReorderLines(inventoryID, warehouseID, productIDs)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch reorder suggestions", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	drafts, skipped := draftPurchaseRequests(lines)
+
+	response := models.GenerateDraftsResponse{
+		Purchases: []models.PurchaseResponse{},
+		Skipped:   skipped,
+	}
+
+	// Drafts are saved one by one; those created before a failure are kept
+	for _, draft := range drafts {
+		purchase := mapper.ToCreatePurchase(&draft, inventoryID)
+		if err := pricePurchase(purchase, &draft); err != nil {
+			return err
+		}
+
+		if err := c.repo.CreatePurchase(purchase); err != nil {
+			return logger.Error(ctx, "Failed to create draft purchase", err, logrus.Fields{
+				"details":   err.Error(),
+				"vendor_id": purchase.VendorID,
+				"created":   len(response.Purchases),
+			})
+		}
+
+		response.Purchases = append(response.Purchases, *mapper.ToPurchaseResponse(purchase))
+	}
+
+	status := http.StatusOK
+	if len(response.Purchases) > 0 {
+		status = http.StatusCreated
+	}
+
+	return ctx.JSON(status, response)
+}
+
+// ListReorderLines lists the low-stock products with their preferred vendor,
+// the quantity still to order and the last unit price paid to that vendor.
+// Without a purchase from the vendor yet, the vendor's last cost and then the
+// product's cost price are used.
+func (r *Repository) ListReorderLines(inventoryID uuid.UUID, warehouseID *uuid.UUID, productIDs []uuid.UUID) ([]models.ReorderLine, error) {
+	// Like the low-stock list, one warehouse's stock is checked when given
+	stock, join, args := "p.total_stock", "", []interface{}{}
+	if warehouseID != nil {
+		join, args = products.WarehouseStockJoin("p", *warehouseID)
+		stock = "ws.quantity"
+	}
+
+	conditions := utils.NewConditions("p.inventory_id = ?", inventoryID)
+	conditions.Add("p.restock_level > 0")
+	conditions.Add(stock + " <= p.restock_level")
+	if len(productIDs) > 0 {
+		conditions.Add("p.id = ANY(?)", pq.Array(productIDs))
+	}
+	args = append(args, conditions.Args()...)
+
+	query := fmt.Sprintf(`
+		SELECT p.id AS product_id, p.name AS product_name,
+		       pv.vendor_id, v.company_name AS vendor_name,
+		       COALESCE(pv.lead_time_days, 0) AS lead_time_days,
+		       GREATEST(GREATEST(p.optimal_level, p.restock_level) - %[1]s - COALESCE(open.quantity, 0), 0) AS quantity,
+		       COALESCE(last.unit_price, pv.last_cost, p.cost_price) AS unit_price
+		FROM products p
+		%[2]s
+		LEFT JOIN product_vendors pv ON pv.product_id = p.id AND pv.is_preferred
+		LEFT JOIN vendors v ON v.id = pv.vendor_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(pi.quantity - pi.received_quantity) AS quantity
+			FROM purchase_items pi
+			JOIN purchases pu ON pu.id = pi.purchase_id
+			WHERE pi.product_id = p.id AND pu.purchase_status IN ('draft', 'ordered', 'shipped')
+		) open ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT pi.unit_price
+			FROM purchase_items pi
+			JOIN purchases pu ON pu.id = pi.purchase_id
+			WHERE pi.product_id = p.id AND pu.vendor_id = pv.vendor_id AND pu.purchase_status <> 'cancelled'
+			ORDER BY pu.purchase_date DESC, pu.created_at DESC
+			LIMIT 1
+		) last ON TRUE
+		%[3]s
+		ORDER BY v.company_name NULLS LAST, pv.vendor_id, p.name`, stock, join, conditions.Where())
+
+	lines := []models.ReorderLine{}
+	if err := r.db.Select(&lines, r.db.Rebind(query), args...); err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching reorder lines")
+	}
+
+	return lines, nil
+}
+
+// CONTROLLER HELPERS
+
+// draftPurchaseRequests groups reorder lines into one draft purchase request
+// per vendor, in the order the vendors come in, and reports the lines left out
+func draftPurchaseRequests(lines []models.ReorderLine) ([]models.PurchaseRequest, []models.SkippedReorderLine) {
+	drafts := []models.PurchaseRequest{}
+	skipped := []models.SkippedReorderLine{}
+	byVendor := make(map[uuid.UUID]int)
+	leadTimes := make(map[uuid.UUID]int)
+
+	for _, line := range lines {
+		switch {
+		case line.VendorID == nil:
+			skipped = append(skipped, models.SkippedReorderLine{
+				ProductID: line.ProductID, Name: line.ProductName, Reason: "No preferred vendor",
+			})
+			continue
+		case line.Quantity == 0:
+			skipped = append(skipped, models.SkippedReorderLine{
+				ProductID: line.ProductID, Name: line.ProductName, Reason: "Already on order",
+			})
+			continue
+		}
+
+		index, ok := byVendor[*line.VendorID]
+		if !ok {
+			vendorID := line.VendorID.String()
+			drafts = append(drafts, models.PurchaseRequest{
+				VendorID:       &vendorID,
+				VendorName:     line.VendorName,
+				PaymentStatus:  "pending",
+				PurchaseStatus: "draft",
+			})
+			index = len(drafts) - 1
+			byVendor[*line.VendorID] = index
+		}
+
+		drafts[index].Items = append(drafts[index].Items, models.PurchaseItemRequest{
+			ProductID: line.ProductID.String(),
+			Quantity:  line.Quantity,
+			UnitPrice: line.UnitPrice,
+		})
+		leadTimes[*line.VendorID] = max(leadTimes[*line.VendorID], line.LeadTimeDays)
+	}
+
+	// Expect delivery after the slowest line of each vendor
+	for vendorID, index := range byVendor {
+		if days := leadTimes[vendorID]; days > 0 {
+			eta := time.Now().AddDate(0, 0, days)
+			drafts[index].Eta = &eta
+		}
+	}
+
+	return drafts, skipped
+}
@@ -0,0 +1,32 @@
+package purchases
+
+import (
+	"testing"
+
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/google/uuid"
+)
+
+func TestListReorderLinesInWarehouse(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+	repo := NewRepository(db, testdb.Cache{})
+
+	// The cable sold out of the main warehouse and the hub was never stocked there
+	cable := testdb.SeedProduct(t, db, seed.InventoryID, "Cable", 100, 200)
+	testdb.SeedProduct(t, db, seed.InventoryID, "Hub", 100, 200)
+	testdb.Exec(t, db, `UPDATE products SET restock_level = 5, optimal_level = 20 WHERE inventory_id = $1`, seed.InventoryID)
+	testdb.Exec(t, db,
+		`INSERT INTO stock_movements (id, inventory_id, product_id, warehouse_id, quantity, reason)
+         VALUES ($1, $3, $4, $5, 10, 'receipt'), ($2, $3, $4, $5, -10, 'sale')`,
+		uuid.New(), uuid.New(), seed.InventoryID, cable, seed.WarehouseID)
+
+	lines, err := repo.ListReorderLines(seed.InventoryID, &seed.WarehouseID, nil)
+	if err != nil {
+		t.Fatalf("ListReorderLines: %v", err)
+	}
+
+	if len(lines) != 1 || lines[0].ProductID != cable || lines[0].Quantity != 20 {
+		t.Fatalf("lines = %+v, want 20 of the sold-out cable", lines)
+	}
+}
@@ -2,15 +2,21 @@ package purchases
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"math/rand"
+	"strings"
 	"time"
 
+	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/features/application/products"
+	"github.com/app/venside/internal/features/application/warehouses"
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/cache"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type Repository struct {
@@ -34,82 +40,72 @@ const (
 	TTL = 30 * 24 * time.Hour
 )
 
-func (r *Repository) ListPurchases(inventoryID uuid.UUID) ([]models.Purchase, error) {
-	key := purchaseListCacheKey(inventoryID)
+// purchaseColumns selects a purchase along with the name of its vendor
+const purchaseColumns = `purchases.*,
+	COALESCE((SELECT company_name FROM vendors WHERE vendors.id = purchases.vendor_id), '') AS vendor_name`
+
+// purchaseSortColumns maps the sortable API fields of purchases to their columns
+var purchaseSortColumns = map[string]string{
+	"createdAt":      "created_at",
+	"purchaseDate":   "purchase_date",
+	"purchaseNumber": "purchase_number",
+	"eta":            "eta",
+	"totalAmount":    "total_amount",
+	"balance":        "balance",
+}
 
-	var cachedPurchases []models.Purchase
-	if err := r.cache.Get(key, &cachedPurchases); err == nil {
-		return cachedPurchases, nil
-	}
+func (r *Repository) ListPurchases(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Purchase], error) {
+	var page utils.Page[models.Purchase]
+	key := utils.PageCacheKey(r.cache, purchaseListCacheKey(inventoryID), query, TTL)
 
-	purchasesQuery := `SELECT * FROM purchases WHERE inventory_id = $1 ORDER BY created_at DESC`
-	purchases := []models.Purchase{}
+	if err := r.cache.Get(key, &page); err == nil {
+		return page, nil
+	}
 
-	err := r.db.Select(&purchases, purchasesQuery, inventoryID)
+	conditions, err := purchaseListConditions(inventoryID, query)
 	if err != nil {
-		return nil, errors.DatabaseError(err, "Error fetching purchases")
+		return page, err
 	}
 
-	for i := range purchases {
-		itemsQuery := `
-    SELECT 
-        pi.id, pi.purchase_id, pi.product_id, pi.quantity, 
-        pi.unit_price, pi.subtotal, pi.created_at,
-        p.id as "product.id", p.name as "product.name", 
-        p.code as "product.code", p.sku as "product.sku",
-        p.brand as "product.brand", p.model as "product.model",
-        p.description as "product.description", p.total_quantity as "product.total_quantity",
-        p.total_stock as "product.total_stock", p.restock_level as "product.restock_level",
-        p.optimal_level as "product.optimal_level", p.cost_price as "product.cost_price",
-        p.selling_price as "product.selling_price", p.inventory_id as "product.inventory_id",
-        p.created_at as "product.created_at", p.updated_at as "product.updated_at"
-    FROM purchase_items pi
-    LEFT JOIN products p ON pi.product_id = p.id
-    WHERE pi.purchase_id = $1
-    ORDER BY pi.created_at ASC
-`
-
-		type PurchaseItemWithProduct struct {
-			models.PurchaseItem
-			Product models.Product
-		}
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM purchases` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting purchases")
+	}
 
-		var itemsWithProducts []PurchaseItemWithProduct
-		err := r.db.Select(&itemsWithProducts, itemsQuery, purchases[i].ID)
-		if err != nil {
-			return nil, errors.DatabaseError(err, "Error fetching purchase items")
-		}
+	purchasesQuery := r.db.Rebind(`SELECT ` + purchaseColumns + ` FROM purchases` + conditions.Where() + query.OrderAndLimit("id"))
+	purchases := []models.Purchase{}
 
-		items := make([]models.PurchaseItem, len(itemsWithProducts))
-		for j, itemWithProduct := range itemsWithProducts {
-			items[j] = itemWithProduct.PurchaseItem
-			if itemWithProduct.Product.ID != uuid.Nil {
-				items[j].Product = &itemWithProduct.Product
-			}
-		}
+	if err := r.db.Select(&purchases, purchasesQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching purchases")
+	}
 
-		purchases[i].Items = items
+	// Fetch the items of the whole page in a single query
+	if err := r.loadPurchaseItems(purchases); err != nil {
+		return page, err
 	}
 
-	if err := r.cache.Set(key, purchases, TTL); err != nil {
-		return purchases, errors.CacheError(err, "Error caching purchases")
+	page = utils.NewPage(purchases, total, query)
+
+	if err := r.cache.Set(key, page, TTL); err != nil {
+		return page, errors.CacheError(err, "Error caching purchases")
 	}
 
-	return purchases, nil
+	return page, nil
 }
 
-func (r *Repository) GetPurchase(purchaseID uuid.UUID) (models.Purchase, error) {
+func (r *Repository) GetPurchase(purchaseID, inventoryID uuid.UUID) (models.Purchase, error) {
 	key := purchaseCacheKey(purchaseID)
 
 	var cachedPurchase models.Purchase
-	if err := r.cache.Get(key, &cachedPurchase); err == nil {
+	if err := r.cache.Get(key, &cachedPurchase); err == nil && cachedPurchase.InventoryID == inventoryID {
 		return cachedPurchase, nil
 	}
 
 	var purchase models.Purchase
-	query := `SELECT * FROM purchases WHERE id = $1`
+	query := `SELECT ` + purchaseColumns + ` FROM purchases WHERE id = $1 AND inventory_id = $2`
 
-	err := r.db.Get(&purchase, query, purchaseID)
+	err := r.db.Get(&purchase, query, purchaseID, inventoryID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return purchase, errors.NotFoundError("Purchase not found")
@@ -117,44 +113,11 @@ func (r *Repository) GetPurchase(purchaseID uuid.UUID) (models.Purchase, error)
 		return purchase, errors.DatabaseError(err, "Error getting purchase by ID")
 	}
 
-	itemsQuery := `
-    SELECT 
-        pi.id, pi.purchase_id, pi.product_id, pi.quantity, 
-        pi.unit_price, pi.subtotal, pi.created_at,
-        p.id as "product.id", p.name as "product.name", 
-        p.code as "product.code", p.sku as "product.sku",
-        p.brand as "product.brand", p.model as "product.model",
-        p.description as "product.description", p.total_quantity as "product.total_quantity",
-        p.total_stock as "product.total_stock", p.restock_level as "product.restock_level",
-        p.optimal_level as "product.optimal_level", p.cost_price as "product.cost_price",
-        p.selling_price as "product.selling_price", p.inventory_id as "product.inventory_id",
-        p.created_at as "product.created_at", p.updated_at as "product.updated_at"
-    FROM purchase_items pi
-    LEFT JOIN products p ON pi.product_id = p.id
-    WHERE pi.purchase_id = $1
-    ORDER BY pi.created_at ASC
-`
-
-	type PurchaseItemWithProduct struct {
-		models.PurchaseItem
-		Product models.Product
+	purchases := []models.Purchase{purchase}
+	if err := r.loadPurchaseItems(purchases); err != nil {
+		return purchase, err
 	}
-
-	var itemsWithProducts []PurchaseItemWithProduct
-	err = r.db.Select(&itemsWithProducts, itemsQuery, purchaseID)
-	if err != nil {
-		return purchase, errors.DatabaseError(err, "Error fetching purchase items")
-	}
-
-	items := make([]models.PurchaseItem, len(itemsWithProducts))
-	for i, itemWithProduct := range itemsWithProducts {
-		items[i] = itemWithProduct.PurchaseItem
-		if itemWithProduct.Product.ID != uuid.Nil {
-			items[i].Product = &itemWithProduct.Product
-		}
-	}
-
-	purchase.Items = items
+	purchase = purchases[0]
 
 	if err := r.cache.Set(key, purchase, TTL); err != nil {
 		return purchase, errors.CacheError(err, "Error caching purchase")
@@ -170,7 +133,16 @@ func (r *Repository) CreatePurchase(purchase *models.Purchase) error {
 	}
 	defer tx.Rollback()
 
-	purchaseNumber, err := r.generatePurchaseNumber(purchase.PurchaseDate)
+	if err := r.checkPurchaseVendor(tx, purchase.VendorID, purchase.InventoryID); err != nil {
+		return err
+	}
+
+	if err := checkPurchaseProducts(tx, purchase); err != nil {
+		return err
+	}
+
+	// Numbers follow the order documents are created in, not their backdated dates
+	purchaseNumber, err := inventories.NextDocumentNumber(tx, purchase.InventoryID, models.DocumentTypePurchase, time.Now())
 	if err != nil {
 		return err
 	}
@@ -178,12 +150,12 @@ func (r *Repository) CreatePurchase(purchase *models.Purchase) error {
 
 	purchaseQuery := `
 		INSERT INTO purchases (
-			id, purchase_number, vendor_id, purchase_date, eta,
+			id, purchase_number, vendor_id, purchase_date, eta, due_date,
 			delivery_date, shipping_cost, total_amount, payment_status,
 			purchase_status, discount_amount, discount_percent, inventory_id, 
 			created_at, updated_at
 		) VALUES (
-			:id, :purchase_number, :vendor_id, :purchase_date, :eta,
+			:id, :purchase_number, :vendor_id, :purchase_date, :eta, :due_date,
 			:delivery_date, :shipping_cost, :total_amount, :payment_status,
 			:purchase_status, :discount_amount, :discount_percent, :inventory_id, 
 			:created_at, :updated_at
@@ -197,9 +169,11 @@ func (r *Repository) CreatePurchase(purchase *models.Purchase) error {
 	if len(purchase.Items) > 0 {
 		itemQuery := `
 			INSERT INTO purchase_items (
-				id, purchase_id, product_id, quantity, unit_price, subtotal, created_at
+				id, purchase_id, product_id, quantity, unit_price,
+				discount_amount, discount_percent, subtotal, created_at
 			) VALUES (
-				:id, :purchase_id, :product_id, :quantity, :unit_price, :subtotal, :created_at
+				:id, :purchase_id, :product_id, :quantity, :unit_price,
+				:discount_amount, :discount_percent, :subtotal, :created_at
 			)
 		`
 		for _, item := range purchase.Items {
@@ -210,6 +184,11 @@ func (r *Repository) CreatePurchase(purchase *models.Purchase) error {
 		}
 	}
 
+	// Payments are recorded separately, so the status follows the empty ledger
+	if err := r.applyPurchasePayments(tx, purchase); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return errors.DatabaseError(err, "Error committing transaction")
 	}
@@ -219,6 +198,128 @@ func (r *Repository) CreatePurchase(purchase *models.Purchase) error {
 	return nil
 }
 
+// UpdatePurchase replaces the header and lines of a purchase and keeps the previous
+// state as a revision. Received quantities are preserved, so lines that have
+// already arrived cannot be dropped, change product or shrink below what arrived.
+func (r *Repository) UpdatePurchase(purchase *models.Purchase, editedBy uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	var existing models.Purchase
+	err = tx.Get(&existing, `SELECT * FROM purchases WHERE id = $1 AND inventory_id = $2 FOR UPDATE`, purchase.ID, purchase.InventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Purchase not found")
+		}
+		return errors.DatabaseError(err, "Error getting purchase by ID")
+	}
+
+	if existing.PurchaseStatus == "cancelled" {
+		return errors.ValidationError("Cannot edit a cancelled purchase")
+	}
+
+	if err := r.checkPurchaseHasNoReturns(tx, purchase.ID, "edit"); err != nil {
+		return err
+	}
+
+	if err := r.checkPurchaseVendor(tx, purchase.VendorID, purchase.InventoryID); err != nil {
+		return err
+	}
+
+	if err := checkPurchaseProducts(tx, purchase); err != nil {
+		return err
+	}
+
+	err = tx.Select(&existing.Items,
+		`SELECT id, purchase_id, product_id, quantity, received_quantity, unit_price,
+                discount_amount, discount_percent, subtotal, created_at
+         FROM purchase_items
+         WHERE purchase_id = $1
+         ORDER BY created_at ASC
+         FOR UPDATE`,
+		purchase.ID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching purchase items")
+	}
+
+	if err := checkReceivedItems(purchase.Items, existing.Items); err != nil {
+		return err
+	}
+
+	if err := r.recordPurchaseRevision(tx, &existing, editedBy); err != nil {
+		return err
+	}
+
+	if err := r.syncPurchaseItems(tx, purchase, existing.Items); err != nil {
+		return err
+	}
+
+	// The purchase is received only while every line has fully arrived
+	_, err = tx.NamedExec(`
+		UPDATE purchases SET
+			vendor_id = :vendor_id,
+			purchase_date = :purchase_date,
+			eta = :eta,
+			due_date = :due_date,
+			shipping_cost = :shipping_cost,
+			total_amount = :total_amount,
+			payment_status = :payment_status,
+			purchase_status = CASE
+				WHEN NOT EXISTS (
+					SELECT 1 FROM purchase_items
+					WHERE purchase_id = :id AND received_quantity < quantity
+				) AND :purchase_status <> 'cancelled' THEN 'received'
+				WHEN :purchase_status = 'received' THEN 'ordered'
+				ELSE :purchase_status
+			END,
+			discount_amount = :discount_amount,
+			discount_percent = :discount_percent,
+			updated_at = :updated_at
+		WHERE id = :id
+	`, purchase)
+	if err != nil {
+		return errors.DatabaseError(err, "Error updating purchase")
+	}
+
+	// The total may have moved, so the balance and status follow
+	if err := r.applyPurchasePayments(tx, purchase); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Error committing transaction")
+	}
+
+	r.invalidatePurchaseCaches(purchase.ID, purchase.InventoryID)
+
+	return nil
+}
+
+func (r *Repository) ListPurchaseRevisions(purchaseID, inventoryID uuid.UUID) ([]models.DocumentRevision, error) {
+	var exists bool
+	err := r.db.Get(&exists, `SELECT EXISTS(SELECT 1 FROM purchases WHERE id = $1 AND inventory_id = $2)`, purchaseID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error getting purchase by ID")
+	}
+	if !exists {
+		return nil, errors.NotFoundError("Purchase not found")
+	}
+
+	revisions := []models.DocumentRevision{}
+	query := `SELECT * FROM document_revisions
+              WHERE document_type = $1 AND document_id = $2 AND inventory_id = $3
+              ORDER BY revision DESC`
+
+	if err := r.db.Select(&revisions, query, models.DocumentTypePurchase, purchaseID, inventoryID); err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching purchase revisions")
+	}
+
+	return revisions, nil
+}
+
 func (r *Repository) DeletePurchase(purchaseID, inventoryID uuid.UUID) error {
 	tx, err := r.db.Beginx()
 	if err != nil {
@@ -226,12 +327,37 @@ func (r *Repository) DeletePurchase(purchaseID, inventoryID uuid.UUID) error {
 	}
 	defer tx.Rollback()
 
+	// Locking the purchase keeps a receipt from landing while it is deleted
+	var purchaseStatus string
+	err = tx.Get(&purchaseStatus, `SELECT purchase_status FROM purchases WHERE id = $1 AND inventory_id = $2 FOR UPDATE`, purchaseID, inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Purchase not found")
+		}
+		return errors.DatabaseError(err, "Error getting purchase by ID")
+	}
+
+	if err := r.checkPurchaseHasNoReturns(tx, purchaseID, "delete"); err != nil {
+		return err
+	}
+
+	// Received units are in stock and in the cost layers, both pointing at
+	// this purchase, so it has to stay
+	var received bool
+	err = tx.Get(&received, `SELECT EXISTS(SELECT 1 FROM purchase_items WHERE purchase_id = $1 AND received_quantity > 0)`, purchaseID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error checking received items")
+	}
+	if received {
+		return errors.ValidationError("Cannot delete a purchase that has received items")
+	}
+
 	_, err = tx.Exec("DELETE FROM purchase_items WHERE purchase_id = $1", purchaseID)
 	if err != nil {
 		return errors.DatabaseError(err, "Error deleting purchase items")
 	}
 
-	_, err = tx.Exec("DELETE FROM purchases WHERE id = $1", purchaseID)
+	_, err = tx.Exec("DELETE FROM purchases WHERE id = $1 AND inventory_id = $2", purchaseID, inventoryID)
 	if err != nil {
 		return errors.DatabaseError(err, "Error deleting purchase")
 	}
@@ -245,37 +371,547 @@ func (r *Repository) DeletePurchase(purchaseID, inventoryID uuid.UUID) error {
 	return nil
 }
 
+func (r *Repository) ReceivePurchase(purchaseID, inventoryID uuid.UUID, receipt *models.PurchaseReceipt) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	var header struct {
+		PurchaseStatus string     `db:"purchase_status"`
+		VendorID       *uuid.UUID `db:"vendor_id"`
+		TotalAmount    int        `db:"total_amount"`
+	}
+	err = tx.Get(&header,
+		`SELECT purchase_status, vendor_id, total_amount FROM purchases WHERE id = $1 AND inventory_id = $2 FOR UPDATE`,
+		purchaseID, inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Purchase not found")
+		}
+		return errors.DatabaseError(err, "Error getting purchase by ID")
+	}
+
+	// Drafts are not yet agreed with the vendor, so they are ordered first
+	switch header.PurchaseStatus {
+	case "ordered", "shipped":
+	case "received":
+		return errors.ValidationError("Purchase has already been fully received")
+	default:
+		return errors.ValidationError(fmt.Sprintf("Cannot receive a %s purchase", header.PurchaseStatus))
+	}
+
+	var warehouseExists bool
+	err = tx.Get(&warehouseExists,
+		`SELECT EXISTS(SELECT 1 FROM warehouses WHERE id = $1 AND inventory_id = $2)`,
+		receipt.WarehouseID, inventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error validating warehouse")
+	}
+	if !warehouseExists {
+		return errors.ValidationError(fmt.Sprintf("Warehouse with ID %s not found", receipt.WarehouseID))
+	}
+
+	// Order discounts and shipping are capitalised into the received units,
+	// shared across the lines by their subtotals
+	var itemsSubtotal int
+	err = tx.Get(&itemsSubtotal, `SELECT COALESCE(SUM(subtotal), 0) FROM purchase_items WHERE purchase_id = $1`, purchaseID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error summing purchase items")
+	}
+
+	stockItems := make([]models.StockItemRequest, 0, len(receipt.Items))
+	for _, receiptItem := range receipt.Items {
+		var line models.PurchaseItem
+		err = tx.Get(&line,
+			`SELECT id, purchase_id, product_id, quantity, received_quantity, unit_price,
+                    discount_amount, discount_percent, subtotal, created_at
+             FROM purchase_items
+             WHERE id = $1 AND purchase_id = $2
+             FOR UPDATE`,
+			receiptItem.PurchaseItemID, purchaseID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.ValidationError(fmt.Sprintf("Purchase item with ID %s not found", receiptItem.PurchaseItemID))
+			}
+			return errors.DatabaseError(err, "Error fetching purchase item")
+		}
+
+		outstanding := line.Quantity - line.ReceivedQuantity
+		if receiptItem.Quantity > outstanding {
+			return errors.ValidationError(fmt.Sprintf("Cannot receive %d units for purchase item %s. Only %d outstanding",
+				receiptItem.Quantity, line.ID, outstanding))
+		}
+
+		_, err = tx.Exec(
+			`UPDATE purchase_items SET received_quantity = received_quantity + $1 WHERE id = $2`,
+			receiptItem.Quantity, line.ID)
+		if err != nil {
+			return errors.DatabaseError(err, "Error updating received quantity")
+		}
+
+		if _, err := warehouses.LockProduct(tx, line.ProductID, inventoryID); err != nil {
+			return err
+		}
+
+		// Received units are new stock the inventory now owns
+		_, err = tx.Exec(
+			`UPDATE products SET total_quantity = total_quantity + $1 WHERE id = $2 AND inventory_id = $3`,
+			receiptItem.Quantity, line.ProductID, inventoryID)
+		if err != nil {
+			return errors.DatabaseError(err, "Error updating product total quantity")
+		}
+
+		// Each receipt opens a cost layer at the line's landed unit cost
+		landedTotal := line.Subtotal
+		if itemsSubtotal > 0 {
+			landedTotal = utils.ShareOf(header.TotalAmount, line.Subtotal, itemsSubtotal)
+		}
+		err = warehouses.AddCostLayer(tx, models.CostLayer{
+			InventoryID: inventoryID,
+			ProductID:   line.ProductID,
+			Source:      models.CostLayerReceipt,
+			DocumentID:  &purchaseID,
+			Quantity:    receiptItem.Quantity,
+			UnitCost:    utils.ShareOf(landedTotal, 1, line.Quantity),
+		})
+		if err != nil {
+			return err
+		}
+
+		// The vendor's price is what they charge per unit, before order
+		// discounts and shipping
+		if header.VendorID != nil {
+			unitPrice := utils.ShareOf(line.Subtotal, 1, line.Quantity)
+			if err := products.RecordVendorCost(tx, inventoryID, line.ProductID, *header.VendorID, unitPrice); err != nil {
+				return err
+			}
+		}
+
+		stockItems = append(stockItems, models.StockItemRequest{
+			ProductID:       line.ProductID,
+			QuantityInStock: receiptItem.Quantity,
+		})
+	}
+
+	movement := models.StockMovement{
+		InventoryID: inventoryID,
+		Reason:      models.MovementReceipt,
+		DocumentID:  &purchaseID,
+		UserID:      &receipt.ReceivedBy,
+	}
+	if err := warehouses.AddStock(tx, receipt.WarehouseID, stockItems, movement); err != nil {
+		return err
+	}
+
+	// The purchase is only marked received once every line has fully arrived
+	_, err = tx.Exec(
+		`UPDATE purchases SET
+            delivery_date = $1,
+            purchase_status = CASE
+                WHEN NOT EXISTS (
+                    SELECT 1 FROM purchase_items
+                    WHERE purchase_id = $2 AND received_quantity < quantity
+                ) THEN 'received'
+                ELSE purchase_status
+            END,
+            updated_at = $3
+         WHERE id = $2`,
+		receipt.DeliveryDate, purchaseID, time.Now())
+	if err != nil {
+		return errors.DatabaseError(err, "Error updating purchase delivery")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Error committing transaction")
+	}
+
+	r.invalidatePurchaseCaches(purchaseID, inventoryID)
+	r.invalidateStockCaches(stockItems, inventoryID)
+
+	return nil
+}
+
+func (r *Repository) ListPurchasePayments(purchaseID, inventoryID uuid.UUID) ([]models.PurchasePayment, error) {
+	var exists bool
+	err := r.db.Get(&exists, `SELECT EXISTS(SELECT 1 FROM purchases WHERE id = $1 AND inventory_id = $2)`, purchaseID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error getting purchase by ID")
+	}
+	if !exists {
+		return nil, errors.NotFoundError("Purchase not found")
+	}
+
+	payments := []models.PurchasePayment{}
+	query := `SELECT * FROM purchase_payments WHERE purchase_id = $1 ORDER BY payment_date ASC, created_at ASC`
+
+	if err := r.db.Select(&payments, query, purchaseID); err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching purchase payments")
+	}
+
+	return payments, nil
+}
+
+func (r *Repository) CreatePurchasePayment(payment *models.PurchasePayment, inventoryID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	var purchase models.Purchase
+
+	// Lock the purchase so concurrent payments cannot overpay it
+	err = tx.Get(&purchase, `SELECT * FROM purchases WHERE id = $1 AND inventory_id = $2 FOR UPDATE`, payment.PurchaseID, inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Purchase not found")
+		}
+		return errors.DatabaseError(err, "Error getting purchase by ID")
+	}
+
+	if purchase.PurchaseStatus == "cancelled" || purchase.PaymentStatus == models.PaymentStatusCancelled {
+		return errors.ValidationError("Cannot record a payment on a cancelled purchase")
+	}
+
+	if payment.Amount > purchase.Balance {
+		return errors.ValidationError(fmt.Sprintf("Payment of %d exceeds the outstanding balance of %d", payment.Amount, purchase.Balance))
+	}
+
+	if err := r.insertPurchasePayment(tx, payment); err != nil {
+		return err
+	}
+
+	if err := r.applyPurchasePayments(tx, &purchase); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Error committing transaction")
+	}
+
+	r.invalidatePurchaseCaches(purchase.ID, inventoryID)
+
+	return nil
+}
+
 // HELPER METHODS
-func (r *Repository) invalidatePurchaseCaches(purchaseID, inventoryID uuid.UUID) {
-	r.cache.Delete(purchaseCacheKey(purchaseID))
-	r.cache.Delete(purchaseListCacheKey(inventoryID))
+
+// purchaseListConditions turns the filters of a purchase list into its WHERE
+// clause, shared by the paged list and the export
+func purchaseListConditions(inventoryID uuid.UUID, query utils.ListQuery) (*utils.Conditions, error) {
+	conditions := utils.NewConditions("inventory_id = ?", inventoryID)
+	if status, ok := query.Filters["paymentStatus"]; ok {
+		conditions.Add("payment_status = ?", status)
+	}
+	if status, ok := query.Filters["purchaseStatus"]; ok {
+		conditions.Add("purchase_status = ?", status)
+	}
+
+	vendorID, err := query.UUIDFilter("vendorId")
+	if err != nil {
+		return nil, err
+	}
+	if vendorID != nil {
+		conditions.Add("vendor_id = ?", *vendorID)
+	}
+
+	if query.From != nil {
+		conditions.Add("purchase_date >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("purchase_date <= ?", *query.To)
+	}
+
+	return conditions, nil
 }
 
-func (repo *Repository) generatePurchaseNumber(purchaseDate time.Time) (string, error) {
-	dateStr := purchaseDate.Format("060102")
+// loadPurchaseItems attaches items and their products to the given purchases with one query
+func (r *Repository) loadPurchaseItems(purchases []models.Purchase) error {
+	if len(purchases) == 0 {
+		return nil
+	}
+
+	purchaseIDs := make([]uuid.UUID, len(purchases))
+	for i := range purchases {
+		purchaseIDs[i] = purchases[i].ID
+	}
+
+	itemsQuery := `
+    SELECT 
+        pi.id, pi.purchase_id, pi.product_id, pi.quantity, pi.received_quantity,
+        pi.unit_price, pi.discount_amount, pi.discount_percent, pi.subtotal, pi.created_at,
+        p.id as "product.id", p.name as "product.name", 
+        p.code as "product.code", p.sku as "product.sku",
+        p.brand as "product.brand", p.model as "product.model",
+        p.description as "product.description", p.total_quantity as "product.total_quantity",
+        p.total_stock as "product.total_stock", p.restock_level as "product.restock_level",
+        p.optimal_level as "product.optimal_level", p.cost_price as "product.cost_price",
+        p.selling_price as "product.selling_price", p.inventory_id as "product.inventory_id",
+        p.created_at as "product.created_at", p.updated_at as "product.updated_at"
+    FROM purchase_items pi
+    LEFT JOIN products p ON pi.product_id = p.id
+    WHERE pi.purchase_id = ANY($1)
+    ORDER BY pi.created_at ASC
+`
 
-	var count int
-	query := `SELECT COUNT(*) FROM purchases WHERE DATE(purchase_date) = DATE($1)`
-	if err := repo.db.Get(&count, query, purchaseDate.Format("2006-01-02")); err != nil {
-		return "", errors.DatabaseError(err, "Error getting purchase count")
+	type PurchaseItemWithProduct struct {
+		models.PurchaseItem
+		Product models.Product
 	}
 
-	for i := 0; i < 10; i++ {
-		seqNumber := count + 1 + i
-		candidateNumber := fmt.Sprintf("PO-%s-%03d", dateStr, seqNumber)
+	var itemsWithProducts []PurchaseItemWithProduct
+	err := r.db.Select(&itemsWithProducts, itemsQuery, pq.Array(purchaseIDs))
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching purchase items")
+	}
 
-		var exists bool
-		existsQuery := `SELECT EXISTS(SELECT 1 FROM purchases WHERE purchase_number = $1)`
-		if err := repo.db.Get(&exists, existsQuery, candidateNumber); err != nil {
-			return "", errors.DatabaseError(err, "Error checking purchase number uniqueness")
+	itemsByPurchase := make(map[uuid.UUID][]models.PurchaseItem, len(purchases))
+	for i := range itemsWithProducts {
+		item := itemsWithProducts[i].PurchaseItem
+		if itemsWithProducts[i].Product.ID != uuid.Nil {
+			item.Product = &itemsWithProducts[i].Product
 		}
+		itemsByPurchase[item.PurchaseID] = append(itemsByPurchase[item.PurchaseID], item)
+	}
 
-		if !exists {
-			return candidateNumber, nil
+	for i := range purchases {
+		items := itemsByPurchase[purchases[i].ID]
+		if items == nil {
+			items = []models.PurchaseItem{}
 		}
+		purchases[i].Items = items
 	}
 
-	fallbackNumber := fmt.Sprintf("PO-%06d-001", rand.Intn(1000000))
+	return nil
+}
+func (r *Repository) checkPurchaseVendor(tx *sqlx.Tx, vendorID *uuid.UUID, inventoryID uuid.UUID) error {
+	if vendorID == nil {
+		return nil
+	}
+
+	var vendorExists bool
+	err := tx.Get(&vendorExists,
+		`SELECT EXISTS(SELECT 1 FROM vendors WHERE id = $1 AND inventory_id = $2)`,
+		vendorID, inventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error validating vendor")
+	}
+	if !vendorExists {
+		return errors.ValidationError(fmt.Sprintf("Vendor with ID %s not found", *vendorID))
+	}
+
+	return nil
+}
+
+// checkPurchaseProducts makes sure every line orders a product of the
+// purchase's inventory
+func checkPurchaseProducts(tx *sqlx.Tx, purchase *models.Purchase) error {
+	for _, item := range purchase.Items {
+		if _, err := warehouses.LockProduct(tx, item.ProductID, purchase.InventoryID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReceivedItems rejects edits that would lose track of units already received
+func checkReceivedItems(items, existing []models.PurchaseItem) error {
+	edited := make(map[uuid.UUID]models.PurchaseItem, len(items))
+	for _, item := range items {
+		edited[item.ID] = item
+	}
+
+	var errorMessages []string
+	for _, line := range existing {
+		if line.ReceivedQuantity == 0 {
+			continue
+		}
+
+		item, ok := edited[line.ID]
+		switch {
+		case !ok:
+			errorMessages = append(errorMessages, fmt.Sprintf("Purchase item %s has received units and cannot be removed", line.ID))
+		case item.ProductID != line.ProductID:
+			errorMessages = append(errorMessages, fmt.Sprintf("Purchase item %s has received units and cannot change product", line.ID))
+		case item.Quantity < line.ReceivedQuantity:
+			errorMessages = append(errorMessages, fmt.Sprintf("Purchase item %s cannot be reduced below the %d units received", line.ID, line.ReceivedQuantity))
+		}
+	}
+
+	if len(errorMessages) > 0 {
+		return errors.ValidationError(strings.Join(errorMessages, "; "))
+	}
+
+	return nil
+}
+
+// syncPurchaseItems writes the edited lines of a purchase: lines that already exist
+// are updated in place, new ones inserted and the ones left out deleted.
+func (r *Repository) syncPurchaseItems(tx *sqlx.Tx, purchase *models.Purchase, existing []models.PurchaseItem) error {
+	existingIDs := make(map[uuid.UUID]bool, len(existing))
+	for _, item := range existing {
+		existingIDs[item.ID] = true
+	}
+
+	keptIDs := make([]uuid.UUID, 0, len(purchase.Items))
+	for _, item := range purchase.Items {
+		keptIDs = append(keptIDs, item.ID)
+
+		query := `
+			INSERT INTO purchase_items (
+				id, purchase_id, product_id, quantity, unit_price,
+				discount_amount, discount_percent, subtotal, created_at
+			) VALUES (
+				:id, :purchase_id, :product_id, :quantity, :unit_price,
+				:discount_amount, :discount_percent, :subtotal, :created_at
+			)
+		`
+		if existingIDs[item.ID] {
+			query = `
+				UPDATE purchase_items SET
+					product_id = :product_id,
+					quantity = :quantity,
+					unit_price = :unit_price,
+					discount_amount = :discount_amount,
+					discount_percent = :discount_percent,
+					subtotal = :subtotal
+				WHERE id = :id AND purchase_id = :purchase_id
+			`
+		}
+
+		if _, err := tx.NamedExec(query, item); err != nil {
+			return errors.DatabaseError(err, "Error saving purchase item")
+		}
+	}
+
+	_, err := tx.Exec(`DELETE FROM purchase_items WHERE purchase_id = $1 AND NOT (id = ANY($2))`, purchase.ID, pq.Array(keptIDs))
+	if err != nil {
+		return errors.DatabaseError(err, "Error deleting purchase items")
+	}
+
+	return nil
+}
+
+// recordPurchaseRevision stores the given state of a purchase as its next revision
+func (r *Repository) recordPurchaseRevision(tx *sqlx.Tx, purchase *models.Purchase, editedBy uuid.UUID) error {
+	snapshot, err := json.Marshal(purchase)
+	if err != nil {
+		return errors.DatabaseError(err, "Error encoding purchase revision")
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO document_revisions (id, inventory_id, document_type, document_id, revision, snapshot, edited_by, created_at)
+         SELECT $1, $2, $3, $4, COALESCE(MAX(revision), 0) + 1, $5, $6, $7
+         FROM document_revisions
+         WHERE document_type = $3 AND document_id = $4`,
+		uuid.New(), purchase.InventoryID, models.DocumentTypePurchase, purchase.ID, string(snapshot), editedBy, time.Now())
+	if err != nil {
+		return errors.DatabaseError(err, "Error recording purchase revision")
+	}
+
+	return nil
+}
+
+// purchaseLedger sums what was paid, credited by returns and refunded on a purchase
+type purchaseLedger struct {
+	Paid     int `db:"paid"`
+	Credited int `db:"credited"`
+	Refunded int `db:"refunded"`
+}
+
+func (r *Repository) getPurchaseLedger(tx *sqlx.Tx, purchaseID uuid.UUID) (purchaseLedger, error) {
+	var ledger purchaseLedger
+	err := tx.Get(&ledger,
+		`SELECT
+             (SELECT COALESCE(SUM(amount), 0) FROM purchase_payments WHERE purchase_id = $1) AS paid,
+             COALESCE(SUM(credit_amount), 0) AS credited,
+             COALESCE(SUM(refund_due), 0) AS refunded
+         FROM purchase_returns
+         WHERE purchase_id = $1`,
+		purchaseID)
+	if err != nil {
+		return ledger, errors.DatabaseError(err, "Error summing purchase payments")
+	}
+
+	return ledger, nil
+}
+
+// applyPurchasePayments recomputes what is still owed to the vendor and the
+// payment status from the payments ledger and stores them. Returns lower what
+// is owed and refunds due from the vendor give back what was paid. Cancelled
+// purchases owe nothing. The purchase row must already be locked.
+func (r *Repository) applyPurchasePayments(tx *sqlx.Tx, purchase *models.Purchase) error {
+	ledger, err := r.getPurchaseLedger(tx, purchase.ID)
+	if err != nil {
+		return err
+	}
+
+	total := purchase.TotalAmount - ledger.Credited
+	paid := ledger.Paid - ledger.Refunded
+
+	if paid > total {
+		return errors.ValidationError(fmt.Sprintf("Payments of %d exceed the purchase total of %d", paid, total))
+	}
+
+	purchase.Balance = total - paid
+	purchase.PaymentStatus = purchasePaymentStatus(purchase.PaymentStatus, total, paid)
+	if purchase.PurchaseStatus == "cancelled" || purchase.PaymentStatus == models.PaymentStatusCancelled {
+		purchase.Balance = 0
+	}
+	purchase.UpdatedAt = time.Now()
 
-	return fallbackNumber, nil
+	_, err = tx.Exec(`UPDATE purchases SET balance = $1, payment_status = $2, updated_at = $3 WHERE id = $4`,
+		purchase.Balance, purchase.PaymentStatus, purchase.UpdatedAt, purchase.ID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error updating purchase balance")
+	}
+
+	return nil
+}
+
+// purchasePaymentStatus derives the payment status from the amount paid. Cancelled
+// purchases stay cancelled and overdue ones stay overdue until settled.
+func purchasePaymentStatus(current string, total, paid int) string {
+	switch {
+	case current == models.PaymentStatusCancelled:
+		return current
+	case paid >= total:
+		return models.PaymentStatusPaid
+	case current == models.PaymentStatusOverdue:
+		return current
+	case paid > 0:
+		return models.PaymentStatusPartial
+	default:
+		return models.PaymentStatusPending
+	}
+}
+
+func (r *Repository) insertPurchasePayment(tx *sqlx.Tx, payment *models.PurchasePayment) error {
+	query := `
+		INSERT INTO purchase_payments (
+			id, purchase_id, amount, method, reference, payment_date, created_at
+		) VALUES (
+			:id, :purchase_id, :amount, :method, :reference, :payment_date, :created_at
+		)
+	`
+	if _, err := tx.NamedExec(query, payment); err != nil {
+		return errors.DatabaseError(err, "Error creating purchase payment")
+	}
+
+	return nil
+}
+
+func (r *Repository) invalidatePurchaseCaches(purchaseID, inventoryID uuid.UUID) {
+	r.cache.Delete(purchaseCacheKey(purchaseID))
+	r.cache.Delete(purchaseListCacheKey(inventoryID))
+}
+
+// invalidateStockCaches drops cached products whose stock changed with a receipt
+func (r *Repository) invalidateStockCaches(items []models.StockItemRequest, inventoryID uuid.UUID) {
+	for _, item := range items {
+		r.cache.Delete("product:" + item.ProductID.String())
+	}
+	r.cache.Delete("products:" + inventoryID.String())
 }
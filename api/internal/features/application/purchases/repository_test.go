@@ -0,0 +1,198 @@
+package purchases
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/app/venside/internal/mapper"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/google/uuid"
+)
+
+func TestUpdatePurchase(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+	vendorID := testdb.SeedVendor(t, db, seed.InventoryID, "Acme Supplies")
+	productID := testdb.SeedProduct(t, db, seed.InventoryID, "Widget", 500, 900)
+	repo := NewRepository(db, testdb.Cache{})
+
+	vendor := vendorID.String()
+	req := models.PurchaseRequest{
+		VendorID:       &vendor,
+		PurchaseStatus: "ordered",
+		Items: []models.PurchaseItemRequest{
+			{ProductID: productID.String(), Quantity: 4, UnitPrice: 500},
+		},
+	}
+	purchase := mapper.ToCreatePurchase(&req, seed.InventoryID)
+	if err := pricePurchase(purchase, &req); err != nil {
+		t.Fatalf("pricing purchase: %v", err)
+	}
+	if err := repo.CreatePurchase(purchase); err != nil {
+		t.Fatalf("creating purchase: %v", err)
+	}
+
+	existing, err := repo.GetPurchase(purchase.ID, seed.InventoryID)
+	if err != nil {
+		t.Fatalf("getting purchase: %v", err)
+	}
+
+	// Edit the existing line and add shipping and an order discount
+	lineID := existing.Items[0].ID.String()
+	edit := models.PurchaseRequest{
+		VendorID:       &vendor,
+		ShippingCost:   250,
+		DiscountAmount: 100,
+		Items: []models.PurchaseItemRequest{
+			{ID: &lineID, ProductID: productID.String(), Quantity: 6, UnitPrice: 450},
+		},
+	}
+	edited := mapper.ToEditPurchase(&edit, &existing)
+	if err := pricePurchase(edited, &edit); err != nil {
+		t.Fatalf("pricing edit: %v", err)
+	}
+	if err := repo.UpdatePurchase(edited, seed.UserID); err != nil {
+		t.Fatalf("updating purchase: %v", err)
+	}
+
+	updated, err := repo.GetPurchase(purchase.ID, seed.InventoryID)
+	if err != nil {
+		t.Fatalf("getting updated purchase: %v", err)
+	}
+
+	if want := 6*450 - 100 + 250; updated.TotalAmount != want {
+		t.Errorf("total = %d, want %d", updated.TotalAmount, want)
+	}
+	if updated.Balance != updated.TotalAmount {
+		t.Errorf("balance = %d, want the unpaid total %d", updated.Balance, updated.TotalAmount)
+	}
+	if updated.VendorName != "Acme Supplies" {
+		t.Errorf("vendor name = %q, want it read from the vendor", updated.VendorName)
+	}
+	if len(updated.Items) != 1 || updated.Items[0].ID != existing.Items[0].ID || updated.Items[0].Quantity != 6 {
+		t.Errorf("items = %+v, want the original line edited to 6 units", updated.Items)
+	}
+
+	revisions, err := repo.ListPurchaseRevisions(purchase.ID, seed.InventoryID)
+	if err != nil {
+		t.Fatalf("listing revisions: %v", err)
+	}
+	if len(revisions) != 1 {
+		t.Errorf("revisions = %d, want 1", len(revisions))
+	}
+}
+
+func TestCreatePurchaseRejectsForeignProduct(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+	other := testdb.SeedInventory(t, db, "")
+	foreignID := testdb.SeedProduct(t, db, other.InventoryID, "Their widget", 500, 900)
+	repo := NewRepository(db, testdb.Cache{})
+
+	req := models.PurchaseRequest{
+		PurchaseStatus: "ordered",
+		Items: []models.PurchaseItemRequest{
+			{ProductID: foreignID.String(), Quantity: 4, UnitPrice: 500},
+		},
+	}
+	purchase := mapper.ToCreatePurchase(&req, seed.InventoryID)
+	if err := pricePurchase(purchase, &req); err != nil {
+		t.Fatalf("pricing purchase: %v", err)
+	}
+
+	err := repo.CreatePurchase(purchase)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("err = %v, want the other inventory's product not found", err)
+	}
+}
+
+// createTestPurchase records a purchase of one line of the given product
+func createTestPurchase(t *testing.T, repo PurchaseRepository, inventoryID uuid.UUID, productID uuid.UUID, status string) models.Purchase {
+	t.Helper()
+
+	req := models.PurchaseRequest{
+		PurchaseStatus: status,
+		Items: []models.PurchaseItemRequest{
+			{ProductID: productID.String(), Quantity: 4, UnitPrice: 500},
+		},
+	}
+	purchase := mapper.ToCreatePurchase(&req, inventoryID)
+	if err := pricePurchase(purchase, &req); err != nil {
+		t.Fatalf("pricing purchase: %v", err)
+	}
+	if err := repo.CreatePurchase(purchase); err != nil {
+		t.Fatalf("creating purchase: %v", err)
+	}
+
+	created, err := repo.GetPurchase(purchase.ID, inventoryID)
+	if err != nil {
+		t.Fatalf("getting purchase: %v", err)
+	}
+	return created
+}
+
+func TestUpdatePurchaseWithReturns(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+	productID := testdb.SeedProduct(t, db, seed.InventoryID, "Widget", 500, 900)
+	repo := NewRepository(db, testdb.Cache{})
+
+	existing := createTestPurchase(t, repo, seed.InventoryID, productID, "ordered")
+	testdb.Exec(t, db,
+		`INSERT INTO purchase_returns (id, return_number, purchase_id, inventory_id, reason, credit_amount)
+         VALUES ($1, 'RTV-1', $2, $3, 'Damaged', 500)`,
+		uuid.New(), existing.ID, seed.InventoryID)
+
+	lineID := existing.Items[0].ID.String()
+	edit := models.PurchaseRequest{
+		PurchaseStatus: "cancelled",
+		Items: []models.PurchaseItemRequest{
+			{ID: &lineID, ProductID: productID.String(), Quantity: 4, UnitPrice: 500},
+		},
+	}
+	edited := mapper.ToEditPurchase(&edit, &existing)
+	if err := pricePurchase(edited, &edit); err != nil {
+		t.Fatalf("pricing edit: %v", err)
+	}
+
+	err := repo.UpdatePurchase(edited, seed.UserID)
+	if err == nil || !strings.Contains(err.Error(), "Cannot edit a purchase that has returns") {
+		t.Fatalf("err = %v, want the edit rejected", err)
+	}
+}
+
+func TestReceiveAndDeletePurchase(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+	productID := testdb.SeedProduct(t, db, seed.InventoryID, "Widget", 500, 900)
+	repo := NewRepository(db, testdb.Cache{})
+
+	receipt := func(purchase models.Purchase) *models.PurchaseReceipt {
+		return &models.PurchaseReceipt{
+			WarehouseID:  seed.WarehouseID,
+			DeliveryDate: time.Now(),
+			ReceivedBy:   seed.UserID,
+			Items:        []models.PurchaseReceiptItem{{PurchaseItemID: purchase.Items[0].ID, Quantity: 1}},
+		}
+	}
+
+	draft := createTestPurchase(t, repo, seed.InventoryID, productID, "draft")
+	err := repo.ReceivePurchase(draft.ID, seed.InventoryID, receipt(draft))
+	if err == nil || !strings.Contains(err.Error(), "Cannot receive a draft purchase") {
+		t.Fatalf("receiving a draft: err = %v, want it rejected", err)
+	}
+	if err := repo.DeletePurchase(draft.ID, seed.InventoryID); err != nil {
+		t.Fatalf("deleting a draft: %v", err)
+	}
+
+	ordered := createTestPurchase(t, repo, seed.InventoryID, productID, "ordered")
+	if err := repo.ReceivePurchase(ordered.ID, seed.InventoryID, receipt(ordered)); err != nil {
+		t.Fatalf("receiving an ordered purchase: %v", err)
+	}
+	err = repo.DeletePurchase(ordered.ID, seed.InventoryID)
+	if err == nil || !strings.Contains(err.Error(), "Cannot delete a purchase that has received items") {
+		t.Fatalf("deleting a received purchase: err = %v, want it rejected", err)
+	}
+}
@@ -0,0 +1,546 @@
+package purchases
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/features/application/warehouses"
+	"github.com/app/venside/internal/mapper"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// purchaseReturnSortColumns maps the sortable API fields of vendor returns to their columns
+var purchaseReturnSortColumns = map[string]string{
+	"createdAt":    "pr.created_at",
+	"returnDate":   "pr.return_date",
+	"returnNumber": "pr.return_number",
+	"creditAmount": "pr.credit_amount",
+}
+
+// ListReturns lists the vendor returns of the whole inventory
+func (c *Controller) ListReturns(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	query, err := utils.ParseListQuery(ctx, purchaseReturnSortColumns, "createdAt", "purchaseId", "vendorId", "warehouseId")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListReturns(inventoryID, query)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch purchase returns", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	response := utils.MapPage(page, mapper.ToPurchaseReturnResponse)
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) GetPurchaseReturn(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	returnID, err := uuid.Parse(ctx.Param("returnId"))
+	if err != nil {
+		return errors.ValidationError("Invalid return ID")
+	}
+
+	ret, err := c.repo.GetPurchaseReturn(returnID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to retrieve purchase return", err, logrus.Fields{
+			"details":   err.Error(),
+			"return_id": returnID,
+		})
+	}
+
+	response := mapper.ToPurchaseReturnResponse(&ret)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) ListPurchaseReturns(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid purchase ID")
+	}
+
+	returns, err := c.repo.ListPurchaseReturns(purchaseID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch purchase returns", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	response := make([]*models.PurchaseReturnResponse, len(returns))
+	for i := range returns {
+		response[i] = mapper.ToPurchaseReturnResponse(&returns[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// CreatePurchaseReturn sends received units back to the vendor. The units leave
+// the given warehouse and their value is credited against the purchase.
+func (c *Controller) CreatePurchaseReturn(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	purchaseID, err := uuid.Parse(ctx.Param("purchaseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid purchase ID")
+	}
+
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	var req models.PurchaseReturnRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	ret := mapper.ToCreatePurchaseReturn(&req, purchaseID, inventoryID, time.Now())
+	ret.ReturnedBy = &user.ID
+
+	if err := c.repo.CreatePurchaseReturn(ret); err != nil {
+		return logger.Error(ctx, "Failed to record purchase return", err, logrus.Fields{
+			"details":     err.Error(),
+			"purchase_id": purchaseID,
+		})
+	}
+
+	response := mapper.ToPurchaseReturnResponse(ret)
+	return ctx.JSON(http.StatusCreated, response)
+}
+
+func (r *Repository) ListReturns(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.PurchaseReturn], error) {
+	var page utils.Page[models.PurchaseReturn]
+
+	conditions := utils.NewConditions("pr.inventory_id = ?", inventoryID)
+
+	purchaseID, err := query.UUIDFilter("purchaseId")
+	if err != nil {
+		return page, err
+	}
+	if purchaseID != nil {
+		conditions.Add("pr.purchase_id = ?", *purchaseID)
+	}
+
+	vendorID, err := query.UUIDFilter("vendorId")
+	if err != nil {
+		return page, err
+	}
+	if vendorID != nil {
+		conditions.Add("pr.vendor_id = ?", *vendorID)
+	}
+
+	warehouseID, err := query.UUIDFilter("warehouseId")
+	if err != nil {
+		return page, err
+	}
+	if warehouseID != nil {
+		conditions.Add("pr.warehouse_id = ?", *warehouseID)
+	}
+
+	if query.From != nil {
+		conditions.Add("pr.return_date >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("pr.return_date <= ?", *query.To)
+	}
+
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM purchase_returns pr` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting purchase returns")
+	}
+
+	returnsQuery := r.db.Rebind(`
+		SELECT pr.*, p.purchase_number
+		FROM purchase_returns pr
+		JOIN purchases p ON p.id = pr.purchase_id` + conditions.Where() + query.OrderAndLimit("pr.id"))
+	returns := []models.PurchaseReturn{}
+
+	if err := r.db.Select(&returns, returnsQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching purchase returns")
+	}
+
+	if err := r.loadPurchaseReturnItems(returns); err != nil {
+		return page, err
+	}
+
+	return utils.NewPage(returns, total, query), nil
+}
+
+func (r *Repository) GetPurchaseReturn(returnID, inventoryID uuid.UUID) (models.PurchaseReturn, error) {
+	var ret models.PurchaseReturn
+	err := r.db.Get(&ret,
+		`SELECT pr.*, p.purchase_number
+         FROM purchase_returns pr
+         JOIN purchases p ON p.id = pr.purchase_id
+         WHERE pr.id = $1 AND pr.inventory_id = $2`,
+		returnID, inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ret, errors.NotFoundError("Purchase return not found")
+		}
+		return ret, errors.DatabaseError(err, "Error getting purchase return by ID")
+	}
+
+	returns := []models.PurchaseReturn{ret}
+	if err := r.loadPurchaseReturnItems(returns); err != nil {
+		return ret, err
+	}
+
+	return returns[0], nil
+}
+
+func (r *Repository) ListPurchaseReturns(purchaseID, inventoryID uuid.UUID) ([]models.PurchaseReturn, error) {
+	var exists bool
+	err := r.db.Get(&exists, `SELECT EXISTS(SELECT 1 FROM purchases WHERE id = $1 AND inventory_id = $2)`, purchaseID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error getting purchase by ID")
+	}
+	if !exists {
+		return nil, errors.NotFoundError("Purchase not found")
+	}
+
+	returns := []models.PurchaseReturn{}
+	query := `
+		SELECT pr.*, p.purchase_number
+		FROM purchase_returns pr
+		JOIN purchases p ON p.id = pr.purchase_id
+		WHERE pr.purchase_id = $1
+		ORDER BY pr.return_date ASC, pr.created_at ASC
+	`
+
+	if err := r.db.Select(&returns, query, purchaseID); err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching purchase returns")
+	}
+
+	if err := r.loadPurchaseReturnItems(returns); err != nil {
+		return nil, err
+	}
+
+	return returns, nil
+}
+
+// CreatePurchaseReturn records a debit note against a locked purchase. Only
+// received units can go back. Each line is credited at its share of the goods
+// total, so discounts are given back in proportion and shipping is not; any
+// credit beyond what is still owed is a refund due from the vendor.
+func (r *Repository) CreatePurchaseReturn(ret *models.PurchaseReturn) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	var purchase models.Purchase
+	err = tx.Get(&purchase, `SELECT * FROM purchases WHERE id = $1 AND inventory_id = $2 FOR UPDATE`, ret.PurchaseID, ret.InventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Purchase not found")
+		}
+		return errors.DatabaseError(err, "Error getting purchase by ID")
+	}
+
+	if purchase.PurchaseStatus == "cancelled" {
+		return errors.ValidationError("Cannot return goods of a cancelled purchase")
+	}
+
+	var warehouseExists bool
+	err = tx.Get(&warehouseExists,
+		`SELECT EXISTS(SELECT 1 FROM warehouses WHERE id = $1 AND inventory_id = $2)`,
+		ret.WarehouseID, ret.InventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error validating warehouse")
+	}
+	if !warehouseExists {
+		return errors.ValidationError(fmt.Sprintf("Warehouse with ID %s not found", *ret.WarehouseID))
+	}
+
+	if err := r.pricePurchaseReturn(tx, &purchase, ret); err != nil {
+		return err
+	}
+
+	// Numbers follow the order documents are created in, not their backdated dates
+	returnNumber, err := inventories.NextDocumentNumber(tx, ret.InventoryID, models.DocumentTypePurchaseReturn, time.Now())
+	if err != nil {
+		return err
+	}
+	ret.ReturnNumber = returnNumber
+	ret.PurchaseNumber = purchase.PurchaseNumber
+	ret.VendorID = purchase.VendorID
+	ret.RefundDue = max(ret.CreditAmount-purchase.Balance, 0)
+
+	_, err = tx.NamedExec(`
+		INSERT INTO purchase_returns (
+			id, return_number, purchase_id, inventory_id, vendor_id, warehouse_id,
+			reason, credit_amount, refund_due, return_date, returned_by, created_at
+		) VALUES (
+			:id, :return_number, :purchase_id, :inventory_id, :vendor_id, :warehouse_id,
+			:reason, :credit_amount, :refund_due, :return_date, :returned_by, :created_at
+		)
+	`, ret)
+	if err != nil {
+		return errors.DatabaseError(err, "Error creating purchase return")
+	}
+
+	movement := models.StockMovement{
+		InventoryID: ret.InventoryID,
+		Reason:      models.MovementReturn,
+		DocumentID:  &ret.ID,
+		UserID:      ret.ReturnedBy,
+	}
+
+	stockItems := make([]models.StockItemRequest, 0, len(ret.Items))
+	for _, item := range ret.Items {
+		_, err = tx.NamedExec(`
+			INSERT INTO purchase_return_items (
+				id, return_id, purchase_item_id, product_id, quantity, unit_cost, amount, created_at
+			) VALUES (
+				:id, :return_id, :purchase_item_id, :product_id, :quantity, :unit_cost, :amount, :created_at
+			)
+		`, item)
+		if err != nil {
+			return errors.DatabaseError(err, "Error creating purchase return item")
+		}
+
+		if err := r.takeReturnedStock(tx, movement, ret.PurchaseID, *ret.WarehouseID, &item); err != nil {
+			return err
+		}
+
+		stockItems = append(stockItems, models.StockItemRequest{ProductID: item.ProductID, QuantityInStock: item.Quantity})
+	}
+
+	if err := r.applyPurchasePayments(tx, &purchase); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Error committing transaction")
+	}
+
+	r.invalidatePurchaseCaches(purchase.ID, purchase.InventoryID)
+	r.invalidateStockCaches(stockItems, purchase.InventoryID)
+
+	return nil
+}
+
+// HELPER METHODS
+
+// pricePurchaseReturn checks every returned line against what was received and
+// not yet returned on its purchase item and fills in the credited amounts
+func (r *Repository) pricePurchaseReturn(tx *sqlx.Tx, purchase *models.Purchase, ret *models.PurchaseReturn) error {
+	var lines []models.PurchaseItem
+	err := tx.Select(&lines,
+		`SELECT id, purchase_id, product_id, quantity, received_quantity, unit_price,
+                discount_amount, discount_percent, subtotal, created_at
+         FROM purchase_items
+         WHERE purchase_id = $1`,
+		purchase.ID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching purchase items")
+	}
+
+	var returned []struct {
+		PurchaseItemID uuid.UUID `db:"purchase_item_id"`
+		Quantity       int       `db:"quantity"`
+	}
+	err = tx.Select(&returned,
+		`SELECT pri.purchase_item_id, SUM(pri.quantity) AS quantity
+         FROM purchase_return_items pri
+         JOIN purchase_returns pr ON pr.id = pri.return_id
+         WHERE pr.purchase_id = $1
+         GROUP BY pri.purchase_item_id`,
+		purchase.ID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error summing returned quantities")
+	}
+
+	linesByID := make(map[uuid.UUID]*models.PurchaseItem, len(lines))
+	itemsSubtotal := 0
+	for i := range lines {
+		linesByID[lines[i].ID] = &lines[i]
+		itemsSubtotal += lines[i].Subtotal
+	}
+
+	returnedQty := make(map[uuid.UUID]int, len(returned))
+	for _, row := range returned {
+		returnedQty[row.PurchaseItemID] = row.Quantity
+	}
+
+	goodsTotal := purchase.TotalAmount - purchase.ShippingCost
+
+	ret.CreditAmount = 0
+	for i := range ret.Items {
+		item := &ret.Items[i]
+
+		line, ok := linesByID[item.PurchaseItemID]
+		if !ok {
+			return errors.ValidationError(fmt.Sprintf("Purchase item with ID %s not found", item.PurchaseItemID))
+		}
+
+		before := returnedQty[line.ID]
+		if before+item.Quantity > line.ReceivedQuantity {
+			return errors.ValidationError(fmt.Sprintf("Cannot return %d units of purchase item %s. Received: %d, already returned: %d",
+				item.Quantity, line.ID, line.ReceivedQuantity, before))
+		}
+		returnedQty[line.ID] = before + item.Quantity
+
+		// The line's share of the goods total carries the order discount. Crediting
+		// the difference of cumulative shares makes a full return credit it exactly.
+		lineTotal := 0
+		if itemsSubtotal > 0 {
+			lineTotal = utils.ShareOf(goodsTotal, line.Subtotal, itemsSubtotal)
+		}
+
+		item.ProductID = line.ProductID
+		item.UnitCost = utils.ShareOf(line.Subtotal, 1, line.Quantity)
+		item.Amount = utils.ShareOf(lineTotal, before+item.Quantity, line.Quantity) -
+			utils.ShareOf(lineTotal, before, line.Quantity)
+		ret.CreditAmount += item.Amount
+	}
+
+	return nil
+}
+
+// takeReturnedStock takes returned units out of the warehouse and out of the
+// cost layers of the purchase they came from. Rows are locked so the stock
+// cannot be sold from under the return.
+func (r *Repository) takeReturnedStock(tx *sqlx.Tx, movement models.StockMovement, purchaseID, warehouseID uuid.UUID, item *models.PurchaseReturnItem) error {
+	productName, err := warehouses.LockProduct(tx, item.ProductID, movement.InventoryID)
+	if err != nil {
+		return err
+	}
+
+	var currentStock int
+	err = tx.Get(&currentStock,
+		`SELECT quantity_in_stock
+         FROM warehouse_product_link
+         WHERE warehouse_id = $1 AND product_id = $2
+         FOR UPDATE`,
+		warehouseID, item.ProductID)
+	if err != nil && err != sql.ErrNoRows {
+		return errors.DatabaseError(err, "Error checking warehouse stock")
+	}
+
+	if currentStock < item.Quantity {
+		return errors.ValidationError(fmt.Sprintf("Insufficient stock for \"%s\". Available: %d, Requested: %d",
+			productName, currentStock, item.Quantity))
+	}
+
+	_, err = tx.Exec(
+		`UPDATE warehouse_product_link
+         SET quantity_in_stock = quantity_in_stock - $1
+         WHERE warehouse_id = $2 AND product_id = $3`,
+		item.Quantity, warehouseID, item.ProductID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error deducting warehouse stock")
+	}
+
+	// Remove the product from the warehouse if stock becomes 0
+	_, err = tx.Exec(
+		`DELETE FROM warehouse_product_link
+         WHERE warehouse_id = $1 AND product_id = $2 AND quantity_in_stock = 0`,
+		warehouseID, item.ProductID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error cleaning up zero stock")
+	}
+
+	// Returned units leave both the stocked and the owned quantity
+	_, err = tx.Exec(
+		`UPDATE products
+         SET total_stock = total_stock - $1,
+             total_quantity = total_quantity - $1
+         WHERE id = $2`,
+		item.Quantity, item.ProductID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error updating product total stock")
+	}
+
+	if _, err := warehouses.ConsumeReceivedCostLayers(tx, movement.InventoryID, item.ProductID, item.Quantity, movement.DocumentID, purchaseID); err != nil {
+		return err
+	}
+
+	movement.ProductID = item.ProductID
+	movement.WarehouseID = warehouseID
+	movement.Quantity = -item.Quantity
+
+	return warehouses.RecordMovement(tx, movement)
+}
+
+// loadPurchaseReturnItems attaches their lines to the given returns with one query
+func (r *Repository) loadPurchaseReturnItems(returns []models.PurchaseReturn) error {
+	if len(returns) == 0 {
+		return nil
+	}
+
+	returnIDs := make([]uuid.UUID, len(returns))
+	for i := range returns {
+		returnIDs[i] = returns[i].ID
+	}
+
+	var items []models.PurchaseReturnItem
+	err := r.db.Select(&items,
+		`SELECT * FROM purchase_return_items WHERE return_id = ANY($1) ORDER BY created_at ASC`,
+		pq.Array(returnIDs))
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching purchase return items")
+	}
+
+	itemsByReturn := make(map[uuid.UUID][]models.PurchaseReturnItem, len(returns))
+	for _, item := range items {
+		itemsByReturn[item.ReturnID] = append(itemsByReturn[item.ReturnID], item)
+	}
+
+	for i := range returns {
+		returns[i].Items = itemsByReturn[returns[i].ID]
+	}
+
+	return nil
+}
+
+// checkPurchaseHasNoReturns keeps purchases with returns from being edited or
+// deleted, since the returns were credited against their lines and total
+func (r *Repository) checkPurchaseHasNoReturns(tx *sqlx.Tx, purchaseID uuid.UUID, action string) error {
+	var exists bool
+	err := tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM purchase_returns WHERE purchase_id = $1)`, purchaseID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error checking purchase returns")
+	}
+
+	if exists {
+		return errors.ValidationError(fmt.Sprintf("Cannot %s a purchase that has returns", action))
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package sales
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/app/venside/internal/mapper"
 	"github.com/app/venside/internal/models"
@@ -29,7 +30,12 @@ func (c *Controller) ListSales(ctx echo.Context) error {
 		return errors.ValidationError("Invalid inventory ID")
 	}
 
-	sales, err := c.repo.ListSales(inventoryID)
+	query, err := utils.ParseListQuery(ctx, saleSortColumns, "createdAt", "paymentStatus", "customerId")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListSales(inventoryID, query)
 	if err != nil {
 		return logger.Error(ctx, "Failed to fetch sales", err, logrus.Fields{
 			"details":      err.Error(),
@@ -37,21 +43,23 @@ func (c *Controller) ListSales(ctx echo.Context) error {
 		})
 	}
 
-	response := make([]models.SaleResponse, len(sales))
-	for i, sale := range sales {
-		response[i] = *mapper.ToSaleResponse(&sale)
-	}
+	response := utils.MapPage(page, mapper.ToSaleResponse)
 
 	return ctx.JSON(http.StatusOK, response)
 }
 
 func (c *Controller) GetSale(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	saleID, err := uuid.Parse(ctx.Param("saleId"))
 	if err != nil {
 		return errors.ValidationError("Invalid sale ID")
 	}
 
-	sale, err := c.repo.GetSale(saleID)
+	sale, err := c.repo.GetSale(saleID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to retrieve sale", err, logrus.Fields{
 			"details": err.Error(),
@@ -74,9 +82,17 @@ func (c *Controller) CreateSale(ctx echo.Context) error {
 		return err
 	}
 
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
 	newSale := mapper.ToCreateSale(&req, inventoryID)
+	if err := priceSale(newSale, &req); err != nil {
+		return err
+	}
 
-	if err := c.repo.CreateSale(newSale); err != nil {
+	if err := c.repo.CreateSale(newSale, user.ID); err != nil {
 		return logger.Error(ctx, "Failed to create sale", err, logrus.Fields{
 			"details":       err.Error(),
 			"customer_name": newSale.CustomerName,
@@ -87,6 +103,90 @@ func (c *Controller) CreateSale(ctx echo.Context) error {
 	return ctx.JSON(http.StatusCreated, response)
 }
 
+func (c *Controller) UpdateSale(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	saleID, err := uuid.Parse(ctx.Param("saleId"))
+	if err != nil {
+		return errors.ValidationError("Invalid sale ID")
+	}
+
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	var req models.SaleRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	existing, err := c.repo.GetSale(saleID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to retrieve sale", err, logrus.Fields{
+			"details": err.Error(),
+			"sale_id": saleID,
+		})
+	}
+
+	if err := checkEditedItems(req.Items, &existing); err != nil {
+		return err
+	}
+
+	updatedSale := mapper.ToEditSale(&req, &existing)
+	if err := priceSale(updatedSale, &req); err != nil {
+		return err
+	}
+
+	if err := c.repo.UpdateSale(updatedSale, user.ID); err != nil {
+		return logger.Error(ctx, "Failed to update sale", err, logrus.Fields{
+			"details": err.Error(),
+			"sale_id": saleID,
+		})
+	}
+
+	sale, err := c.repo.GetSale(saleID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to retrieve sale", err, logrus.Fields{
+			"details": err.Error(),
+			"sale_id": saleID,
+		})
+	}
+
+	response := mapper.ToSaleResponse(&sale)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) ListSaleRevisions(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	saleID, err := uuid.Parse(ctx.Param("saleId"))
+	if err != nil {
+		return errors.ValidationError("Invalid sale ID")
+	}
+
+	revisions, err := c.repo.ListSaleRevisions(saleID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch sale revisions", err, logrus.Fields{
+			"details": err.Error(),
+			"sale_id": saleID,
+		})
+	}
+
+	response := make([]*models.DocumentRevisionResponse, len(revisions))
+	for i := range revisions {
+		response[i] = mapper.ToDocumentRevisionResponse(&revisions[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
 func (c *Controller) DeleteSale(ctx echo.Context) error {
 	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
 	if err != nil {
@@ -98,7 +198,12 @@ func (c *Controller) DeleteSale(ctx echo.Context) error {
 		return errors.ValidationError("Invalid sale ID")
 	}
 
-	if err := c.repo.DeleteSale(saleID, inventoryID); err != nil {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	if err := c.repo.DeleteSale(saleID, inventoryID, user.ID); err != nil {
 		return logger.Error(ctx, "Failed to delete sale", err, logrus.Fields{
 			"details": err.Error(),
 			"sale_id": saleID,
@@ -107,3 +212,59 @@ func (c *Controller) DeleteSale(ctx echo.Context) error {
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+func (c *Controller) ListSalePayments(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	saleID, err := uuid.Parse(ctx.Param("saleId"))
+	if err != nil {
+		return errors.ValidationError("Invalid sale ID")
+	}
+
+	payments, err := c.repo.ListSalePayments(saleID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch sale payments", err, logrus.Fields{
+			"details": err.Error(),
+			"sale_id": saleID,
+		})
+	}
+
+	response := make([]*models.SalePaymentResponse, len(payments))
+	for i := range payments {
+		response[i] = mapper.ToSalePaymentResponse(&payments[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) CreateSalePayment(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	saleID, err := uuid.Parse(ctx.Param("saleId"))
+	if err != nil {
+		return errors.ValidationError("Invalid sale ID")
+	}
+
+	var req models.SalePaymentRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	payment := mapper.ToCreateSalePayment(&req, saleID, time.Now())
+
+	if err := c.repo.CreateSalePayment(payment, inventoryID); err != nil {
+		return logger.Error(ctx, "Failed to record sale payment", err, logrus.Fields{
+			"details": err.Error(),
+			"sale_id": saleID,
+		})
+	}
+
+	response := mapper.ToSalePaymentResponse(payment)
+	return ctx.JSON(http.StatusCreated, response)
+}
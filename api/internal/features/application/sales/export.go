@@ -0,0 +1,80 @@
+package sales
+
+import (
+	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+var saleExportColumns = []utils.ExportColumn[models.Sale]{
+	{Header: "Number", Key: "saleNumber", Value: func(s *models.Sale) any { return s.SaleNumber }},
+	{Header: "Date", Key: "saleDate", Value: func(s *models.Sale) any { return s.SaleDate }},
+	{Header: "Customer", Key: "customerName", Value: func(s *models.Sale) any { return s.CustomerName }},
+	{Header: "Payment status", Key: "paymentStatus", Value: func(s *models.Sale) any { return s.PaymentStatus }},
+	{Header: "Discount", Key: "discountAmount", Money: true, Value: func(s *models.Sale) any { return s.DiscountAmount }},
+	{Header: "Discount %", Key: "discountPercent", Value: func(s *models.Sale) any { return s.DiscountPercent }},
+	{Header: "Total", Key: "totalAmount", Money: true, Value: func(s *models.Sale) any { return s.TotalAmount }},
+	{Header: "Balance", Key: "balance", Money: true, Value: func(s *models.Sale) any { return s.Balance }},
+	{Header: "Created at", Key: "createdAt", Value: func(s *models.Sale) any { return s.CreatedAt }},
+}
+
+// ExportSales downloads every sale matching the list filters as CSV, XLSX or JSON
+func (c *Controller) ExportSales(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	query, err := utils.ParseListQuery(ctx, saleSortColumns, "createdAt", "paymentStatus", "customerId")
+	if err != nil {
+		return err
+	}
+
+	format, err := utils.ParseExportFormat(ctx)
+	if err != nil {
+		return err
+	}
+
+	currency, err := c.repo.GetCurrency(inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch inventory currency", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	exporter := utils.NewExporter(ctx, format, "sales", saleExportColumns, currency.Code, currency.Locale)
+	if err := c.repo.ExportSales(inventoryID, query, exporter.Write); err != nil {
+		return logger.Error(ctx, "Failed to export sales", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	return exporter.Close()
+}
+
+// ExportSales streams the sales matching the list filters to write, in list
+// order and without paging
+func (r *Repository) ExportSales(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Sale) error) error {
+	conditions, err := saleListConditions(inventoryID, query)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.db.Queryx(r.db.Rebind(`SELECT * FROM sales`+conditions.Where()+query.OrderBy("id")), conditions.Args()...)
+	if err != nil {
+		return errors.DatabaseError(err, "Error exporting sales")
+	}
+
+	return utils.EachRow(rows, write)
+}
+
+func (r *Repository) GetCurrency(inventoryID uuid.UUID) (models.Currency, error) {
+	return inventories.GetCurrency(r.db, inventoryID)
+}
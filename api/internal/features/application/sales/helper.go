@@ -0,0 +1,572 @@
+package sales
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/app/venside/internal/features/application/warehouses"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// REPOSITORY HELPERS
+
+// saleListConditions turns the filters of a sale list into its WHERE clause,
+// shared by the paged list and the export
+func saleListConditions(inventoryID uuid.UUID, query utils.ListQuery) (*utils.Conditions, error) {
+	conditions := utils.NewConditions("inventory_id = ?", inventoryID)
+	if status, ok := query.Filters["paymentStatus"]; ok {
+		conditions.Add("payment_status = ?", status)
+	}
+
+	customerID, err := query.UUIDFilter("customerId")
+	if err != nil {
+		return nil, err
+	}
+	if customerID != nil {
+		conditions.Add("customer_id = ?", *customerID)
+	}
+
+	if query.From != nil {
+		conditions.Add("sale_date >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("sale_date <= ?", *query.To)
+	}
+
+	return conditions, nil
+}
+
+// deductSaleStock resolves the source warehouse of every sale item and takes the
+// sold quantity out of it. Rows are locked so concurrent sales cannot oversell.
+func (r *Repository) deductSaleStock(tx *sqlx.Tx, sale *models.Sale, userID uuid.UUID) error {
+	var mainWarehouseID *uuid.UUID
+
+	for i := range sale.Items {
+		item := &sale.Items[i]
+
+		if item.WarehouseID != nil {
+			if err := r.checkSaleWarehouse(tx, *item.WarehouseID, sale.InventoryID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Fall back to the inventory's main warehouse
+		if mainWarehouseID == nil {
+			warehouseID, err := r.getMainWarehouseID(tx, sale.InventoryID)
+			if err != nil {
+				return err
+			}
+			mainWarehouseID = &warehouseID
+		}
+
+		warehouseID := *mainWarehouseID
+		item.WarehouseID = &warehouseID
+	}
+
+	// Lock rows in a stable order so two sales touching the same products cannot deadlock
+	order := make([]int, len(sale.Items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		itemA, itemB := sale.Items[order[a]], sale.Items[order[b]]
+		if itemA.ProductID != itemB.ProductID {
+			return itemA.ProductID.String() < itemB.ProductID.String()
+		}
+		return itemA.WarehouseID.String() < itemB.WarehouseID.String()
+	})
+
+	for _, i := range order {
+		movement := models.StockMovement{
+			InventoryID: sale.InventoryID,
+			Reason:      models.MovementSale,
+			DocumentID:  &sale.ID,
+			UserID:      &userID,
+		}
+		if err := r.takeStock(tx, movement, &sale.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) takeStock(tx *sqlx.Tx, movement models.StockMovement, item *models.SaleItem) error {
+	productName, err := warehouses.LockProduct(tx, item.ProductID, movement.InventoryID)
+	if err != nil {
+		return err
+	}
+
+	var currentStock int
+	err = tx.Get(&currentStock,
+		`SELECT quantity_in_stock
+         FROM warehouse_product_link
+         WHERE warehouse_id = $1 AND product_id = $2
+         FOR UPDATE`,
+		item.WarehouseID, item.ProductID)
+	if err != nil && err != sql.ErrNoRows {
+		return errors.DatabaseError(err, "Error checking warehouse stock")
+	}
+
+	if currentStock < item.Quantity {
+		return errors.ValidationError(fmt.Sprintf("Insufficient stock for \"%s\". Available: %d, Requested: %d",
+			productName, currentStock, item.Quantity))
+	}
+
+	_, err = tx.Exec(
+		`UPDATE warehouse_product_link
+         SET quantity_in_stock = quantity_in_stock - $1
+         WHERE warehouse_id = $2 AND product_id = $3`,
+		item.Quantity, item.WarehouseID, item.ProductID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error deducting warehouse stock")
+	}
+
+	// Remove the product from the warehouse if stock becomes 0
+	_, err = tx.Exec(
+		`DELETE FROM warehouse_product_link
+         WHERE warehouse_id = $1 AND product_id = $2 AND quantity_in_stock = 0`,
+		item.WarehouseID, item.ProductID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error cleaning up zero stock")
+	}
+
+	// Sold units leave both the stocked and the owned quantity
+	_, err = tx.Exec(
+		`UPDATE products
+         SET total_stock = total_stock - $1,
+             total_quantity = total_quantity - $1
+         WHERE id = $2`,
+		item.Quantity, item.ProductID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error updating product total stock")
+	}
+
+	// The line remembers what its units cost when they left
+	cost, err := warehouses.ConsumeCostLayers(tx, movement.InventoryID, item.ProductID, item.Quantity, movement.DocumentID)
+	if err != nil {
+		return err
+	}
+	item.UnitCost = utils.ShareOf(cost, 1, item.Quantity)
+
+	movement.ProductID = item.ProductID
+	movement.WarehouseID = *item.WarehouseID
+	movement.Quantity = -item.Quantity
+
+	return warehouses.RecordMovement(tx, movement)
+}
+
+// restoreSaleStock returns the quantities taken by a sale to their source warehouses.
+// Items recorded without a warehouse never deducted stock and are skipped.
+func (r *Repository) restoreSaleStock(tx *sqlx.Tx, saleID, inventoryID, userID uuid.UUID) ([]models.SaleItem, error) {
+	var items []models.SaleItem
+	err := tx.Select(&items,
+		`SELECT id, sale_id, product_id, warehouse_id, quantity, unit_price, unit_cost,
+                discount_amount, discount_percent, subtotal, created_at
+         FROM sale_items
+         WHERE sale_id = $1
+         ORDER BY product_id, warehouse_id`,
+		saleID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching sale items")
+	}
+
+	if err := warehouses.ReleaseCostLayers(tx, saleID); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.WarehouseID == nil {
+			continue
+		}
+
+		_, err = tx.NamedExec(
+			`INSERT INTO warehouse_product_link (product_id, warehouse_id, quantity_in_stock)
+             VALUES (:product_id, :warehouse_id, :quantity_in_stock)
+             ON CONFLICT (product_id, warehouse_id)
+             DO UPDATE SET
+                 quantity_in_stock = warehouse_product_link.quantity_in_stock + :quantity_in_stock`,
+			map[string]interface{}{
+				"product_id":        item.ProductID,
+				"warehouse_id":      item.WarehouseID,
+				"quantity_in_stock": item.Quantity,
+			})
+		if err != nil {
+			return nil, errors.DatabaseError(err, "Error restoring warehouse stock")
+		}
+
+		_, err = tx.Exec(
+			`UPDATE products
+             SET total_stock = total_stock + $1,
+                 total_quantity = total_quantity + $1
+             WHERE id = $2`,
+			item.Quantity, item.ProductID)
+		if err != nil {
+			return nil, errors.DatabaseError(err, "Error updating product total stock")
+		}
+
+		// Reverses the sale's own movement, so it keeps the sale reason
+		err = warehouses.RecordMovement(tx, models.StockMovement{
+			InventoryID: inventoryID,
+			ProductID:   item.ProductID,
+			WarehouseID: *item.WarehouseID,
+			Quantity:    item.Quantity,
+			Reason:      models.MovementSale,
+			DocumentID:  &saleID,
+			UserID:      &userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+func (r *Repository) getMainWarehouseID(tx *sqlx.Tx, inventoryID uuid.UUID) (uuid.UUID, error) {
+	var warehouseID uuid.UUID
+	err := tx.Get(&warehouseID,
+		`SELECT id FROM warehouses
+         WHERE inventory_id = $1 AND is_main = true
+         ORDER BY created_at ASC
+         LIMIT 1`,
+		inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return warehouseID, errors.ValidationError("No source warehouse given and the inventory has no main warehouse")
+		}
+		return warehouseID, errors.DatabaseError(err, "Error getting main warehouse")
+	}
+
+	return warehouseID, nil
+}
+
+func (r *Repository) checkSaleWarehouse(tx *sqlx.Tx, warehouseID, inventoryID uuid.UUID) error {
+	var exists bool
+	err := tx.Get(&exists,
+		`SELECT EXISTS(SELECT 1 FROM warehouses WHERE id = $1 AND inventory_id = $2)`,
+		warehouseID, inventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error validating warehouse")
+	}
+
+	if !exists {
+		return errors.ValidationError(fmt.Sprintf("Warehouse with ID %s not found", warehouseID))
+	}
+
+	return nil
+}
+
+func (r *Repository) checkSaleCustomer(tx *sqlx.Tx, customerID *uuid.UUID, inventoryID uuid.UUID) error {
+	if customerID == nil {
+		return nil
+	}
+
+	var exists bool
+	err := tx.Get(&exists,
+		`SELECT EXISTS(SELECT 1 FROM customers WHERE id = $1 AND inventory_id = $2)`,
+		customerID, inventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error validating customer")
+	}
+
+	if !exists {
+		return errors.ValidationError(fmt.Sprintf("Customer with ID %s not found", *customerID))
+	}
+
+	return nil
+}
+
+// checkCustomerCredit stops a sale from leaving its customer owing more than
+// their credit limit across all their open sales. Sales without a customer or
+// balance and customers without a limit pass.
+func (r *Repository) checkCustomerCredit(tx *sqlx.Tx, sale *models.Sale) error {
+	if sale.CustomerID == nil || sale.Balance == 0 {
+		return nil
+	}
+
+	// Lock the customer so two sales cannot both fit under the same limit
+	var creditLimit *int
+	err := tx.Get(&creditLimit,
+		`SELECT credit_limit FROM customers WHERE id = $1 AND inventory_id = $2 FOR UPDATE`,
+		sale.CustomerID, sale.InventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error getting customer credit limit")
+	}
+	if creditLimit == nil {
+		return nil
+	}
+
+	var owed int
+	err = tx.Get(&owed,
+		`SELECT COALESCE(SUM(balance), 0)
+         FROM sales
+         WHERE customer_id = $1 AND inventory_id = $2 AND id <> $3
+           AND payment_status <> 'cancelled'`,
+		sale.CustomerID, sale.InventoryID, sale.ID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error summing customer balance")
+	}
+
+	if owed+sale.Balance > *creditLimit {
+		return errors.ValidationError(fmt.Sprintf("Sale exceeds the customer's credit limit of %d. Owed: %d, On this sale: %d",
+			*creditLimit, owed, sale.Balance))
+	}
+
+	return nil
+}
+
+func sameCustomer(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// syncSaleItems writes the edited lines of a sale: lines that already exist are
+// updated in place, new ones inserted and the ones left out deleted.
+func (r *Repository) syncSaleItems(tx *sqlx.Tx, sale *models.Sale, existing []models.SaleItem) error {
+	existingIDs := make(map[uuid.UUID]bool, len(existing))
+	for _, item := range existing {
+		existingIDs[item.ID] = true
+	}
+
+	keptIDs := make([]uuid.UUID, 0, len(sale.Items))
+	for _, item := range sale.Items {
+		keptIDs = append(keptIDs, item.ID)
+
+		query := `
+			INSERT INTO sale_items (
+				id, sale_id, product_id, warehouse_id, quantity, unit_price, unit_cost,
+				discount_amount, discount_percent, subtotal, created_at
+			) VALUES (
+				:id, :sale_id, :product_id, :warehouse_id, :quantity, :unit_price, :unit_cost,
+				:discount_amount, :discount_percent, :subtotal, :created_at
+			)
+		`
+		if existingIDs[item.ID] {
+			query = `
+				UPDATE sale_items SET
+					product_id = :product_id,
+					warehouse_id = :warehouse_id,
+					quantity = :quantity,
+					unit_price = :unit_price,
+					unit_cost = :unit_cost,
+					discount_amount = :discount_amount,
+					discount_percent = :discount_percent,
+					subtotal = :subtotal
+				WHERE id = :id AND sale_id = :sale_id
+			`
+		}
+
+		if _, err := tx.NamedExec(query, item); err != nil {
+			return errors.DatabaseError(err, "Error saving sale item")
+		}
+	}
+
+	_, err := tx.Exec(`DELETE FROM sale_items WHERE sale_id = $1 AND NOT (id = ANY($2))`, sale.ID, pq.Array(keptIDs))
+	if err != nil {
+		return errors.DatabaseError(err, "Error deleting sale items")
+	}
+
+	return nil
+}
+
+// recordSaleRevision stores the given state of a sale as its next revision
+func (r *Repository) recordSaleRevision(tx *sqlx.Tx, sale *models.Sale, editedBy uuid.UUID) error {
+	snapshot, err := json.Marshal(sale)
+	if err != nil {
+		return errors.DatabaseError(err, "Error encoding sale revision")
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO document_revisions (id, inventory_id, document_type, document_id, revision, snapshot, edited_by, created_at)
+         SELECT $1, $2, $3, $4, COALESCE(MAX(revision), 0) + 1, $5, $6, $7
+         FROM document_revisions
+         WHERE document_type = $3 AND document_id = $4`,
+		uuid.New(), sale.InventoryID, models.DocumentTypeSale, sale.ID, string(snapshot), editedBy, time.Now())
+	if err != nil {
+		return errors.DatabaseError(err, "Error recording sale revision")
+	}
+
+	return nil
+}
+
+func (r *Repository) insertSalePayment(tx *sqlx.Tx, payment *models.SalePayment) error {
+	query := `
+		INSERT INTO sale_payments (
+			id, sale_id, amount, method, reference, payment_date, created_at
+		) VALUES (
+			:id, :sale_id, :amount, :method, :reference, :payment_date, :created_at
+		)
+	`
+	if _, err := tx.NamedExec(query, payment); err != nil {
+		return errors.DatabaseError(err, "Error creating sale payment")
+	}
+
+	return nil
+}
+
+// saleLedger sums what was paid, returned and refunded on a sale
+type saleLedger struct {
+	Paid     int `db:"paid"`
+	Returned int `db:"returned"`
+	Refunded int `db:"refunded"`
+}
+
+func (r *Repository) getSaleLedger(tx *sqlx.Tx, saleID uuid.UUID) (saleLedger, error) {
+	var ledger saleLedger
+	err := tx.Get(&ledger,
+		`SELECT
+             (SELECT COALESCE(SUM(amount), 0) FROM sale_payments WHERE sale_id = $1) AS paid,
+             COALESCE(SUM(total_amount), 0) AS returned,
+             COALESCE(SUM(refund_amount), 0) AS refunded
+         FROM sale_returns
+         WHERE sale_id = $1`,
+		saleID)
+	if err != nil {
+		return ledger, errors.DatabaseError(err, "Error summing sale payments")
+	}
+
+	return ledger, nil
+}
+
+// applySalePayments recomputes the balance and payment status of a sale from its
+// payments ledger and stores them. Returns lower what is owed and refunds what
+// was paid back. The sale row must already be locked.
+func (r *Repository) applySalePayments(tx *sqlx.Tx, sale *models.Sale) error {
+	ledger, err := r.getSaleLedger(tx, sale.ID)
+	if err != nil {
+		return err
+	}
+
+	total := sale.TotalAmount - ledger.Returned
+	paid := ledger.Paid - ledger.Refunded
+
+	if paid > total {
+		return errors.ValidationError(fmt.Sprintf("Payments of %d exceed the sale total of %d", paid, total))
+	}
+
+	sale.Balance = total - paid
+	sale.PaymentStatus = salePaymentStatus(sale.PaymentStatus, total, paid)
+	sale.UpdatedAt = time.Now()
+
+	_, err = tx.Exec(`UPDATE sales SET balance = $1, payment_status = $2, updated_at = $3 WHERE id = $4`,
+		sale.Balance, sale.PaymentStatus, sale.UpdatedAt, sale.ID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error updating sale balance")
+	}
+
+	return nil
+}
+
+// salePaymentStatus derives the payment status from the amount paid. Cancelled
+// sales stay cancelled and overdue ones stay overdue until settled.
+func salePaymentStatus(current string, total, paid int) string {
+	switch {
+	case current == models.PaymentStatusCancelled:
+		return current
+	case paid >= total:
+		return models.PaymentStatusPaid
+	case current == models.PaymentStatusOverdue:
+		return current
+	case paid > 0:
+		return models.PaymentStatusPartial
+	default:
+		return models.PaymentStatusPending
+	}
+}
+
+// loadSaleItems attaches items and their products to the given sales with one query
+func (r *Repository) loadSaleItems(sales []models.Sale) error {
+	if len(sales) == 0 {
+		return nil
+	}
+
+	saleIDs := make([]uuid.UUID, len(sales))
+	for i := range sales {
+		saleIDs[i] = sales[i].ID
+	}
+
+	itemsQuery := `
+    SELECT 
+        si.id, si.sale_id, si.product_id, si.warehouse_id, si.quantity, 
+        si.unit_price, si.unit_cost, si.discount_amount, si.discount_percent, si.subtotal, si.created_at,
+        p.id as "product.id", p.name as "product.name", 
+        p.code as "product.code", p.sku as "product.sku",
+        p.brand as "product.brand", p.model as "product.model",
+        p.description as "product.description", p.total_quantity as "product.total_quantity",
+        p.total_stock as "product.total_stock", p.restock_level as "product.restock_level",
+        p.optimal_level as "product.optimal_level", p.cost_price as "product.cost_price",
+        p.selling_price as "product.selling_price", p.inventory_id as "product.inventory_id",
+        p.created_at as "product.created_at", p.updated_at as "product.updated_at"
+    FROM sale_items si
+    LEFT JOIN products p ON si.product_id = p.id
+    WHERE si.sale_id = ANY($1)
+    ORDER BY si.created_at ASC
+`
+
+	type SaleItemWithProduct struct {
+		models.SaleItem
+		Product models.Product
+	}
+
+	var itemsWithProducts []SaleItemWithProduct
+	err := r.db.Select(&itemsWithProducts, itemsQuery, pq.Array(saleIDs))
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching sale items")
+	}
+
+	// Convert to SaleItems with Product pointers, grouped by sale
+	itemsBySale := make(map[uuid.UUID][]models.SaleItem, len(sales))
+	for i := range itemsWithProducts {
+		item := itemsWithProducts[i].SaleItem
+		if itemsWithProducts[i].Product.ID != uuid.Nil {
+			item.Product = &itemsWithProducts[i].Product
+		}
+		itemsBySale[item.SaleID] = append(itemsBySale[item.SaleID], item)
+	}
+
+	for i := range sales {
+		items := itemsBySale[sales[i].ID]
+		if items == nil {
+			items = []models.SaleItem{}
+		}
+		sales[i].Items = items
+	}
+
+	return nil
+}
+
+// invalidateStockCaches drops cached products whose stock changed with a sale
+func (r *Repository) invalidateStockCaches(items []models.SaleItem, inventoryID uuid.UUID) {
+	for _, item := range items {
+		r.cache.Delete("product:" + item.ProductID.String())
+	}
+	r.cache.Delete("products:" + inventoryID.String())
+}
+
+// CONTROLLER HELPERS
+
+// checkEditedItems makes sure every line ID sent with an edit belongs to the sale
+func checkEditedItems(items []models.SaleItemRequest, existing *models.Sale) error {
+	existingIDs := make([]uuid.UUID, len(existing.Items))
+	for i, item := range existing.Items {
+		existingIDs[i] = item.ID
+	}
+	editedIDs := make([]*string, len(items))
+	for i, item := range items {
+		editedIDs[i] = item.ID
+	}
+
+	return utils.CheckEditedLines(existingIDs, editedIDs, "sale")
+}
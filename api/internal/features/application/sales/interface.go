@@ -2,20 +2,41 @@ package sales
 
 import (
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type SaleRepository interface {
-	ListSales(inventoryID uuid.UUID) ([]models.Sale, error)
-	GetSale(saleID uuid.UUID) (models.Sale, error)
-	CreateSale(sale *models.Sale) error
-	DeleteSale(saleID, inventoryID uuid.UUID) error
+	ListSales(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Sale], error)
+	ExportSales(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Sale) error) error
+	GetCurrency(inventoryID uuid.UUID) (models.Currency, error)
+	GetSale(saleID, inventoryID uuid.UUID) (models.Sale, error)
+	CreateSale(sale *models.Sale, userID uuid.UUID) error
+	UpdateSale(sale *models.Sale, editedBy uuid.UUID) error
+	DeleteSale(saleID, inventoryID, userID uuid.UUID) error
+	ListSaleRevisions(saleID, inventoryID uuid.UUID) ([]models.DocumentRevision, error)
+	ListSalePayments(saleID, inventoryID uuid.UUID) ([]models.SalePayment, error)
+	CreateSalePayment(payment *models.SalePayment, inventoryID uuid.UUID) error
+	GetSaleDocument(saleID, inventoryID uuid.UUID, layout string) (models.SaleDocument, error)
+	ListReturns(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.SaleReturn], error)
+	ListSaleReturns(saleID, inventoryID uuid.UUID) ([]models.SaleReturn, error)
+	CreateSaleReturn(ret *models.SaleReturn) error
 }
 
 type SaleController interface {
 	ListSales(ctx echo.Context) error
+	ExportSales(ctx echo.Context) error
 	GetSale(ctx echo.Context) error
 	CreateSale(ctx echo.Context) error
+	UpdateSale(ctx echo.Context) error
 	DeleteSale(ctx echo.Context) error
+	ListSaleRevisions(ctx echo.Context) error
+	ListSalePayments(ctx echo.Context) error
+	CreateSalePayment(ctx echo.Context) error
+	GetSaleInvoice(ctx echo.Context) error
+	GetSaleReceipt(ctx echo.Context) error
+	ListReturns(ctx echo.Context) error
+	ListSaleReturns(ctx echo.Context) error
+	CreateSaleReturn(ctx echo.Context) error
 }
@@ -0,0 +1,37 @@
+package sales
+
+import (
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+)
+
+// priceSale derives every amount of a sale from its quantities and prices.
+//
+// Each line is quantity * unit price, less its percentage discount, less its
+// fixed discount. The sale total is the sum of the lines, less the order
+// percentage discount, less the order fixed discount. Percentages round half up.
+//
+// Totals sent by the client are optional but must agree with the computed ones.
+func priceSale(sale *models.Sale, req *models.SaleRequest) error {
+	lines := make([]utils.LinePrice, len(sale.Items))
+	for i, item := range sale.Items {
+		lines[i] = utils.LinePrice{
+			Quantity:        item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			DiscountPercent: item.DiscountPercent,
+			DiscountAmount:  item.DiscountAmount,
+			Subtotal:        req.Items[i].Subtotal,
+		}
+	}
+
+	subtotals, sum, err := utils.PriceLines(lines)
+	if err != nil {
+		return err
+	}
+	for i := range sale.Items {
+		sale.Items[i].Subtotal = subtotals[i]
+	}
+
+	sale.TotalAmount, err = utils.PriceTotal(sum, sale.DiscountPercent, sale.DiscountAmount, 0, req.TotalAmount, "sale")
+	return err
+}
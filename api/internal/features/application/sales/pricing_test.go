@@ -0,0 +1,103 @@
+package sales
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/app/venside/internal/models"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestPriceSale(t *testing.T) {
+	tests := []struct {
+		name          string
+		items         []models.SaleItemRequest
+		percent       int
+		fixed         int
+		total         *int
+		wantSubtotals []int
+		wantTotal     int
+		wantErr       string
+	}{
+		{
+			name: "line and order discounts",
+			items: []models.SaleItemRequest{
+				{Quantity: 3, UnitPrice: 333, DiscountPercent: 10},
+				{Quantity: 2, UnitPrice: 1250, DiscountAmount: 500},
+			},
+			percent:       5,
+			fixed:         100,
+			wantSubtotals: []int{899, 2000},
+			wantTotal:     2654, // 2899 - 145 - 100
+		},
+		{
+			name: "client totals that match",
+			items: []models.SaleItemRequest{
+				{Quantity: 1, UnitPrice: 999, DiscountPercent: 50, Subtotal: intPtr(499)},
+			},
+			total:         intPtr(499),
+			wantSubtotals: []int{499}, // 499.5 off rounds up
+			wantTotal:     499,
+		},
+		{
+			name: "client total that does not match",
+			items: []models.SaleItemRequest{
+				{Quantity: 2, UnitPrice: 500},
+			},
+			percent: 10,
+			total:   intPtr(1000),
+			wantErr: "Total amount 1000 does not match the computed 900",
+		},
+		{
+			name: "order discount exceeding the lines",
+			items: []models.SaleItemRequest{
+				{Quantity: 1, UnitPrice: 500},
+			},
+			fixed:   501,
+			wantErr: "Discount exceeds the sale amount",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.SaleRequest{
+				DiscountPercent: tt.percent,
+				DiscountAmount:  tt.fixed,
+				TotalAmount:     tt.total,
+				Items:           tt.items,
+			}
+			sale := &models.Sale{DiscountPercent: tt.percent, DiscountAmount: tt.fixed}
+			for _, item := range tt.items {
+				sale.Items = append(sale.Items, models.SaleItem{
+					Quantity:        item.Quantity,
+					UnitPrice:       item.UnitPrice,
+					DiscountPercent: item.DiscountPercent,
+					DiscountAmount:  item.DiscountAmount,
+				})
+			}
+
+			err := priceSale(sale, req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("priceSale: %v", err)
+			}
+
+			for i, want := range tt.wantSubtotals {
+				if sale.Items[i].Subtotal != want {
+					t.Errorf("item %d subtotal = %d, want %d", i+1, sale.Items[i].Subtotal, want)
+				}
+			}
+			if sale.TotalAmount != tt.wantTotal {
+				t.Errorf("total = %d, want %d", sale.TotalAmount, tt.wantTotal)
+			}
+		})
+	}
+}
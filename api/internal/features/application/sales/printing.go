@@ -0,0 +1,279 @@
+package sales
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetSaleInvoice renders a sale as a full-page PDF invoice
+func (c *Controller) GetSaleInvoice(ctx echo.Context) error {
+	return c.printSale(ctx, models.DocumentLayoutInvoice, func(doc *models.SaleDocument) []byte {
+		return utils.RenderDocumentPDF(saleInvoice(doc))
+	})
+}
+
+// GetSaleReceipt renders a sale as a PDF sized for thermal receipt printers
+func (c *Controller) GetSaleReceipt(ctx echo.Context) error {
+	return c.printSale(ctx, models.DocumentLayoutReceipt, func(doc *models.SaleDocument) []byte {
+		return utils.RenderReceiptPDF(doc.Template.PaperSize, saleReceipt(doc))
+	})
+}
+
+func (c *Controller) printSale(ctx echo.Context, layout string, render func(*models.SaleDocument) []byte) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	saleID, err := uuid.Parse(ctx.Param("saleId"))
+	if err != nil {
+		return errors.ValidationError("Invalid sale ID")
+	}
+
+	doc, err := c.repo.GetSaleDocument(saleID, inventoryID, layout)
+	if err != nil {
+		return logger.Error(ctx, "Failed to print sale", err, logrus.Fields{
+			"details": err.Error(),
+			"sale_id": saleID,
+			"layout":  layout,
+		})
+	}
+
+	filename := fmt.Sprintf("%s-%s.pdf", layout, doc.Sale.SaleNumber)
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s"`, filename))
+
+	return ctx.Blob(http.StatusOK, "application/pdf", render(&doc))
+}
+
+// GetSaleDocument loads a sale with everything its printed layout shows
+func (r *Repository) GetSaleDocument(saleID, inventoryID uuid.UUID, layout string) (models.SaleDocument, error) {
+	var doc models.SaleDocument
+
+	sale, err := r.GetSale(saleID, inventoryID)
+	if err != nil {
+		return doc, err
+	}
+	doc.Sale = sale
+
+	if err := r.db.Get(&doc.InventoryName, `SELECT name FROM inventories WHERE id = $1`, inventoryID); err != nil {
+		return doc, errors.DatabaseError(err, "Error fetching inventory name")
+	}
+
+	// The sale keeps the customer's name even if the customer was deleted since
+	if sale.CustomerID != nil {
+		var customer models.Customer
+		err := r.db.Get(&customer, `SELECT * FROM customers WHERE id = $1 AND inventory_id = $2`, *sale.CustomerID, inventoryID)
+		if err != nil && err != sql.ErrNoRows {
+			return doc, errors.DatabaseError(err, "Error fetching sale customer")
+		}
+		if err == nil {
+			doc.Customer = &customer
+		}
+	}
+
+	if doc.Payments, err = r.ListSalePayments(saleID, inventoryID); err != nil {
+		return doc, err
+	}
+
+	if doc.Currency, err = inventories.GetCurrency(r.db, inventoryID); err != nil {
+		return doc, err
+	}
+
+	if doc.Template, err = inventories.GetDocumentTemplate(r.db, inventoryID, layout); err != nil {
+		return doc, err
+	}
+
+	return doc, nil
+}
+
+// CONTROLLER HELPERS
+
+func saleInvoice(doc *models.SaleDocument) utils.PrintedDocument {
+	sale := &doc.Sale
+	money := func(amount int) string {
+		return utils.PrintedMoney(amount, doc.Currency.Code, doc.Currency.Locale)
+	}
+
+	printed := utils.PrintedDocument{
+		PaperSize:   doc.Template.PaperSize,
+		Title:       doc.Template.Title,
+		Issuer:      doc.InventoryName,
+		IssuerLines: utils.TextLines(doc.Template.Header),
+		Details: [][2]string{
+			{"Number", sale.SaleNumber},
+			{"Date", sale.SaleDate.Format("2006-01-02")},
+			{"Status", utils.Humanize(sale.PaymentStatus)},
+		},
+		PartyTitle: "Bill to",
+		PartyLines: saleCustomerLines(doc),
+		Items: utils.PrintedTable{
+			Columns: []utils.PrintedColumn{
+				{Header: "Item", Width: 0.40},
+				{Header: "Qty", Width: 0.08, Right: true},
+				{Header: "Unit price", Width: 0.17, Right: true},
+				{Header: "Discount", Width: 0.15, Right: true},
+				{Header: "Amount", Width: 0.20, Right: true},
+			},
+		},
+		Footer: doc.Template.Footer,
+	}
+
+	subtotal := 0
+	for _, item := range sale.Items {
+		printed.Items.Rows = append(printed.Items.Rows, []string{
+			saleItemName(&item),
+			strconv.Itoa(item.Quantity),
+			money(item.UnitPrice),
+			utils.DiscountText(item.DiscountPercent, item.DiscountAmount, money),
+			money(item.Subtotal),
+		})
+		subtotal += item.Subtotal
+	}
+
+	printed.Totals = saleTotals(sale, subtotal, money)
+
+	if doc.Template.ShowPayments {
+		payments := utils.PrintedTable{
+			Title: "Payments",
+			Columns: []utils.PrintedColumn{
+				{Header: "Date", Width: 0.20},
+				{Header: "Method", Width: 0.25},
+				{Header: "Reference", Width: 0.35},
+				{Header: "Amount", Width: 0.20, Right: true},
+			},
+		}
+		for _, payment := range doc.Payments {
+			reference := ""
+			if payment.Reference != nil {
+				reference = *payment.Reference
+			}
+			payments.Rows = append(payments.Rows, []string{
+				payment.PaymentDate.Format("2006-01-02"),
+				utils.Humanize(payment.Method),
+				reference,
+				money(payment.Amount),
+			})
+		}
+		printed.Tables = append(printed.Tables, payments)
+	}
+
+	return printed
+}
+
+func saleReceipt(doc *models.SaleDocument) []utils.ReceiptLine {
+	sale := &doc.Sale
+	money := func(amount int) string {
+		return utils.PrintedMoney(amount, doc.Currency.Code, doc.Currency.Locale)
+	}
+
+	lines := []utils.ReceiptLine{{Left: doc.InventoryName, Bold: true, Center: true}}
+	for _, line := range utils.TextLines(doc.Template.Header) {
+		lines = append(lines, utils.ReceiptLine{Left: line, Center: true})
+	}
+	lines = append(lines,
+		utils.ReceiptLine{Left: doc.Template.Title, Bold: true, Center: true},
+		utils.ReceiptLine{Rule: true},
+		utils.ReceiptLine{Left: "No", Right: sale.SaleNumber},
+		utils.ReceiptLine{Left: "Date", Right: sale.SaleDate.Format("2006-01-02 15:04")},
+	)
+	if sale.CustomerName != "" {
+		lines = append(lines, utils.ReceiptLine{Left: "Customer", Right: sale.CustomerName})
+	}
+	lines = append(lines, utils.ReceiptLine{Rule: true})
+
+	subtotal := 0
+	for _, item := range sale.Items {
+		gross := item.Quantity * item.UnitPrice
+		lines = append(lines,
+			utils.ReceiptLine{Left: saleItemName(&item)},
+			utils.ReceiptLine{Left: fmt.Sprintf("  %d x %s", item.Quantity, money(item.UnitPrice)), Right: money(gross)},
+		)
+		if gross != item.Subtotal {
+			lines = append(lines, utils.ReceiptLine{Left: "  Discount", Right: money(item.Subtotal - gross)})
+		}
+		subtotal += item.Subtotal
+	}
+
+	lines = append(lines, utils.ReceiptLine{Rule: true})
+	for _, total := range saleTotals(sale, subtotal, money) {
+		lines = append(lines, utils.ReceiptLine{Left: total.Label, Right: total.Value, Bold: total.Bold})
+	}
+
+	if doc.Template.ShowPayments && len(doc.Payments) > 0 {
+		lines = append(lines, utils.ReceiptLine{Rule: true})
+		for _, payment := range doc.Payments {
+			lines = append(lines, utils.ReceiptLine{
+				Left:  payment.PaymentDate.Format("2006-01-02") + " " + utils.Humanize(payment.Method),
+				Right: money(payment.Amount),
+			})
+		}
+	}
+
+	if doc.Template.Footer != "" {
+		lines = append(lines, utils.ReceiptLine{Rule: true})
+		for _, line := range utils.TextLines(doc.Template.Footer) {
+			lines = append(lines, utils.ReceiptLine{Left: line, Center: true})
+		}
+	}
+
+	return lines
+}
+
+// saleTotals lists the amounts under the items: the order discount, what is
+// paid and the balance due
+func saleTotals(sale *models.Sale, subtotal int, money func(int) string) []utils.PrintedTotal {
+	totals := []utils.PrintedTotal{{Label: "Subtotal", Value: money(subtotal)}}
+
+	if discount := subtotal - sale.TotalAmount; discount != 0 {
+		label := "Discount"
+		if sale.DiscountPercent > 0 {
+			label = fmt.Sprintf("Discount (%d%%)", sale.DiscountPercent)
+		}
+		totals = append(totals, utils.PrintedTotal{Label: label, Value: money(-discount)})
+	}
+
+	return append(totals,
+		utils.PrintedTotal{Label: "Total", Value: money(sale.TotalAmount), Bold: true},
+		utils.PrintedTotal{Label: "Paid", Value: money(sale.TotalAmount - sale.Balance)},
+		utils.PrintedTotal{Label: "Balance due", Value: money(sale.Balance), Bold: true},
+	)
+}
+
+func saleCustomerLines(doc *models.SaleDocument) []string {
+	if doc.Customer == nil {
+		if doc.Sale.CustomerName == "" {
+			return nil
+		}
+		return []string{doc.Sale.CustomerName}
+	}
+
+	lines := []string{doc.Customer.Name}
+	for _, value := range []*string{doc.Customer.Address, doc.Customer.Phone, doc.Customer.Email} {
+		if value != nil && strings.TrimSpace(*value) != "" {
+			lines = append(lines, utils.TextLines(*value)...)
+		}
+	}
+	return lines
+}
+
+func saleItemName(item *models.SaleItem) string {
+	if item.Product == nil {
+		return "Deleted product"
+	}
+	if item.Product.SKU != "" {
+		return fmt.Sprintf("%s (%s)", item.Product.Name, item.Product.SKU)
+	}
+	return item.Product.Name
+}
@@ -3,10 +3,11 @@ package sales
 import (
 	"database/sql"
 	"fmt"
-	"math/rand"
 	"time"
 
+	"github.com/app/venside/internal/features/account/inventories"
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/cache"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
@@ -34,86 +35,68 @@ const (
 	TTL = 30 * 24 * time.Hour
 )
 
-func (r *Repository) ListSales(inventoryID uuid.UUID) ([]models.Sale, error) {
-	key := saleListCacheKey(inventoryID)
+// saleSortColumns maps the sortable API fields of sales to their columns
+var saleSortColumns = map[string]string{
+	"createdAt":    "created_at",
+	"saleDate":     "sale_date",
+	"saleNumber":   "sale_number",
+	"customerName": "customer_name",
+	"totalAmount":  "total_amount",
+	"balance":      "balance",
+}
 
-	var cachedSales []models.Sale
-	if err := r.cache.Get(key, &cachedSales); err == nil {
-		return cachedSales, nil
-	}
+func (r *Repository) ListSales(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Sale], error) {
+	var page utils.Page[models.Sale]
+	key := utils.PageCacheKey(r.cache, saleListCacheKey(inventoryID), query, TTL)
 
-	// First get all sales
-	salesQuery := `SELECT * FROM sales WHERE inventory_id = $1 ORDER BY created_at DESC`
-	sales := []models.Sale{}
+	if err := r.cache.Get(key, &page); err == nil {
+		return page, nil
+	}
 
-	err := r.db.Select(&sales, salesQuery, inventoryID)
+	conditions, err := saleListConditions(inventoryID, query)
 	if err != nil {
-		return nil, errors.DatabaseError(err, "Error fetching sales")
-	}
-
-	// For each sale, get its items with product details
-	for i := range sales {
-		// In both ListSales and GetSale methods, update the itemsQuery to:
-		itemsQuery := `
-    SELECT 
-        si.id, si.sale_id, si.product_id, si.quantity, 
-        si.unit_price, si.subtotal, si.created_at,
-        p.id as "product.id", p.name as "product.name", 
-        p.code as "product.code", p.sku as "product.sku",
-        p.brand as "product.brand", p.model as "product.model",
-        p.description as "product.description", p.total_quantity as "product.total_quantity",
-        p.total_stock as "product.total_stock", p.restock_level as "product.restock_level",
-        p.optimal_level as "product.optimal_level", p.cost_price as "product.cost_price",
-        p.selling_price as "product.selling_price", p.inventory_id as "product.inventory_id",
-        p.created_at as "product.created_at", p.updated_at as "product.updated_at"
-    FROM sale_items si
-    LEFT JOIN products p ON si.product_id = p.id
-    WHERE si.sale_id = $1
-    ORDER BY si.created_at ASC
-`
-
-		type SaleItemWithProduct struct {
-			models.SaleItem
-			Product models.Product
-		}
+		return page, err
+	}
 
-		var itemsWithProducts []SaleItemWithProduct
-		err := r.db.Select(&itemsWithProducts, itemsQuery, sales[i].ID)
-		if err != nil {
-			return nil, errors.DatabaseError(err, "Error fetching sale items")
-		}
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM sales` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting sales")
+	}
 
-		// Convert to SaleItems with Product pointers
-		items := make([]models.SaleItem, len(itemsWithProducts))
-		for j, itemWithProduct := range itemsWithProducts {
-			items[j] = itemWithProduct.SaleItem
-			if itemWithProduct.Product.ID != uuid.Nil {
-				items[j].Product = &itemWithProduct.Product
-			}
-		}
+	salesQuery := r.db.Rebind(`SELECT * FROM sales` + conditions.Where() + query.OrderAndLimit("id"))
+	sales := []models.Sale{}
 
-		sales[i].Items = items
+	if err := r.db.Select(&sales, salesQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching sales")
 	}
 
-	if err := r.cache.Set(key, sales, TTL); err != nil {
-		return sales, errors.CacheError(err, "Error caching sales")
+	// Fetch the items of the whole page in a single query
+	if err := r.loadSaleItems(sales); err != nil {
+		return page, err
+	}
+
+	page = utils.NewPage(sales, total, query)
+
+	if err := r.cache.Set(key, page, TTL); err != nil {
+		return page, errors.CacheError(err, "Error caching sales")
 	}
 
-	return sales, nil
+	return page, nil
 }
 
-func (r *Repository) GetSale(saleID uuid.UUID) (models.Sale, error) {
+func (r *Repository) GetSale(saleID, inventoryID uuid.UUID) (models.Sale, error) {
 	key := saleCacheKey(saleID)
 
 	var cachedSale models.Sale
-	if err := r.cache.Get(key, &cachedSale); err == nil {
+	if err := r.cache.Get(key, &cachedSale); err == nil && cachedSale.InventoryID == inventoryID {
 		return cachedSale, nil
 	}
 
 	var sale models.Sale
-	query := `SELECT * FROM sales WHERE id = $1`
+	query := `SELECT * FROM sales WHERE id = $1 AND inventory_id = $2`
 
-	err := r.db.Get(&sale, query, saleID)
+	err := r.db.Get(&sale, query, saleID, inventoryID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return sale, errors.NotFoundError("Sale not found")
@@ -121,45 +104,11 @@ func (r *Repository) GetSale(saleID uuid.UUID) (models.Sale, error) {
 		return sale, errors.DatabaseError(err, "Error getting sale by ID")
 	}
 
-	itemsQuery := `
-    SELECT 
-        si.id, si.sale_id, si.product_id, si.quantity, 
-        si.unit_price, si.subtotal, si.created_at,
-        p.id as "product.id", p.name as "product.name", 
-        p.code as "product.code", p.sku as "product.sku",
-        p.brand as "product.brand", p.model as "product.model",
-        p.description as "product.description", p.total_quantity as "product.total_quantity",
-        p.total_stock as "product.total_stock", p.restock_level as "product.restock_level",
-        p.optimal_level as "product.optimal_level", p.cost_price as "product.cost_price",
-        p.selling_price as "product.selling_price", p.inventory_id as "product.inventory_id",
-        p.created_at as "product.created_at", p.updated_at as "product.updated_at"
-    FROM sale_items si
-    LEFT JOIN products p ON si.product_id = p.id
-    WHERE si.sale_id = $1
-    ORDER BY si.created_at ASC
-`
-
-	type SaleItemWithProduct struct {
-		models.SaleItem
-		Product models.Product
-	}
-
-	var itemsWithProducts []SaleItemWithProduct
-	err = r.db.Select(&itemsWithProducts, itemsQuery, saleID)
-	if err != nil {
-		return sale, errors.DatabaseError(err, "Error fetching sale items")
-	}
-
-	// Convert to SaleItems with Product pointers
-	items := make([]models.SaleItem, len(itemsWithProducts))
-	for i, itemWithProduct := range itemsWithProducts {
-		items[i] = itemWithProduct.SaleItem
-		if itemWithProduct.Product.ID != uuid.Nil {
-			items[i].Product = &itemWithProduct.Product
-		}
+	sales := []models.Sale{sale}
+	if err := r.loadSaleItems(sales); err != nil {
+		return sale, err
 	}
-
-	sale.Items = items
+	sale = sales[0]
 
 	if err := r.cache.Set(key, sale, TTL); err != nil {
 		return sale, errors.CacheError(err, "Error caching sale")
@@ -168,14 +117,19 @@ func (r *Repository) GetSale(saleID uuid.UUID) (models.Sale, error) {
 	return sale, nil
 }
 
-func (r *Repository) CreateSale(sale *models.Sale) error {
+func (r *Repository) CreateSale(sale *models.Sale, userID uuid.UUID) error {
 	tx, err := r.db.Beginx()
 	if err != nil {
 		return errors.DatabaseError(err, "Error starting transaction")
 	}
 	defer tx.Rollback()
 
-	saleNumber, err := r.generateSaleNumber(sale.SaleDate)
+	if err := r.checkSaleCustomer(tx, sale.CustomerID, sale.InventoryID); err != nil {
+		return err
+	}
+
+	// Numbers follow the order documents are created in, not their backdated dates
+	saleNumber, err := inventories.NextDocumentNumber(tx, sale.InventoryID, models.DocumentTypeSale, time.Now())
 	if err != nil {
 		return err
 	}
@@ -198,13 +152,20 @@ func (r *Repository) CreateSale(sale *models.Sale) error {
 		return errors.DatabaseError(err, "Error creating sale")
 	}
 
+	// Deduct stock from each line's source warehouse
+	if err := r.deductSaleStock(tx, sale, userID); err != nil {
+		return err
+	}
+
 	// Insert sale items
 	if len(sale.Items) > 0 {
 		itemQuery := `
 			INSERT INTO sale_items (
-				id, sale_id, product_id, quantity, unit_price, subtotal, created_at
+				id, sale_id, product_id, warehouse_id, quantity, unit_price, unit_cost,
+				discount_amount, discount_percent, subtotal, created_at
 			) VALUES (
-				:id, :sale_id, :product_id, :quantity, :unit_price,	:subtotal, :created_at
+				:id, :sale_id, :product_id, :warehouse_id, :quantity, :unit_price, :unit_cost,
+				:discount_amount, :discount_percent, :subtotal, :created_at
 			)
 		`
 		for _, item := range sale.Items {
@@ -215,22 +176,164 @@ func (r *Repository) CreateSale(sale *models.Sale) error {
 		}
 	}
 
+	// Record payments taken at checkout and derive the balance from them
+	for i := range sale.Payments {
+		if err := r.insertSalePayment(tx, &sale.Payments[i]); err != nil {
+			return err
+		}
+	}
+	if err := r.applySalePayments(tx, sale); err != nil {
+		return err
+	}
+
+	// Whatever is left unpaid goes on the customer's account
+	if err := r.checkCustomerCredit(tx, sale); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return errors.DatabaseError(err, "Error committing transaction")
 	}
 
 	r.invalidateSaleCaches(sale.ID, sale.InventoryID)
+	r.invalidateStockCaches(sale.Items, sale.InventoryID)
 
 	return nil
 }
 
-func (r *Repository) DeleteSale(saleID, inventoryID uuid.UUID) error {
+// UpdateSale replaces the header and lines of a sale. Stock taken by the old lines
+// is put back before the new lines take theirs, the balance is recomputed from
+// the payments ledger and the previous state is kept as a revision.
+func (r *Repository) UpdateSale(sale *models.Sale, editedBy uuid.UUID) error {
 	tx, err := r.db.Beginx()
 	if err != nil {
 		return errors.DatabaseError(err, "Error starting transaction")
 	}
 	defer tx.Rollback()
 
+	var existing models.Sale
+	err = tx.Get(&existing, `SELECT * FROM sales WHERE id = $1 AND inventory_id = $2 FOR UPDATE`, sale.ID, sale.InventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Sale not found")
+		}
+		return errors.DatabaseError(err, "Error getting sale by ID")
+	}
+
+	if existing.PaymentStatus == models.PaymentStatusCancelled {
+		return errors.ValidationError("Cannot edit a cancelled sale")
+	}
+
+	if err := r.checkSaleHasNoReturns(tx, sale.ID, "edit"); err != nil {
+		return err
+	}
+
+	if err := r.checkSaleCustomer(tx, sale.CustomerID, sale.InventoryID); err != nil {
+		return err
+	}
+
+	existing.Items, err = r.restoreSaleStock(tx, sale.ID, sale.InventoryID, editedBy)
+	if err != nil {
+		return err
+	}
+
+	if err := r.recordSaleRevision(tx, &existing, editedBy); err != nil {
+		return err
+	}
+
+	if err := r.deductSaleStock(tx, sale, editedBy); err != nil {
+		return err
+	}
+
+	if err := r.syncSaleItems(tx, sale, existing.Items); err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExec(`
+		UPDATE sales SET
+			customer_id = :customer_id,
+			customer_name = :customer_name,
+			sale_date = :sale_date,
+			total_amount = :total_amount,
+			discount_amount = :discount_amount,
+			discount_percent = :discount_percent,
+			updated_at = :updated_at
+		WHERE id = :id
+	`, sale)
+	if err != nil {
+		return errors.DatabaseError(err, "Error updating sale")
+	}
+
+	// The total may have moved, so the balance and status follow
+	if err := r.applySalePayments(tx, sale); err != nil {
+		return err
+	}
+
+	// Only an edit that puts more on an account is held to its credit limit
+	if !sameCustomer(existing.CustomerID, sale.CustomerID) || sale.Balance > existing.Balance {
+		if err := r.checkCustomerCredit(tx, sale); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Error committing transaction")
+	}
+
+	r.invalidateSaleCaches(sale.ID, sale.InventoryID)
+	r.invalidateStockCaches(append(existing.Items, sale.Items...), sale.InventoryID)
+
+	return nil
+}
+
+func (r *Repository) ListSaleRevisions(saleID, inventoryID uuid.UUID) ([]models.DocumentRevision, error) {
+	var exists bool
+	err := r.db.Get(&exists, `SELECT EXISTS(SELECT 1 FROM sales WHERE id = $1 AND inventory_id = $2)`, saleID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error getting sale by ID")
+	}
+	if !exists {
+		return nil, errors.NotFoundError("Sale not found")
+	}
+
+	revisions := []models.DocumentRevision{}
+	query := `SELECT * FROM document_revisions
+              WHERE document_type = $1 AND document_id = $2 AND inventory_id = $3
+              ORDER BY revision DESC`
+
+	if err := r.db.Select(&revisions, query, models.DocumentTypeSale, saleID, inventoryID); err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching sale revisions")
+	}
+
+	return revisions, nil
+}
+
+func (r *Repository) DeleteSale(saleID, inventoryID, userID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM sales WHERE id = $1 AND inventory_id = $2)`, saleID, inventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error getting sale by ID")
+	}
+	if !exists {
+		return errors.NotFoundError("Sale not found")
+	}
+
+	if err := r.checkSaleHasNoReturns(tx, saleID, "delete"); err != nil {
+		return err
+	}
+
+	// Put the sold quantities back into their source warehouses
+	items, err := r.restoreSaleStock(tx, saleID, inventoryID, userID)
+	if err != nil {
+		return err
+	}
+
 	// Delete sale items first (due to foreign key constraint)
 	_, err = tx.Exec("DELETE FROM sale_items WHERE sale_id = $1", saleID)
 	if err != nil {
@@ -238,7 +341,7 @@ func (r *Repository) DeleteSale(saleID, inventoryID uuid.UUID) error {
 	}
 
 	// Delete sale
-	_, err = tx.Exec("DELETE FROM sales WHERE id = $1", saleID)
+	_, err = tx.Exec("DELETE FROM sales WHERE id = $1 AND inventory_id = $2", saleID, inventoryID)
 	if err != nil {
 		return errors.DatabaseError(err, "Error deleting sale")
 	}
@@ -248,45 +351,76 @@ func (r *Repository) DeleteSale(saleID, inventoryID uuid.UUID) error {
 	}
 
 	r.invalidateSaleCaches(saleID, inventoryID)
+	r.invalidateStockCaches(items, inventoryID)
 
 	return nil
 }
 
-// Helper methods
-func (r *Repository) invalidateSaleCaches(saleID, inventoryID uuid.UUID) {
-	r.cache.Delete(saleCacheKey(saleID))
-	r.cache.Delete(saleListCacheKey(inventoryID))
-}
+func (r *Repository) ListSalePayments(saleID, inventoryID uuid.UUID) ([]models.SalePayment, error) {
+	var exists bool
+	err := r.db.Get(&exists, `SELECT EXISTS(SELECT 1 FROM sales WHERE id = $1 AND inventory_id = $2)`, saleID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error getting sale by ID")
+	}
+	if !exists {
+		return nil, errors.NotFoundError("Sale not found")
+	}
 
-func (repo *Repository) generateSaleNumber(saleDate time.Time) (string, error) {
-	dateStr := saleDate.Format("060102") // YYMMDD format
+	payments := []models.SalePayment{}
+	query := `SELECT * FROM sale_payments WHERE sale_id = $1 ORDER BY payment_date ASC, created_at ASC`
 
-	// Get count of sales for the given date
-	var count int
-	query := `SELECT COUNT(*) FROM sales WHERE DATE(sale_date) = DATE($1)`
-	if err := repo.db.Get(&count, query, saleDate.Format("2006-01-02")); err != nil {
-		return "", errors.DatabaseError(err, "Error getting sale count")
+	if err := r.db.Select(&payments, query, saleID); err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching sale payments")
 	}
 
-	// Try to generate unique sale number with sequential numbering (max 10 attempts)
-	for i := 0; i < 10; i++ {
-		seqNumber := count + 1 + i
-		candidateNumber := fmt.Sprintf("SO-%s-%03d", dateStr, seqNumber)
+	return payments, nil
+}
 
-		var exists bool
-		existsQuery := `SELECT EXISTS(SELECT 1 FROM sales WHERE sale_number = $1)`
-		if err := repo.db.Get(&exists, existsQuery, candidateNumber); err != nil {
-			return "", errors.DatabaseError(err, "Error checking sale number uniqueness")
-		}
+func (r *Repository) CreateSalePayment(payment *models.SalePayment, inventoryID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	var sale models.Sale
 
-		if !exists {
-			return candidateNumber, nil
+	// Lock the sale so concurrent payments cannot overpay it
+	err = tx.Get(&sale, `SELECT * FROM sales WHERE id = $1 AND inventory_id = $2 FOR UPDATE`, payment.SaleID, inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Sale not found")
 		}
+		return errors.DatabaseError(err, "Error getting sale by ID")
 	}
 
-	// Fallback: Generate random date-like digits and start from 001
-	randomDate := fmt.Sprintf("%06d", rand.Intn(1000000))
-	fallbackNumber := fmt.Sprintf("SO-%s-001", randomDate)
+	if sale.PaymentStatus == models.PaymentStatusCancelled {
+		return errors.ValidationError("Cannot record a payment on a cancelled sale")
+	}
+
+	if payment.Amount > sale.Balance {
+		return errors.ValidationError(fmt.Sprintf("Payment of %d exceeds the outstanding balance of %d", payment.Amount, sale.Balance))
+	}
 
-	return fallbackNumber, nil
+	if err := r.insertSalePayment(tx, payment); err != nil {
+		return err
+	}
+
+	if err := r.applySalePayments(tx, &sale); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Error committing transaction")
+	}
+
+	r.invalidateSaleCaches(sale.ID, inventoryID)
+
+	return nil
+}
+
+// Helper methods
+func (r *Repository) invalidateSaleCaches(saleID, inventoryID uuid.UUID) {
+	r.cache.Delete(saleCacheKey(saleID))
+	r.cache.Delete(saleListCacheKey(inventoryID))
 }
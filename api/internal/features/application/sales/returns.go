@@ -0,0 +1,492 @@
+package sales
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/features/application/warehouses"
+	"github.com/app/venside/internal/mapper"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// saleReturnSortColumns maps the sortable API fields of sale returns to their columns
+var saleReturnSortColumns = map[string]string{
+	"createdAt":    "sr.created_at",
+	"returnDate":   "sr.return_date",
+	"returnNumber": "sr.return_number",
+	"totalAmount":  "sr.total_amount",
+	"refundAmount": "sr.refund_amount",
+}
+
+// ListReturns lists the customer returns of the whole inventory
+func (c *Controller) ListReturns(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	query, err := utils.ParseListQuery(ctx, saleReturnSortColumns, "createdAt", "saleId", "warehouseId")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListReturns(inventoryID, query)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch sale returns", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	response := utils.MapPage(page, mapper.ToSaleReturnResponse)
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (c *Controller) ListSaleReturns(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	saleID, err := uuid.Parse(ctx.Param("saleId"))
+	if err != nil {
+		return errors.ValidationError("Invalid sale ID")
+	}
+
+	returns, err := c.repo.ListSaleReturns(saleID, inventoryID)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch sale returns", err, logrus.Fields{
+			"details": err.Error(),
+			"sale_id": saleID,
+		})
+	}
+
+	response := make([]*models.SaleReturnResponse, len(returns))
+	for i := range returns {
+		response[i] = mapper.ToSaleReturnResponse(&returns[i])
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// CreateSaleReturn takes units of a sale back. Restockable units go into the
+// given warehouse, and the credited value comes off the sale's balance, with
+// anything already paid beyond the new total refunded.
+func (c *Controller) CreateSaleReturn(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	saleID, err := uuid.Parse(ctx.Param("saleId"))
+	if err != nil {
+		return errors.ValidationError("Invalid sale ID")
+	}
+
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	var req models.SaleReturnRequest
+	if err := utils.BindAndValidateRequest(ctx, &req); err != nil {
+		return err
+	}
+
+	ret := mapper.ToCreateSaleReturn(&req, saleID, inventoryID, time.Now())
+	ret.ReturnedBy = &user.ID
+
+	if err := c.repo.CreateSaleReturn(ret); err != nil {
+		return logger.Error(ctx, "Failed to record sale return", err, logrus.Fields{
+			"details": err.Error(),
+			"sale_id": saleID,
+		})
+	}
+
+	response := mapper.ToSaleReturnResponse(ret)
+	return ctx.JSON(http.StatusCreated, response)
+}
+
+func (r *Repository) ListReturns(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.SaleReturn], error) {
+	var page utils.Page[models.SaleReturn]
+
+	conditions := utils.NewConditions("sr.inventory_id = ?", inventoryID)
+
+	saleID, err := query.UUIDFilter("saleId")
+	if err != nil {
+		return page, err
+	}
+	if saleID != nil {
+		conditions.Add("sr.sale_id = ?", *saleID)
+	}
+
+	warehouseID, err := query.UUIDFilter("warehouseId")
+	if err != nil {
+		return page, err
+	}
+	if warehouseID != nil {
+		conditions.Add("sr.warehouse_id = ?", *warehouseID)
+	}
+
+	if query.From != nil {
+		conditions.Add("sr.return_date >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("sr.return_date <= ?", *query.To)
+	}
+
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM sale_returns sr` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting sale returns")
+	}
+
+	returnsQuery := r.db.Rebind(`
+		SELECT sr.*, s.sale_number
+		FROM sale_returns sr
+		JOIN sales s ON s.id = sr.sale_id` + conditions.Where() + query.OrderAndLimit("sr.id"))
+	returns := []models.SaleReturn{}
+
+	if err := r.db.Select(&returns, returnsQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching sale returns")
+	}
+
+	if err := r.loadSaleReturnItems(returns); err != nil {
+		return page, err
+	}
+
+	return utils.NewPage(returns, total, query), nil
+}
+
+func (r *Repository) ListSaleReturns(saleID, inventoryID uuid.UUID) ([]models.SaleReturn, error) {
+	var exists bool
+	err := r.db.Get(&exists, `SELECT EXISTS(SELECT 1 FROM sales WHERE id = $1 AND inventory_id = $2)`, saleID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error getting sale by ID")
+	}
+	if !exists {
+		return nil, errors.NotFoundError("Sale not found")
+	}
+
+	returns := []models.SaleReturn{}
+	query := `
+		SELECT sr.*, s.sale_number
+		FROM sale_returns sr
+		JOIN sales s ON s.id = sr.sale_id
+		WHERE sr.sale_id = $1
+		ORDER BY sr.return_date ASC, sr.created_at ASC
+	`
+
+	if err := r.db.Select(&returns, query, saleID); err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching sale returns")
+	}
+
+	if err := r.loadSaleReturnItems(returns); err != nil {
+		return nil, err
+	}
+
+	return returns, nil
+}
+
+// CreateSaleReturn records a return against a locked sale. Each line is credited
+// at its share of the sale total, so line and order discounts are given back in
+// proportion, and the sale's balance is recomputed with the return applied.
+func (r *Repository) CreateSaleReturn(ret *models.SaleReturn) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	var sale models.Sale
+
+	// Lock the sale so concurrent returns cannot take back the same units twice
+	err = tx.Get(&sale, `SELECT * FROM sales WHERE id = $1 AND inventory_id = $2 FOR UPDATE`, ret.SaleID, ret.InventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFoundError("Sale not found")
+		}
+		return errors.DatabaseError(err, "Error getting sale by ID")
+	}
+
+	if sale.PaymentStatus == models.PaymentStatusCancelled {
+		return errors.ValidationError("Cannot record a return on a cancelled sale")
+	}
+
+	if err := r.checkSaleWarehouse(tx, *ret.WarehouseID, ret.InventoryID); err != nil {
+		return err
+	}
+
+	if err := r.priceSaleReturn(tx, &sale, ret); err != nil {
+		return err
+	}
+
+	// Numbers follow the order documents are created in, not their backdated dates
+	returnNumber, err := inventories.NextDocumentNumber(tx, ret.InventoryID, models.DocumentTypeSaleReturn, time.Now())
+	if err != nil {
+		return err
+	}
+	ret.ReturnNumber = returnNumber
+	ret.SaleNumber = sale.SaleNumber
+
+	_, err = tx.NamedExec(`
+		INSERT INTO sale_returns (
+			id, return_number, sale_id, inventory_id, warehouse_id, reason,
+			total_amount, refund_amount, refund_method, return_date, returned_by, created_at
+		) VALUES (
+			:id, :return_number, :sale_id, :inventory_id, :warehouse_id, :reason,
+			:total_amount, :refund_amount, :refund_method, :return_date, :returned_by, :created_at
+		)
+	`, ret)
+	if err != nil {
+		return errors.DatabaseError(err, "Error creating sale return")
+	}
+
+	for _, item := range ret.Items {
+		_, err = tx.NamedExec(`
+			INSERT INTO sale_return_items (
+				id, return_id, sale_item_id, product_id, quantity, condition,
+				unit_price, unit_cost, amount, created_at
+			) VALUES (
+				:id, :return_id, :sale_item_id, :product_id, :quantity, :condition,
+				:unit_price, :unit_cost, :amount, :created_at
+			)
+		`, item)
+		if err != nil {
+			return errors.DatabaseError(err, "Error creating sale return item")
+		}
+	}
+
+	restocked, err := r.restockSaleReturn(tx, ret)
+	if err != nil {
+		return err
+	}
+
+	// The return is in the ledger now, so the balance follows it
+	if err := r.applySalePayments(tx, &sale); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.DatabaseError(err, "Error committing transaction")
+	}
+
+	r.invalidateSaleCaches(sale.ID, sale.InventoryID)
+	r.invalidateStockCaches(restocked, sale.InventoryID)
+
+	return nil
+}
+
+// REPOSITORY HELPERS
+
+// priceSaleReturn checks every returned line against what is left to return on
+// its sale item and fills in the credited amounts and the refund.
+func (r *Repository) priceSaleReturn(tx *sqlx.Tx, sale *models.Sale, ret *models.SaleReturn) error {
+	var lines []models.SaleItem
+	err := tx.Select(&lines,
+		`SELECT id, sale_id, product_id, warehouse_id, quantity, unit_price, unit_cost,
+                discount_amount, discount_percent, subtotal, created_at
+         FROM sale_items
+         WHERE sale_id = $1`,
+		sale.ID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching sale items")
+	}
+
+	var returned []struct {
+		SaleItemID uuid.UUID `db:"sale_item_id"`
+		Quantity   int       `db:"quantity"`
+	}
+	err = tx.Select(&returned,
+		`SELECT sri.sale_item_id, SUM(sri.quantity) AS quantity
+         FROM sale_return_items sri
+         JOIN sale_returns sr ON sr.id = sri.return_id
+         WHERE sr.sale_id = $1
+         GROUP BY sri.sale_item_id`,
+		sale.ID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error summing returned quantities")
+	}
+
+	linesByID := make(map[uuid.UUID]*models.SaleItem, len(lines))
+	itemsSubtotal := 0
+	for i := range lines {
+		linesByID[lines[i].ID] = &lines[i]
+		itemsSubtotal += lines[i].Subtotal
+	}
+
+	returnedQty := make(map[uuid.UUID]int, len(returned))
+	for _, row := range returned {
+		returnedQty[row.SaleItemID] = row.Quantity
+	}
+
+	ret.TotalAmount = 0
+	for i := range ret.Items {
+		item := &ret.Items[i]
+
+		line, ok := linesByID[item.SaleItemID]
+		if !ok {
+			return errors.ValidationError(fmt.Sprintf("Sale item with ID %s not found", item.SaleItemID))
+		}
+
+		before := returnedQty[line.ID]
+		if before+item.Quantity > line.Quantity {
+			return errors.ValidationError(fmt.Sprintf("Cannot return %d of sale item %s. Sold: %d, already returned: %d",
+				item.Quantity, line.ID, line.Quantity, before))
+		}
+		returnedQty[line.ID] = before + item.Quantity
+
+		// The line's share of the sale total carries the order discount. Crediting
+		// the difference of cumulative shares makes a full return credit it exactly.
+		lineTotal := 0
+		if itemsSubtotal > 0 {
+			lineTotal = utils.ShareOf(sale.TotalAmount, line.Subtotal, itemsSubtotal)
+		}
+
+		item.ProductID = line.ProductID
+		item.UnitPrice = line.UnitPrice
+		item.UnitCost = line.UnitCost
+		item.Amount = utils.ShareOf(lineTotal, before+item.Quantity, line.Quantity) -
+			utils.ShareOf(lineTotal, before, line.Quantity)
+		ret.TotalAmount += item.Amount
+	}
+
+	ledger, err := r.getSaleLedger(tx, sale.ID)
+	if err != nil {
+		return err
+	}
+
+	// Whatever the customer has paid beyond the reduced total goes back to them
+	netTotal := sale.TotalAmount - ledger.Returned - ret.TotalAmount
+	netPaid := ledger.Paid - ledger.Refunded
+	ret.RefundAmount = max(netPaid-netTotal, 0)
+
+	if ret.RefundAmount == 0 {
+		ret.RefundMethod = nil
+	} else if ret.RefundMethod == nil {
+		method := models.PaymentMethodCash
+		ret.RefundMethod = &method
+	}
+
+	return nil
+}
+
+// restockSaleReturn puts restockable units into the return's warehouse at the
+// cost they left with. Damaged units, and lines that were sold without taking
+// stock from a warehouse, are credited but not restocked.
+func (r *Repository) restockSaleReturn(tx *sqlx.Tx, ret *models.SaleReturn) ([]models.SaleItem, error) {
+	var stock []models.StockItemRequest
+	var restocked []models.SaleItem
+
+	for _, item := range ret.Items {
+		if item.Condition != models.ReturnConditionRestockable {
+			continue
+		}
+
+		var fromWarehouse bool
+		err := tx.Get(&fromWarehouse, `SELECT warehouse_id IS NOT NULL FROM sale_items WHERE id = $1`, item.SaleItemID)
+		if err != nil {
+			return nil, errors.DatabaseError(err, "Error getting sale item")
+		}
+		if !fromWarehouse {
+			continue
+		}
+
+		// AddStock moves the stocked quantity; returned units are owned again too
+		_, err = tx.Exec(`UPDATE products SET total_quantity = total_quantity + $1 WHERE id = $2`,
+			item.Quantity, item.ProductID)
+		if err != nil {
+			return nil, errors.DatabaseError(err, "Error updating product total quantity")
+		}
+
+		err = warehouses.AddCostLayer(tx, models.CostLayer{
+			InventoryID: ret.InventoryID,
+			ProductID:   item.ProductID,
+			Source:      models.CostLayerReturn,
+			DocumentID:  &ret.ID,
+			Quantity:    item.Quantity,
+			UnitCost:    item.UnitCost,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		stock = append(stock, models.StockItemRequest{ProductID: item.ProductID, QuantityInStock: item.Quantity})
+		restocked = append(restocked, models.SaleItem{ProductID: item.ProductID})
+	}
+
+	if len(stock) == 0 {
+		return restocked, nil
+	}
+
+	err := warehouses.AddStock(tx, *ret.WarehouseID, stock, models.StockMovement{
+		InventoryID: ret.InventoryID,
+		Reason:      models.MovementReturn,
+		DocumentID:  &ret.ID,
+		UserID:      ret.ReturnedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restocked, nil
+}
+
+// loadSaleReturnItems attaches their lines to the given returns with one query
+func (r *Repository) loadSaleReturnItems(returns []models.SaleReturn) error {
+	if len(returns) == 0 {
+		return nil
+	}
+
+	returnIDs := make([]uuid.UUID, len(returns))
+	for i := range returns {
+		returnIDs[i] = returns[i].ID
+	}
+
+	var items []models.SaleReturnItem
+	err := r.db.Select(&items,
+		`SELECT * FROM sale_return_items WHERE return_id = ANY($1) ORDER BY created_at ASC`,
+		pq.Array(returnIDs))
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching sale return items")
+	}
+
+	itemsByReturn := make(map[uuid.UUID][]models.SaleReturnItem, len(returns))
+	for _, item := range items {
+		itemsByReturn[item.ReturnID] = append(itemsByReturn[item.ReturnID], item)
+	}
+
+	for i := range returns {
+		returns[i].Items = itemsByReturn[returns[i].ID]
+	}
+
+	return nil
+}
+
+// checkSaleHasNoReturns keeps sales with returns from being edited or deleted,
+// since the returns were priced against their lines
+func (r *Repository) checkSaleHasNoReturns(tx *sqlx.Tx, saleID uuid.UUID, action string) error {
+	var exists bool
+	err := tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM sale_returns WHERE sale_id = $1)`, saleID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error checking sale returns")
+	}
+
+	if exists {
+		return errors.ValidationError(fmt.Sprintf("Cannot %s a sale that has returns", action))
+	}
+
+	return nil
+}
@@ -31,7 +31,12 @@ func (c *Controller) ListVendors(ctx echo.Context) error {
 		return errors.ValidationError("Invalid inventory ID")
 	}
 
-	vendors, err := c.repo.ListVendors(inventoryID)
+	query, err := utils.ParseListQuery(ctx, vendorSortColumns, "createdAt")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListVendors(inventoryID, query)
 	if err != nil {
 		return logger.Error(ctx, "Failed to fetch vendors", err, logrus.Fields{
 			"details":      err.Error(),
@@ -39,21 +44,23 @@ func (c *Controller) ListVendors(ctx echo.Context) error {
 		})
 	}
 
-	response := make([]models.VendorResponse, len(vendors))
-	for i, vendor := range vendors {
-		response[i] = *mapper.ToVendorResponse(&vendor)
-	}
+	response := utils.MapPage(page, mapper.ToVendorResponse)
 
 	return ctx.JSON(http.StatusOK, response)
 }
 
 func (c *Controller) GetVendor(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	vendorID, err := uuid.Parse(ctx.Param("vendorId"))
 	if err != nil {
 		return errors.ValidationError("Invalid vendor ID")
 	}
 
-	vendor, err := c.repo.GetVendor(vendorID)
+	vendor, err := c.repo.GetVendor(vendorID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to retrieve vendor", err, logrus.Fields{
 			"details":   err.Error(),
@@ -93,6 +100,11 @@ func (c *Controller) CreateVendor(ctx echo.Context) error {
 }
 
 func (c *Controller) UpdateVendor(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	vendorID, err := uuid.Parse(ctx.Param("vendorId"))
 	if err != nil {
 		return errors.ValidationError("Invalid vendor ID")
@@ -103,7 +115,7 @@ func (c *Controller) UpdateVendor(ctx echo.Context) error {
 		return err
 	}
 
-	existingVendor, err := c.repo.GetVendor(vendorID)
+	existingVendor, err := c.repo.GetVendor(vendorID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Vendor not found", err, logrus.Fields{
 			"details":   err.Error(),
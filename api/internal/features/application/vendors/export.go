@@ -0,0 +1,62 @@
+package vendors
+
+import (
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+var vendorExportColumns = []utils.ExportColumn[models.Vendor]{
+	{Header: "Company", Key: "companyName", Value: func(v *models.Vendor) any { return v.CompanyName }},
+	{Header: "Contact", Key: "contactName", Value: func(v *models.Vendor) any { return v.ContactName }},
+	{Header: "Email", Key: "email", Value: func(v *models.Vendor) any { return v.Email }},
+	{Header: "Phone", Key: "phone", Value: func(v *models.Vendor) any { return v.Phone }},
+	{Header: "Website", Key: "website", Value: func(v *models.Vendor) any { return v.Website }},
+	{Header: "Address", Key: "address", Value: func(v *models.Vendor) any { return v.Address }},
+	{Header: "Created at", Key: "createdAt", Value: func(v *models.Vendor) any { return v.CreatedAt }},
+}
+
+// ExportVendors downloads every vendor matching the list filters as CSV, XLSX or JSON
+func (c *Controller) ExportVendors(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	query, err := utils.ParseListQuery(ctx, vendorSortColumns, "createdAt")
+	if err != nil {
+		return err
+	}
+
+	format, err := utils.ParseExportFormat(ctx)
+	if err != nil {
+		return err
+	}
+
+	exporter := utils.NewExporter(ctx, format, "vendors", vendorExportColumns, "", "")
+	if err := c.repo.ExportVendors(inventoryID, query, exporter.Write); err != nil {
+		return logger.Error(ctx, "Failed to export vendors", err, logrus.Fields{
+			"details":      err.Error(),
+			"inventory_id": inventoryID,
+		})
+	}
+
+	return exporter.Close()
+}
+
+// ExportVendors streams the vendors matching the list filters to write, in list
+// order and without paging
+func (r *Repository) ExportVendors(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Vendor) error) error {
+	conditions := vendorListConditions(inventoryID, query)
+
+	rows, err := r.db.Queryx(r.db.Rebind(`SELECT * FROM vendors`+conditions.Where()+query.OrderBy("id")), conditions.Args()...)
+	if err != nil {
+		return errors.DatabaseError(err, "Error exporting vendors")
+	}
+
+	return utils.EachRow(rows, write)
+}
@@ -1,14 +1,19 @@
 package vendors
 
 import (
+	"time"
+
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type VendorRepository interface {
-	ListVendors(inventoryID uuid.UUID) ([]models.Vendor, error)
-	GetVendor(vendorID uuid.UUID) (models.Vendor, error)
+	ListVendors(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Vendor], error)
+	ExportVendors(inventoryID uuid.UUID, query utils.ListQuery, write func(*models.Vendor) error) error
+	GetVendor(vendorID, inventoryID uuid.UUID) (models.Vendor, error)
+	GetVendorStatement(vendorID, inventoryID uuid.UUID, from, to *time.Time, basis string) (*models.VendorStatementResponse, error)
 	CreateVendor(vendor *models.Vendor) error
 	UpdateVendor(vendor *models.Vendor) error
 	DeleteVendor(vendorID, inventoryID uuid.UUID) error
@@ -16,7 +21,9 @@ type VendorRepository interface {
 
 type VendorController interface {
 	ListVendors(ctx echo.Context) error
+	ExportVendors(ctx echo.Context) error
 	GetVendor(ctx echo.Context) error
+	GetVendorStatement(ctx echo.Context) error
 	CreateVendor(ctx echo.Context) error
 	UpdateVendor(ctx echo.Context) error
 	DeleteVendor(ctx echo.Context) error
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/cache"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
@@ -32,41 +33,70 @@ const (
 	TTL = 30 * 24 * time.Hour
 )
 
-func (r *Repository) ListVendors(inventoryID uuid.UUID) ([]models.Vendor, error) {
-	key := vendorListCacheKey(inventoryID)
+// vendorSortColumns maps the sortable API fields of vendors to their columns
+var vendorSortColumns = map[string]string{
+	"createdAt":   "created_at",
+	"companyName": "company_name",
+}
+
+// vendorListConditions builds the WHERE clause of a vendor list, shared by the
+// paged list and the export
+func vendorListConditions(inventoryID uuid.UUID, query utils.ListQuery) *utils.Conditions {
+	conditions := utils.NewConditions("inventory_id = ?", inventoryID)
+	if query.From != nil {
+		conditions.Add("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("created_at <= ?", *query.To)
+	}
+
+	return conditions
+}
+
+func (r *Repository) ListVendors(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Vendor], error) {
+	var page utils.Page[models.Vendor]
+	key := utils.PageCacheKey(r.cache, vendorListCacheKey(inventoryID), query, TTL)
 
-	var cachedVendors []models.Vendor
-	if err := r.cache.Get(key, &cachedVendors); err == nil {
-		return cachedVendors, nil
+	if err := r.cache.Get(key, &page); err == nil {
+		return page, nil
 	}
 
-	query := `SELECT * FROM vendors WHERE inventory_id = $1 ORDER BY created_at DESC`
+	conditions := vendorListConditions(inventoryID, query)
+
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM vendors` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting vendors")
+	}
+
+	listQuery := r.db.Rebind(`SELECT * FROM vendors` + conditions.Where() + query.OrderAndLimit("id"))
 	vendors := []models.Vendor{}
 
-	err := r.db.Select(&vendors, query, inventoryID)
-	if err != nil {
-		return nil, errors.DatabaseError(err, "Error fetching vendors")
+	if err := r.db.Select(&vendors, listQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching vendors")
 	}
 
-	if err := r.cache.Set(key, vendors, TTL); err != nil {
-		return vendors, errors.CacheError(err, "Error caching vendors")
+	page = utils.NewPage(vendors, total, query)
+
+	if err := r.cache.Set(key, page, TTL); err != nil {
+		return page, errors.CacheError(err, "Error caching vendors")
 	}
 
-	return vendors, nil
+	return page, nil
 }
 
-func (r *Repository) GetVendor(vendorID uuid.UUID) (models.Vendor, error) {
+func (r *Repository) GetVendor(vendorID, inventoryID uuid.UUID) (models.Vendor, error) {
 	key := vendorCacheKey(vendorID)
 
 	var cachedVendor models.Vendor
-	if err := r.cache.Get(key, &cachedVendor); err == nil {
+	if err := r.cache.Get(key, &cachedVendor); err == nil && cachedVendor.InventoryID == inventoryID {
 		return cachedVendor, nil
 	}
 
 	var vendor models.Vendor
-	query := `SELECT * FROM vendors WHERE id = $1`
+	query := `SELECT * FROM vendors WHERE id = $1 AND inventory_id = $2`
 
-	err := r.db.Get(&vendor, query, vendorID)
+	err := r.db.Get(&vendor, query, vendorID, inventoryID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return vendor, errors.NotFoundError("Vendor not found")
@@ -113,7 +143,7 @@ func (r *Repository) UpdateVendor(vendor *models.Vendor) error {
 			website = :website,
 			address = :address,
 			updated_at = :updated_at
-		WHERE id = :id
+		WHERE id = :id AND inventory_id = :inventory_id
 	`
 	_, err := r.db.NamedExec(query, vendor)
 	if err != nil {
@@ -126,8 +156,12 @@ func (r *Repository) UpdateVendor(vendor *models.Vendor) error {
 }
 
 func (r *Repository) DeleteVendor(vendorID, inventoryID uuid.UUID) error {
-	query := `DELETE FROM vendors WHERE id = $1`
-	_, err := r.db.Exec(query, vendorID)
+	if _, err := r.GetVendor(vendorID, inventoryID); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM vendors WHERE id = $1 AND inventory_id = $2`
+	_, err := r.db.Exec(query, vendorID, inventoryID)
 	if err != nil {
 		return errors.DatabaseError(err, "Error deleting vendor")
 	}
@@ -0,0 +1,115 @@
+package vendors
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/app/venside/internal/features/account/statistics"
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/app/venside/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// GetVendorStatement lists the purchases, payments and returns on a vendor's
+// account over an optional from/to period, with what is still open
+func (c *Controller) GetVendorStatement(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	vendorID, err := uuid.Parse(ctx.Param("vendorId"))
+	if err != nil {
+		return errors.ValidationError("Invalid vendor ID")
+	}
+
+	from, to, err := utils.ParseDateRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	basis, err := statistics.ParseAgingBasis(ctx)
+	if err != nil {
+		return err
+	}
+
+	statement, err := c.repo.GetVendorStatement(vendorID, inventoryID, from, to, basis)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch vendor statement", err, logrus.Fields{
+			"vendor_id":    vendorID,
+			"inventory_id": inventoryID,
+			"details":      err.Error(),
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, statement)
+}
+
+func (r *Repository) GetVendorStatement(vendorID, inventoryID uuid.UUID, from, to *time.Time, basis string) (*models.VendorStatementResponse, error) {
+	vendor, err := r.GetVendor(vendorID, inventoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Purchases owe their total once ordered, payments and debit notes settle
+	// it and a refund due on a debit note puts back what was overpaid
+	var entries []models.StatementEntry
+	err = r.db.Select(&entries,
+		`WITH vendor_purchases AS (
+             SELECT id, purchase_number, purchase_date, total_amount, created_at
+             FROM purchases
+             WHERE vendor_id = $1 AND inventory_id = $2
+               AND purchase_status NOT IN ('draft', 'cancelled')
+               AND payment_status <> 'cancelled'
+         )
+         SELECT purchase_date AS entry_date, 'purchase' AS entry_type, id AS document_id,
+                purchase_number AS document_number, NULL AS reference,
+                total_amount AS amount, created_at
+         FROM vendor_purchases
+         UNION ALL
+         SELECT pp.payment_date, 'payment', vp.id, vp.purchase_number, pp.reference,
+                -pp.amount, pp.created_at
+         FROM purchase_payments pp
+         JOIN vendor_purchases vp ON vp.id = pp.purchase_id
+         UNION ALL
+         SELECT pr.return_date, 'return', pr.id, pr.return_number, pr.reason,
+                -pr.credit_amount, pr.created_at
+         FROM purchase_returns pr
+         JOIN vendor_purchases vp ON vp.id = pr.purchase_id
+         UNION ALL
+         SELECT pr.return_date, 'refund', pr.id, pr.return_number, NULL,
+                pr.refund_due, pr.created_at
+         FROM purchase_returns pr
+         JOIN vendor_purchases vp ON vp.id = pr.purchase_id
+         WHERE pr.refund_due > 0
+         ORDER BY entry_date, created_at, entry_type DESC`,
+		vendorID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching vendor statement entries")
+	}
+
+	var open []models.OpenDocument
+	err = r.db.Select(&open,
+		`SELECT id, purchase_number AS number, purchase_date AS document_date,
+                due_date, total_amount, balance
+         FROM purchases
+         WHERE vendor_id = $1 AND inventory_id = $2
+           AND balance > 0
+           AND purchase_status NOT IN ('draft', 'cancelled')
+           AND payment_status <> 'cancelled'
+         ORDER BY purchase_date, created_at`,
+		vendorID, inventoryID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching open vendor purchases")
+	}
+
+	return &models.VendorStatementResponse{
+		VendorID:         vendor.ID,
+		VendorName:       vendor.CompanyName,
+		AccountStatement: statistics.BuildStatement(entries, open, from, to, basis, time.Now()),
+	}, nil
+}
@@ -0,0 +1,48 @@
+package vendors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/google/uuid"
+)
+
+func TestGetVendorStatementSkipsDrafts(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+	vendorID := testdb.SeedVendor(t, db, seed.InventoryID, "Acme Supplies")
+	repo := NewRepository(db, testdb.Cache{})
+
+	ordered, draft := uuid.New(), uuid.New()
+	purchaseDate := time.Now().AddDate(0, 0, -45)
+	testdb.Exec(t, db,
+		`INSERT INTO purchases (id, purchase_number, vendor_id, purchase_date, total_amount, balance,
+                                payment_status, purchase_status, inventory_id)
+         VALUES ($1, 'PO-1', $3, $4, 2000, 1500, 'partial', 'ordered', $5),
+                ($2, 'PO-2', $3, $4, 1000, 1000, 'pending', 'draft', $5)`,
+		ordered, draft, vendorID, purchaseDate, seed.InventoryID)
+	testdb.Exec(t, db,
+		`INSERT INTO purchase_payments (id, purchase_id, amount, method, payment_date)
+         VALUES ($1, $2, 500, 'transfer', $3)`,
+		uuid.New(), ordered, purchaseDate)
+
+	statement, err := repo.GetVendorStatement(vendorID, seed.InventoryID, nil, nil, models.AgingBasisDocumentDate)
+	if err != nil {
+		t.Fatalf("GetVendorStatement: %v", err)
+	}
+
+	if len(statement.Entries) != 2 {
+		t.Fatalf("entries = %+v, want the ordered purchase and its payment", statement.Entries)
+	}
+	if statement.ClosingBalance != 1500 {
+		t.Errorf("closing balance = %d, want 1500", statement.ClosingBalance)
+	}
+	if len(statement.OpenDocuments) != 1 || statement.OpenDocuments[0].ID != ordered {
+		t.Errorf("open documents = %+v, want only the ordered purchase", statement.OpenDocuments)
+	}
+	if statement.Aging.Days31To60 != 1500 || statement.Aging.Total != 1500 {
+		t.Errorf("aging = %+v, want 1500 in 31-60 days", statement.Aging)
+	}
+}
@@ -31,7 +31,12 @@ func (c *Controller) ListWarehouses(ctx echo.Context) error {
 		return errors.ValidationError("Invalid inventory ID")
 	}
 
-	warehouses, err := c.repo.ListWarehouses(inventoryID)
+	query, err := utils.ParseListQuery(ctx, warehouseSortColumns, "createdAt", "storageType", "isMain")
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListWarehouses(inventoryID, query)
 	if err != nil {
 		return logger.Error(ctx, "Failed to fetch warehouses", err, logrus.Fields{
 			"inventory_id": inventoryID,
@@ -39,21 +44,23 @@ func (c *Controller) ListWarehouses(ctx echo.Context) error {
 		})
 	}
 
-	response := make([]models.WarehouseResponse, len(warehouses))
-	for i, warehouse := range warehouses {
-		response[i] = *mapper.ToWarehouseResponse(&warehouse)
-	}
+	response := utils.MapPage(page, mapper.ToWarehouseResponse)
 
 	return ctx.JSON(http.StatusOK, response)
 }
 
 func (c *Controller) GetWarehouse(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	warehouseID, err := uuid.Parse(ctx.Param("warehouseId"))
 	if err != nil {
 		return errors.ValidationError("Invalid warehouse ID")
 	}
 
-	warehouse, err := c.repo.GetWarehouseWithStock(warehouseID)
+	warehouse, err := c.repo.GetWarehouseWithStock(warehouseID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Failed to retrieve warehouse", err, logrus.Fields{
 			"warehouse_id": warehouseID,
@@ -93,6 +100,11 @@ func (c *Controller) CreateWarehouse(ctx echo.Context) error {
 }
 
 func (c *Controller) UpdateWarehouse(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
 	warehouseID, err := uuid.Parse(ctx.Param("warehouseId"))
 	if err != nil {
 		return errors.ValidationError("Invalid warehouse ID")
@@ -103,7 +115,7 @@ func (c *Controller) UpdateWarehouse(ctx echo.Context) error {
 		return err
 	}
 
-	existingWarehouse, err := c.repo.GetWarehouse(warehouseID)
+	existingWarehouse, err := c.repo.GetWarehouse(warehouseID, inventoryID)
 	if err != nil {
 		return logger.Error(ctx, "Warehouse not found", err, logrus.Fields{
 			"warehouse_id": warehouseID,
@@ -167,11 +179,16 @@ func (c *Controller) AddProductsToWarehouse(ctx echo.Context) error {
 		return errors.ValidationError("Invalid request payload")
 	}
 
-	if err := c.validator.ValidateStockItems(req.StockItems, warehouseID); err != nil {
+	if err := c.validator.ValidateStockItems(req.StockItems, warehouseID, inventoryID); err != nil {
 		return err
 	}
 
-	if err := c.repo.AddProductsToWarehouse(warehouseID, inventoryID, req.StockItems); err != nil {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	if err := c.repo.AddProductsToWarehouse(warehouseID, inventoryID, req.StockItems, user.ID); err != nil {
 		return logger.Error(ctx, "Failed to add products to warehouse", err, logrus.Fields{
 			"details":      err.Error(),
 			"warehouse_id": warehouseID,
@@ -197,7 +214,12 @@ func (c *Controller) RemoveProductFromWarehouse(ctx echo.Context) error {
 		return errors.ValidationError("Invalid product ID")
 	}
 
-	if err := c.repo.RemoveProductFromWarehouse(inventoryID, warehouseID, productID); err != nil {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	if err := c.repo.RemoveProductFromWarehouse(inventoryID, warehouseID, productID, user.ID); err != nil {
 		return logger.Error(ctx, "Failed to remove product from warehouse", err, logrus.Fields{
 			"details":      err.Error(),
 			"warehouse_id": warehouseID,
@@ -224,11 +246,16 @@ func (c *Controller) TransferWarehouseStock(ctx echo.Context) error {
 		return errors.ValidationError("Invalid request payload")
 	}
 
-	if err := c.validator.ValidateTransferItems(req.FromWarehouseID, req.ToWarehouseID, req.TransferItems); err != nil {
+	if err := c.validator.ValidateTransferItems(inventoryID, req.FromWarehouseID, req.ToWarehouseID, req.TransferItems); err != nil {
 		return err
 	}
 
-	if err := c.repo.TransferWarehouseStock(inventoryID, req.FromWarehouseID, req.ToWarehouseID, req.TransferItems); err != nil {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	if err := c.repo.TransferWarehouseStock(inventoryID, req.FromWarehouseID, req.ToWarehouseID, req.TransferItems, user.ID); err != nil {
 		return logger.Error(ctx, "Failed to transfer products between warehouses", err, logrus.Fields{
 			"details":           err.Error(),
 			"from_warehouse_id": req.FromWarehouseID,
@@ -263,7 +290,12 @@ func (c *Controller) UpdateStockQuantity(ctx echo.Context) error {
 		return errors.ValidationError("Invalid request payload")
 	}
 
-	if err := c.repo.UpdateStockQuantity(inventoryID, warehouseID, productID, req.NewQuantity); err != nil {
+	user, ok := ctx.Get("user").(*models.User)
+	if !ok {
+		return errors.New(errors.Unauthorized, "User not authenticated", http.StatusUnauthorized)
+	}
+
+	if err := c.repo.UpdateStockQuantity(inventoryID, warehouseID, productID, req.NewQuantity, user.ID); err != nil {
 		return logger.Error(ctx, "Failed to update product stock quantity", err, logrus.Fields{
 			"details":      err.Error(),
 			"warehouse_id": warehouseID,
@@ -274,3 +306,32 @@ func (c *Controller) UpdateStockQuantity(ctx echo.Context) error {
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+func (c *Controller) ListWarehouseMovements(ctx echo.Context) error {
+	inventoryID, err := uuid.Parse(ctx.Param("inventoryId"))
+	if err != nil {
+		return errors.ValidationError("Invalid inventory ID")
+	}
+
+	warehouseID, err := uuid.Parse(ctx.Param("warehouseId"))
+	if err != nil {
+		return errors.ValidationError("Invalid warehouse ID")
+	}
+
+	query, err := ParseStockMovementQuery(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.repo.ListWarehouseMovements(warehouseID, inventoryID, query)
+	if err != nil {
+		return logger.Error(ctx, "Failed to fetch warehouse stock movements", err, logrus.Fields{
+			"details":      err.Error(),
+			"warehouse_id": warehouseID,
+		})
+	}
+
+	response := utils.MapPage(page, mapper.ToStockMovementResponse)
+
+	return ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,252 @@
+package warehouses
+
+import (
+	"time"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// AddCostLayer opens a new cost layer inside the caller's transaction, at the
+// product's current cost price as its standard cost. Layers without any
+// quantity are skipped.
+func AddCostLayer(tx *sqlx.Tx, layer models.CostLayer) error {
+	if layer.Quantity <= 0 {
+		return nil
+	}
+
+	layer.ID = uuid.New()
+	layer.RemainingQuantity = layer.Quantity
+	layer.CreatedAt = time.Now()
+	if layer.ReceivedAt.IsZero() {
+		layer.ReceivedAt = layer.CreatedAt
+	}
+
+	_, err := tx.NamedExec(`
+		INSERT INTO cost_layers (
+			id, inventory_id, product_id, source, document_id,
+			quantity, remaining_quantity, unit_cost, standard_cost, received_at, created_at
+		)
+		SELECT
+			:id, :inventory_id, :product_id, :source, :document_id,
+			:quantity, :remaining_quantity, :unit_cost, cost_price, :received_at, :created_at
+		FROM products
+		WHERE id = :product_id
+	`, layer)
+	if err != nil {
+		return errors.DatabaseError(err, "Error creating cost layer")
+	}
+
+	return nil
+}
+
+// ConsumeCostLayers takes quantity units of a product out of its cost layers,
+// oldest first, and returns what they cost under the inventory's valuation
+// method: the layers' own costs for FIFO, the moving average cost for weighted
+// average and the layers' standard costs for standard cost. Units not covered
+// by any layer leave at the average or the cost price.
+func ConsumeCostLayers(tx *sqlx.Tx, inventoryID, productID uuid.UUID, quantity int, documentID *uuid.UUID) (int, error) {
+	return consumeCostLayers(tx, inventoryID, productID, quantity, documentID, nil)
+}
+
+// ConsumeReceivedCostLayers takes units going back to a vendor out of the
+// layers their purchase opened first, at the cost they were received at, and
+// only then out of the oldest layers like ConsumeCostLayers.
+func ConsumeReceivedCostLayers(tx *sqlx.Tx, inventoryID, productID uuid.UUID, quantity int, documentID *uuid.UUID, purchaseID uuid.UUID) (int, error) {
+	return consumeCostLayers(tx, inventoryID, productID, quantity, documentID, &purchaseID)
+}
+
+func consumeCostLayers(tx *sqlx.Tx, inventoryID, productID uuid.UUID, quantity int, documentID, sourceID *uuid.UUID) (int, error) {
+	if quantity <= 0 {
+		return 0, nil
+	}
+
+	var costing struct {
+		Method    string `db:"valuation_method"`
+		CostPrice int    `db:"cost_price"`
+	}
+	err := tx.Get(&costing,
+		`SELECT i.valuation_method, p.cost_price
+         FROM products p
+         JOIN inventories i ON i.id = p.inventory_id
+         WHERE p.id = $1 AND p.inventory_id = $2`,
+		productID, inventoryID)
+	if err != nil {
+		return 0, errors.DatabaseError(err, "Error fetching product costing")
+	}
+
+	var layers []models.CostLayer
+	err = tx.Select(&layers,
+		`SELECT * FROM cost_layers
+         WHERE product_id = $1 AND remaining_quantity > 0
+         ORDER BY COALESCE(document_id = $2, false) DESC, received_at, created_at
+         FOR UPDATE`,
+		productID, sourceID)
+	if err != nil {
+		return 0, errors.DatabaseError(err, "Error fetching cost layers")
+	}
+
+	// What is on hand decides the moving average cost
+	var onHand struct {
+		Quantity int `db:"quantity"`
+		Value    int `db:"value"`
+	}
+	err = tx.Get(&onHand,
+		`SELECT
+            (SELECT COALESCE(SUM(quantity), 0) FROM cost_layers WHERE product_id = $1)
+              - (SELECT COALESCE(SUM(quantity), 0) FROM cost_consumptions WHERE product_id = $1) AS quantity,
+            (SELECT COALESCE(SUM(quantity::BIGINT * unit_cost), 0) FROM cost_layers WHERE product_id = $1)
+              - (SELECT COALESCE(SUM(total_cost), 0) FROM cost_consumptions WHERE product_id = $1) AS value`,
+		productID)
+	if err != nil {
+		return 0, errors.DatabaseError(err, "Error fetching on-hand value")
+	}
+
+	// averageCost prices the first n units leaving at the moving average so
+	// that the split across layers never drifts from the total
+	averageCost := func(n int) int {
+		if onHand.Quantity <= 0 {
+			return n * costing.CostPrice
+		}
+		return utils.ShareOf(onHand.Value, n, onHand.Quantity)
+	}
+
+	consumed := 0
+	totalCost := 0
+	take := func(layer *models.CostLayer, units int) error {
+		standard := units * costing.CostPrice
+		if layer != nil {
+			standard = units * layer.StandardCost
+		}
+
+		cost := standard
+		switch {
+		case layer != nil && sourceID != nil && layer.DocumentID != nil && *layer.DocumentID == *sourceID:
+			cost = units * layer.UnitCost
+		case costing.Method == models.ValuationFIFO:
+			if layer != nil {
+				cost = units * layer.UnitCost
+			}
+		case costing.Method == models.ValuationWeightedAverage:
+			cost = averageCost(consumed+units) - averageCost(consumed)
+		}
+
+		consumption := models.CostConsumption{
+			ID:           uuid.New(),
+			InventoryID:  inventoryID,
+			ProductID:    productID,
+			DocumentID:   documentID,
+			Quantity:     units,
+			TotalCost:    cost,
+			StandardCost: standard,
+			ConsumedAt:   time.Now(),
+		}
+		if layer != nil {
+			consumption.LayerID = &layer.ID
+
+			_, err := tx.Exec(
+				`UPDATE cost_layers SET remaining_quantity = remaining_quantity - $1 WHERE id = $2`,
+				units, layer.ID)
+			if err != nil {
+				return errors.DatabaseError(err, "Error consuming cost layer")
+			}
+		}
+
+		_, err := tx.NamedExec(`
+			INSERT INTO cost_consumptions (
+				id, inventory_id, product_id, layer_id, document_id, quantity, total_cost, standard_cost, consumed_at
+			) VALUES (
+				:id, :inventory_id, :product_id, :layer_id, :document_id, :quantity, :total_cost, :standard_cost, :consumed_at
+			)
+		`, consumption)
+		if err != nil {
+			return errors.DatabaseError(err, "Error recording cost consumption")
+		}
+
+		consumed += units
+		totalCost += cost
+		return nil
+	}
+
+	for i := range layers {
+		if consumed == quantity {
+			break
+		}
+		units := min(quantity-consumed, layers[i].RemainingQuantity)
+		if err := take(&layers[i], units); err != nil {
+			return 0, err
+		}
+	}
+
+	if consumed < quantity {
+		if err := take(nil, quantity-consumed); err != nil {
+			return 0, err
+		}
+	}
+
+	return totalCost, nil
+}
+
+// ReleaseCostLayers undoes everything a document consumed, putting the units
+// back into the layers they came from. The consumptions stay and are offset by
+// reversals dated now, so valuations of earlier dates do not change.
+func ReleaseCostLayers(tx *sqlx.Tx, documentID uuid.UUID) error {
+	_, err := tx.Exec(
+		`UPDATE cost_layers cl
+         SET remaining_quantity = cl.remaining_quantity + c.quantity
+         FROM (
+            SELECT layer_id, SUM(quantity) AS quantity
+            FROM cost_consumptions
+            WHERE document_id = $1 AND layer_id IS NOT NULL
+            GROUP BY layer_id
+         ) c
+         WHERE cl.id = c.layer_id AND c.quantity <> 0`,
+		documentID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error releasing cost layers")
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO cost_consumptions (
+            id, inventory_id, product_id, layer_id, document_id, quantity, total_cost, standard_cost, consumed_at
+         )
+         SELECT gen_random_uuid(), inventory_id, product_id, layer_id, document_id,
+                -SUM(quantity), -SUM(total_cost), -SUM(standard_cost), $2
+         FROM cost_consumptions
+         WHERE document_id = $1
+         GROUP BY inventory_id, product_id, layer_id, document_id
+         HAVING SUM(quantity) <> 0 OR SUM(total_cost) <> 0 OR SUM(standard_cost) <> 0`,
+		documentID, time.Now())
+	if err != nil {
+		return errors.DatabaseError(err, "Error reversing cost consumptions")
+	}
+
+	return nil
+}
+
+// AdjustCostLayers follows a manual change of a product's owned quantity:
+// added units open an adjustment layer at the product's cost price and
+// removed units are consumed like any other outgoing stock.
+func AdjustCostLayers(tx *sqlx.Tx, inventoryID, productID uuid.UUID, delta int) error {
+	if delta < 0 {
+		_, err := ConsumeCostLayers(tx, inventoryID, productID, -delta, nil)
+		return err
+	}
+
+	var costPrice int
+	err := tx.Get(&costPrice, `SELECT cost_price FROM products WHERE id = $1`, productID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error fetching product cost price")
+	}
+
+	return AddCostLayer(tx, models.CostLayer{
+		InventoryID: inventoryID,
+		ProductID:   productID,
+		Source:      models.CostLayerAdjustment,
+		Quantity:    delta,
+		UnitCost:    costPrice,
+	})
+}
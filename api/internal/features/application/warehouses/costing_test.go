@@ -0,0 +1,50 @@
+package warehouses
+
+import (
+	"testing"
+	"time"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/google/uuid"
+)
+
+func TestConsumeReceivedCostLayers(t *testing.T) {
+	for _, method := range []string{models.ValuationFIFO, models.ValuationWeightedAverage, models.ValuationStandard} {
+		t.Run(method, func(t *testing.T) {
+			db := testdb.Open(t)
+			seed := testdb.SeedInventory(t, db, method)
+			productID := testdb.SeedProduct(t, db, seed.InventoryID, "Widget", 150, 400)
+
+			// An older layer at 100 and the returned purchase's own layer at 300
+			olderLayer, purchaseLayer, purchaseID := uuid.New(), uuid.New(), uuid.New()
+			testdb.Exec(t, db,
+				`INSERT INTO cost_layers (id, inventory_id, product_id, source, document_id, quantity, remaining_quantity, unit_cost, received_at)
+             VALUES ($1, $3, $4, 'opening', NULL, 5, 5, 100, $6),
+                    ($2, $3, $4, 'receipt', $5, 5, 5, 300, $7)`,
+				olderLayer, purchaseLayer, seed.InventoryID, productID, purchaseID,
+				time.Now().AddDate(0, -1, 0), time.Now())
+
+			tx := db.MustBegin()
+			defer tx.Rollback()
+
+			returnID := uuid.New()
+			cost, err := ConsumeReceivedCostLayers(tx, seed.InventoryID, productID, 3, &returnID, purchaseID)
+			if err != nil {
+				t.Fatalf("ConsumeReceivedCostLayers: %v", err)
+			}
+			if cost != 900 {
+				t.Errorf("cost = %d, want 900 at the purchase's own unit cost", cost)
+			}
+
+			var remaining []int
+			if err := tx.Select(&remaining, `SELECT remaining_quantity FROM cost_layers WHERE id IN ($1, $2) ORDER BY received_at`,
+				olderLayer, purchaseLayer); err != nil {
+				t.Fatalf("loading layers: %v", err)
+			}
+			if len(remaining) != 2 || remaining[0] != 5 || remaining[1] != 2 {
+				t.Errorf("remaining = %v, want the older layer untouched and 2 left in the purchase's", remaining)
+			}
+		})
+	}
+}
@@ -2,22 +2,24 @@ package warehouses
 
 import (
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type WarehouseRepository interface {
-	ListWarehouses(inventoryID uuid.UUID) ([]models.Warehouse, error)
-	GetWarehouse(warehouseID uuid.UUID) (models.Warehouse, error)
-	GetWarehouseWithStock(warehouseID uuid.UUID) (models.Warehouse, error)
+	ListWarehouses(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Warehouse], error)
+	GetWarehouse(warehouseID, inventoryID uuid.UUID) (models.Warehouse, error)
+	GetWarehouseWithStock(warehouseID, inventoryID uuid.UUID) (models.Warehouse, error)
 	CreateWarehouse(warehouse *models.Warehouse) error
 	UpdateWarehouse(warehouse *models.Warehouse) error
 	DeleteWarehouse(warehouseID, inventoryID uuid.UUID) error
 
-	AddProductsToWarehouse(warehouseID, inventoryID uuid.UUID, items []models.StockItemRequest) error
-	RemoveProductFromWarehouse(inventoryID, warehouseID, productID uuid.UUID) error
-	TransferWarehouseStock(inventoryID uuid.UUID, fromWarehouseID, toWarehouseID uuid.UUID, items []models.TransferItemRequest) error
-	UpdateStockQuantity(inventoryID, warehouseID, productID uuid.UUID, newQuantity int) error
+	AddProductsToWarehouse(warehouseID, inventoryID uuid.UUID, items []models.StockItemRequest, userID uuid.UUID) error
+	RemoveProductFromWarehouse(inventoryID, warehouseID, productID, userID uuid.UUID) error
+	TransferWarehouseStock(inventoryID uuid.UUID, fromWarehouseID, toWarehouseID uuid.UUID, items []models.TransferItemRequest, userID uuid.UUID) error
+	UpdateStockQuantity(inventoryID, warehouseID, productID uuid.UUID, newQuantity int, userID uuid.UUID) error
+	ListWarehouseMovements(warehouseID, inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.StockMovement], error)
 }
 
 type WarehouseController interface {
@@ -31,4 +33,5 @@ type WarehouseController interface {
 	RemoveProductFromWarehouse(ctx echo.Context) error
 	TransferWarehouseStock(ctx echo.Context) error
 	UpdateStockQuantity(ctx echo.Context) error
+	ListWarehouseMovements(ctx echo.Context) error
 }
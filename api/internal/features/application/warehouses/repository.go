@@ -3,10 +3,12 @@ package warehouses
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/app/venside/internal/mapper"
 	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
 	"github.com/app/venside/pkg/cache"
 	"github.com/app/venside/pkg/errors"
 	"github.com/google/uuid"
@@ -36,41 +38,73 @@ const (
 
 // Warehouse Operations
 
-func (r *Repository) ListWarehouses(inventoryID uuid.UUID) ([]models.Warehouse, error) {
-	key := warehouseListCacheKey(inventoryID)
+// warehouseSortColumns maps the sortable API fields of warehouses to their columns
+var warehouseSortColumns = map[string]string{
+	"createdAt": "created_at",
+	"name":      "name",
+	"capacity":  "capacity",
+}
+
+func (r *Repository) ListWarehouses(inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.Warehouse], error) {
+	var page utils.Page[models.Warehouse]
+	key := utils.PageCacheKey(r.cache, warehouseListCacheKey(inventoryID), query, TTL)
 
-	var cachedWarehouses []models.Warehouse
-	if err := r.cache.Get(key, &cachedWarehouses); err == nil {
-		return cachedWarehouses, nil
+	if err := r.cache.Get(key, &page); err == nil {
+		return page, nil
 	}
 
-	query := `SELECT * FROM warehouses WHERE inventory_id = $1 ORDER BY created_at DESC`
+	conditions := utils.NewConditions("inventory_id = ?", inventoryID)
+	if storageType, ok := query.Filters["storageType"]; ok {
+		conditions.Add("storage_type = ?", storageType)
+	}
+	if isMain, ok := query.Filters["isMain"]; ok {
+		value, err := strconv.ParseBool(isMain)
+		if err != nil {
+			return page, errors.ValidationError("Invalid isMain")
+		}
+		conditions.Add("is_main = ?", value)
+	}
+	if query.From != nil {
+		conditions.Add("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("created_at <= ?", *query.To)
+	}
+
+	var total int
+	countQuery := r.db.Rebind(`SELECT COUNT(*) FROM warehouses` + conditions.Where())
+	if err := r.db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting warehouses")
+	}
+
+	listQuery := r.db.Rebind(`SELECT * FROM warehouses` + conditions.Where() + query.OrderAndLimit("id"))
 	warehouses := []models.Warehouse{}
 
-	err := r.db.Select(&warehouses, query, inventoryID)
-	if err != nil {
-		return nil, errors.DatabaseError(err, "Error fetching warehouses")
+	if err := r.db.Select(&warehouses, listQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching warehouses")
 	}
 
-	if err := r.cache.Set(key, warehouses, TTL); err != nil {
-		return warehouses, errors.CacheError(err, "Error caching warehouses")
+	page = utils.NewPage(warehouses, total, query)
+
+	if err := r.cache.Set(key, page, TTL); err != nil {
+		return page, errors.CacheError(err, "Error caching warehouses")
 	}
 
-	return warehouses, nil
+	return page, nil
 }
 
-func (r *Repository) GetWarehouse(warehouseID uuid.UUID) (models.Warehouse, error) {
+func (r *Repository) GetWarehouse(warehouseID, inventoryID uuid.UUID) (models.Warehouse, error) {
 	key := warehouseCacheKey(warehouseID)
 
 	var cachedWarehouse models.Warehouse
-	if err := r.cache.Get(key, &cachedWarehouse); err == nil {
+	if err := r.cache.Get(key, &cachedWarehouse); err == nil && cachedWarehouse.InventoryID == inventoryID {
 		return cachedWarehouse, nil
 	}
 
 	var warehouse models.Warehouse
-	query := `SELECT * FROM warehouses WHERE id = $1`
+	query := `SELECT * FROM warehouses WHERE id = $1 AND inventory_id = $2`
 
-	err := r.db.Get(&warehouse, query, warehouseID)
+	err := r.db.Get(&warehouse, query, warehouseID, inventoryID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return warehouse, errors.NotFoundError("Warehouse not found")
@@ -85,8 +119,8 @@ func (r *Repository) GetWarehouse(warehouseID uuid.UUID) (models.Warehouse, erro
 	return warehouse, nil
 }
 
-func (r *Repository) GetWarehouseWithStock(warehouseID uuid.UUID) (models.Warehouse, error) {
-	warehouse, err := r.GetWarehouse(warehouseID)
+func (r *Repository) GetWarehouseWithStock(warehouseID, inventoryID uuid.UUID) (models.Warehouse, error) {
+	warehouse, err := r.GetWarehouse(warehouseID, inventoryID)
 	if err != nil {
 		return warehouse, err
 	}
@@ -136,6 +170,11 @@ func (r *Repository) CreateWarehouse(warehouse *models.Warehouse) error {
 }
 
 func (r *Repository) UpdateWarehouse(warehouse *models.Warehouse) error {
+	productIDs, err := r.stockedProductIDs(warehouse.ID)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE warehouses SET 
 			name = :name,
@@ -147,93 +186,103 @@ func (r *Repository) UpdateWarehouse(warehouse *models.Warehouse) error {
 			phone = :phone,
 			email = :email,
 			updated_at = :updated_at
-		WHERE id = :id
+		WHERE id = :id AND inventory_id = :inventory_id
 	`
-	_, err := r.db.NamedExec(query, warehouse)
+	_, err = r.db.NamedExec(query, warehouse)
 	if err != nil {
 		return errors.DatabaseError(err, "Error updating warehouse")
 	}
 
-	r.invalidateWarehouseCaches(warehouse.ID, warehouse.InventoryID)
+	r.invalidateWarehouseCaches(warehouse.ID, warehouse.InventoryID, productIDs...)
 
 	return nil
 }
 
 func (r *Repository) DeleteWarehouse(warehouseID, inventoryID uuid.UUID) error {
-	query := `DELETE FROM warehouses WHERE id = $1`
-	_, err := r.db.Exec(query, warehouseID)
+	if err := r.checkWarehouse(warehouseID, inventoryID); err != nil {
+		return err
+	}
+
+	productIDs, err := r.stockedProductIDs(warehouseID)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM warehouses WHERE id = $1 AND inventory_id = $2`
+	_, err = r.db.Exec(query, warehouseID, inventoryID)
 	if err != nil {
 		return errors.DatabaseError(err, "Error deleting warehouse")
 	}
 
-	r.invalidateWarehouseCaches(warehouseID, inventoryID)
+	r.invalidateWarehouseCaches(warehouseID, inventoryID, productIDs...)
 
 	return nil
 }
 
 // Stock Item Operations
 
-func (r *Repository) AddProductsToWarehouse(warehouseID, inventoryID uuid.UUID, items []models.StockItemRequest) error {
+func (r *Repository) AddProductsToWarehouse(warehouseID, inventoryID uuid.UUID, items []models.StockItemRequest, userID uuid.UUID) error {
+	if err := r.checkWarehouse(warehouseID, inventoryID); err != nil {
+		return err
+	}
+
 	tx, err := r.db.Beginx()
 	if err != nil {
 		return errors.DatabaseError(err, "Error starting transaction")
 	}
 	defer tx.Rollback()
 
-	for _, item := range items {
-		// Insert or update warehouse_product_link with the new quantity
-		query := `
-            INSERT INTO warehouse_product_link (product_id, warehouse_id, quantity_in_stock)
-            VALUES (:product_id, :warehouse_id, :quantity_in_stock)
-            ON CONFLICT (product_id, warehouse_id) 
-            DO UPDATE SET 
-                quantity_in_stock = warehouse_product_link.quantity_in_stock + :quantity_in_stock
-        `
-
-		params := map[string]interface{}{
-			"product_id":        item.ProductID,
-			"warehouse_id":      warehouseID,
-			"quantity_in_stock": item.QuantityInStock,
-		}
-
-		_, err = tx.NamedExec(query, params)
-		if err != nil {
-			return errors.DatabaseError(err, "Error adding product to warehouse")
-		}
-
-		// Update the product's total_stock
-		updateProductQuery := `
-            UPDATE products 
-            SET total_stock = total_stock + :quantity_in_stock
-            WHERE id = :product_id
-        `
-
-		updateParams := map[string]interface{}{
-			"quantity_in_stock": item.QuantityInStock,
-			"product_id":        item.ProductID,
-		}
-
-		_, err = tx.NamedExec(updateProductQuery, updateParams)
-		if err != nil {
-			return errors.DatabaseError(err, "Error updating product total stock")
-		}
+	movement := models.StockMovement{
+		InventoryID: inventoryID,
+		Reason:      models.MovementAdjustment,
+		UserID:      &userID,
+	}
+	if err = AddStock(tx, warehouseID, items, movement); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
 		return errors.DatabaseError(err, "Error committing transaction")
 	}
 
-	r.invalidateWarehouseCaches(warehouseID, inventoryID)
+	productIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	r.invalidateWarehouseCaches(warehouseID, inventoryID, productIDs...)
 	return nil
 }
 
-func (r *Repository) RemoveProductFromWarehouse(inventoryID, warehouseID, productID uuid.UUID) error {
-	// Get current quantity (if exists)
+func (r *Repository) RemoveProductFromWarehouse(inventoryID, warehouseID, productID, userID uuid.UUID) error {
+	if err := r.checkWarehouse(warehouseID, inventoryID); err != nil {
+		return err
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	// Lock the product before its stock so concurrent changes queue up
+	// behind this one and the ledger delta matches what is removed
+	var locked bool
+	err = tx.Get(&locked,
+		`SELECT true FROM products WHERE id = $1 AND inventory_id = $2 FOR UPDATE`,
+		productID, inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return errors.DatabaseError(err, "Error locking product")
+	}
+
 	var currentQuantity int
-	err := r.db.Get(&currentQuantity,
-		`SELECT COALESCE(quantity_in_stock, 0) 
-         FROM warehouse_product_link 
-         WHERE warehouse_id = $1 AND product_id = $2`,
+	err = tx.Get(&currentQuantity,
+		`SELECT quantity_in_stock
+         FROM warehouse_product_link
+         WHERE warehouse_id = $1 AND product_id = $2
+         FOR UPDATE`,
 		warehouseID, productID)
 	if err != nil && err != sql.ErrNoRows {
 		return errors.DatabaseError(err, "Error checking product stock")
@@ -244,12 +293,6 @@ func (r *Repository) RemoveProductFromWarehouse(inventoryID, warehouseID, produc
 		return nil
 	}
 
-	tx, err := r.db.Beginx()
-	if err != nil {
-		return errors.DatabaseError(err, "Error starting transaction")
-	}
-	defer tx.Rollback()
-
 	// Remove from warehouse
 	_, err = tx.Exec(
 		`DELETE FROM warehouse_product_link 
@@ -269,28 +312,55 @@ func (r *Repository) RemoveProductFromWarehouse(inventoryID, warehouseID, produc
 		return errors.DatabaseError(err, "Error updating product total stock")
 	}
 
+	err = RecordMovement(tx, models.StockMovement{
+		InventoryID: inventoryID,
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Quantity:    -currentQuantity,
+		Reason:      models.MovementAdjustment,
+		UserID:      &userID,
+	})
+	if err != nil {
+		return err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return errors.DatabaseError(err, "Error committing transaction")
 	}
 
-	r.invalidateWarehouseCaches(warehouseID, inventoryID)
+	r.invalidateWarehouseCaches(warehouseID, inventoryID, productID)
 	return nil
 }
 
-func (r *Repository) TransferWarehouseStock(inventoryID uuid.UUID, fromWarehouseID, toWarehouseID uuid.UUID, items []models.TransferItemRequest) error {
+func (r *Repository) TransferWarehouseStock(inventoryID uuid.UUID, fromWarehouseID, toWarehouseID uuid.UUID, items []models.TransferItemRequest, userID uuid.UUID) error {
+	for _, warehouseID := range []uuid.UUID{fromWarehouseID, toWarehouseID} {
+		if err := r.checkWarehouse(warehouseID, inventoryID); err != nil {
+			return err
+		}
+	}
+
 	tx, err := r.db.Beginx()
 	if err != nil {
 		return errors.DatabaseError(err, "Error starting transaction")
 	}
 	defer tx.Rollback()
 
+	// Both legs of every item share one transfer ID in the ledger
+	transferID := uuid.New()
+
 	for _, item := range items {
-		// First, check if the source warehouse has enough stock
+		if _, err := LockProduct(tx, item.ProductID, inventoryID); err != nil {
+			return err
+		}
+
+		// First, check if the source warehouse has enough stock. The product is
+		// locked above, so concurrent transfers read the stock one at a time.
 		var currentStock int
 		err = tx.Get(&currentStock,
-			`SELECT COALESCE(quantity_in_stock, 0) 
-             FROM warehouse_product_link 
-             WHERE warehouse_id = $1 AND product_id = $2`,
+			`SELECT quantity_in_stock
+             FROM warehouse_product_link
+             WHERE warehouse_id = $1 AND product_id = $2
+             FOR UPDATE`,
 			fromWarehouseID, item.ProductID)
 		if err != nil && err != sql.ErrNoRows {
 			return errors.DatabaseError(err, "Error checking source warehouse stock")
@@ -339,6 +409,27 @@ func (r *Repository) TransferWarehouseStock(inventoryID uuid.UUID, fromWarehouse
 
 		// Note: For transfers, we don't update the product's total_stock
 		// because the total stock across all warehouses remains the same
+
+		for _, leg := range []struct {
+			warehouseID uuid.UUID
+			quantity    int
+		}{
+			{fromWarehouseID, -item.TransferQuantity},
+			{toWarehouseID, item.TransferQuantity},
+		} {
+			err = RecordMovement(tx, models.StockMovement{
+				InventoryID: inventoryID,
+				ProductID:   item.ProductID,
+				WarehouseID: leg.warehouseID,
+				Quantity:    leg.quantity,
+				Reason:      models.MovementTransfer,
+				DocumentID:  &transferID,
+				UserID:      &userID,
+			})
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -346,14 +437,29 @@ func (r *Repository) TransferWarehouseStock(inventoryID uuid.UUID, fromWarehouse
 	}
 
 	// Invalidate caches for both warehouses
-	r.invalidateWarehouseCaches(fromWarehouseID, inventoryID)
-	r.invalidateWarehouseCaches(toWarehouseID, inventoryID)
+	productIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	r.invalidateWarehouseCaches(fromWarehouseID, inventoryID, productIDs...)
+	r.invalidateWarehouseCaches(toWarehouseID, inventoryID, productIDs...)
 
 	return nil
 }
 
-func (r *Repository) UpdateStockQuantity(inventoryID, warehouseID, productID uuid.UUID, newQuantity int) error {
-	// Get current product and stock information
+func (r *Repository) UpdateStockQuantity(inventoryID, warehouseID, productID uuid.UUID, newQuantity int, userID uuid.UUID) error {
+	if err := r.checkWarehouse(warehouseID, inventoryID); err != nil {
+		return err
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return errors.DatabaseError(err, "Error starting transaction")
+	}
+	defer tx.Rollback()
+
+	// Lock the product and then its stock in this warehouse, so the
+	// difference recorded below is taken from the quantity being replaced
 	var current struct {
 		WarehouseStock int       `db:"warehouse_stock"`
 		TotalQuantity  int       `db:"total_quantity"`
@@ -361,18 +467,12 @@ func (r *Repository) UpdateStockQuantity(inventoryID, warehouseID, productID uui
 		InventoryID    uuid.UUID `db:"inventory_id"`
 	}
 
-	err := r.db.Get(&current,
-		`SELECT 
-            COALESCE(wpl.quantity_in_stock, 0) as warehouse_stock,
-            p.total_quantity,
-            p.total_stock,
-            p.inventory_id
-         FROM products p
-         LEFT JOIN warehouse_product_link wpl ON 
-            wpl.product_id = p.id AND 
-            wpl.warehouse_id = $1
-         WHERE p.id = $2`,
-		warehouseID, productID)
+	err = tx.Get(&current,
+		`SELECT total_quantity, total_stock, inventory_id
+         FROM products
+         WHERE id = $1
+         FOR UPDATE`,
+		productID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -386,11 +486,15 @@ func (r *Repository) UpdateStockQuantity(inventoryID, warehouseID, productID uui
 		return errors.ValidationError("Product does not belong to specified inventory")
 	}
 
-	tx, err := r.db.Beginx()
-	if err != nil {
-		return errors.DatabaseError(err, "Error starting transaction")
+	err = tx.Get(&current.WarehouseStock,
+		`SELECT quantity_in_stock
+         FROM warehouse_product_link
+         WHERE warehouse_id = $1 AND product_id = $2
+         FOR UPDATE`,
+		warehouseID, productID)
+	if err != nil && err != sql.ErrNoRows {
+		return errors.DatabaseError(err, "Error retrieving product stock data")
 	}
-	defer tx.Rollback()
 
 	// Calculate the global stock difference
 	stockDifference := newQuantity - current.WarehouseStock
@@ -405,6 +509,10 @@ func (r *Repository) UpdateStockQuantity(inventoryID, warehouseID, productID uui
 		if err != nil {
 			return errors.DatabaseError(err, "Error updating product total quantity")
 		}
+
+		if err := AdjustCostLayers(tx, inventoryID, productID, newQuantity-current.TotalQuantity); err != nil {
+			return err
+		}
 	}
 
 	// Update or delete warehouse stock record
@@ -443,15 +551,75 @@ func (r *Repository) UpdateStockQuantity(inventoryID, warehouseID, productID uui
 		}
 	}
 
+	err = RecordMovement(tx, models.StockMovement{
+		InventoryID: inventoryID,
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Quantity:    stockDifference,
+		Reason:      models.MovementAdjustment,
+		UserID:      &userID,
+	})
+	if err != nil {
+		return err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return errors.DatabaseError(err, "Error committing transaction")
 	}
 
-	r.invalidateWarehouseCaches(warehouseID, inventoryID)
+	r.invalidateWarehouseCaches(warehouseID, inventoryID, productID)
+	return nil
+}
+
+func (r *Repository) ListWarehouseMovements(warehouseID, inventoryID uuid.UUID, query utils.ListQuery) (utils.Page[models.StockMovement], error) {
+	if err := r.checkWarehouse(warehouseID, inventoryID); err != nil {
+		return utils.Page[models.StockMovement]{}, err
+	}
+
+	conditions := utils.NewConditions("sm.inventory_id = ?", inventoryID)
+	conditions.Add("sm.warehouse_id = ?", warehouseID)
+
+	return ListStockMovements(r.db, conditions, query)
+}
+
+// checkWarehouse makes sure the warehouse exists within the given inventory
+func (r *Repository) checkWarehouse(warehouseID, inventoryID uuid.UUID) error {
+	var exists bool
+	err := r.db.Get(&exists,
+		`SELECT EXISTS(SELECT 1 FROM warehouses WHERE id = $1 AND inventory_id = $2)`,
+		warehouseID, inventoryID)
+	if err != nil {
+		return errors.DatabaseError(err, "Error getting warehouse by ID")
+	}
+
+	if !exists {
+		return errors.NotFoundError("Warehouse not found")
+	}
+
 	return nil
 }
 
-func (r *Repository) invalidateWarehouseCaches(warehouseID, inventoryID uuid.UUID) {
+// stockedProductIDs lists the products stocked in a warehouse, whose cached
+// storage goes stale when the warehouse changes
+func (r *Repository) stockedProductIDs(warehouseID uuid.UUID) ([]uuid.UUID, error) {
+	var productIDs []uuid.UUID
+	err := r.db.Select(&productIDs, `SELECT product_id FROM warehouse_product_link WHERE warehouse_id = $1`, warehouseID)
+	if err != nil {
+		return nil, errors.DatabaseError(err, "Error fetching stocked products")
+	}
+	return productIDs, nil
+}
+
+// invalidateWarehouseCaches drops the cached warehouse and, since products
+// carry their storage per warehouse, the product list and the given products
+func (r *Repository) invalidateWarehouseCaches(warehouseID, inventoryID uuid.UUID, productIDs ...uuid.UUID) {
 	r.cache.Delete(warehouseCacheKey(warehouseID))
 	r.cache.Delete(warehouseListCacheKey(inventoryID))
+
+	for _, productID := range productIDs {
+		r.cache.Delete("product:" + productID.String())
+	}
+	if len(productIDs) > 0 {
+		r.cache.Delete("products:" + inventoryID.String())
+	}
 }
@@ -0,0 +1,74 @@
+package warehouses
+
+import (
+	"testing"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/testdb"
+	"github.com/app/venside/internal/shared/utils"
+)
+
+// deletedKeys records the keys a repository invalidates
+type deletedKeys struct {
+	testdb.Cache
+	keys []string
+}
+
+func (c *deletedKeys) Delete(key string) error {
+	c.keys = append(c.keys, key)
+	return nil
+}
+
+func TestStockChangesInvalidateProducts(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+	productID := testdb.SeedProduct(t, db, seed.InventoryID, "Widget", 100, 200)
+	cache := &deletedKeys{}
+	repo := &Repository{db: db, cache: cache}
+
+	items := []models.StockItemRequest{{ProductID: productID, QuantityInStock: 5}}
+	if err := repo.AddProductsToWarehouse(seed.WarehouseID, seed.InventoryID, items, seed.UserID); err != nil {
+		t.Fatalf("AddProductsToWarehouse: %v", err)
+	}
+	if err := repo.UpdateStockQuantity(seed.InventoryID, seed.WarehouseID, productID, 3, seed.UserID); err != nil {
+		t.Fatalf("UpdateStockQuantity: %v", err)
+	}
+
+	for _, key := range []string{"product:" + productID.String(), "products:" + seed.InventoryID.String()} {
+		deleted := 0
+		for _, k := range cache.keys {
+			if k == key {
+				deleted++
+			}
+		}
+		if deleted != 2 {
+			t.Errorf("%s invalidated %d times, want once per change", key, deleted)
+		}
+	}
+}
+
+func TestStockMovementsOutliveProducts(t *testing.T) {
+	db := testdb.Open(t)
+	seed := testdb.SeedInventory(t, db, "")
+	productID := testdb.SeedProduct(t, db, seed.InventoryID, "Widget", 100, 200)
+	repo := &Repository{db: db, cache: testdb.Cache{}}
+
+	items := []models.StockItemRequest{{ProductID: productID, QuantityInStock: 5}}
+	if err := repo.AddProductsToWarehouse(seed.WarehouseID, seed.InventoryID, items, seed.UserID); err != nil {
+		t.Fatalf("AddProductsToWarehouse: %v", err)
+	}
+	testdb.Exec(t, db, `DELETE FROM products WHERE id = $1`, productID)
+
+	query := utils.ListQuery{Limit: 50, Sort: "sm.created_at", Order: "DESC"}
+	page, err := repo.ListWarehouseMovements(seed.WarehouseID, seed.InventoryID, query)
+	if err != nil {
+		t.Fatalf("ListWarehouseMovements: %v", err)
+	}
+
+	if len(page.Data) != 1 {
+		t.Fatalf("movements = %+v, want the receipt kept", page.Data)
+	}
+	if movement := page.Data[0]; movement.ProductName != "Widget" || movement.WarehouseName != "Main" || movement.Quantity != 5 {
+		t.Errorf("movement = %+v, want 5 Widget into Main", movement)
+	}
+}
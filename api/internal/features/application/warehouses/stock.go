@@ -0,0 +1,175 @@
+package warehouses
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/app/venside/internal/models"
+	"github.com/app/venside/internal/shared/utils"
+	"github.com/app/venside/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+// stockMovementSortColumns maps the sortable API fields of stock movements to their columns
+var stockMovementSortColumns = map[string]string{
+	"createdAt": "sm.created_at",
+	"quantity":  "sm.quantity",
+}
+
+// LockProduct locks a product of the inventory for the rest of the caller's
+// transaction and returns its name. A product of another inventory is reported
+// as not found, so stock and costs never land on another tenant's catalogue.
+func LockProduct(tx *sqlx.Tx, productID, inventoryID uuid.UUID) (string, error) {
+	var name string
+	err := tx.Get(&name,
+		`SELECT name FROM products WHERE id = $1 AND inventory_id = $2 FOR UPDATE`,
+		productID, inventoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.ValidationError(fmt.Sprintf("Product with ID %s not found", productID))
+		}
+		return "", errors.DatabaseError(err, "Error locking product")
+	}
+
+	return name, nil
+}
+
+// AddStock upserts the given quantities into a warehouse and bumps each product's
+// total_stock. It runs inside the caller's transaction so other features
+// (e.g. purchase receipts) can put stock away atomically with their own writes.
+// Every product must belong to the movement's inventory.
+// The movement carries the reason, document and user recorded for every item.
+func AddStock(tx *sqlx.Tx, warehouseID uuid.UUID, items []models.StockItemRequest, movement models.StockMovement) error {
+	for _, item := range items {
+		if _, err := LockProduct(tx, item.ProductID, movement.InventoryID); err != nil {
+			return err
+		}
+
+		// Insert or update warehouse_product_link with the new quantity
+		query := `
+            INSERT INTO warehouse_product_link (product_id, warehouse_id, quantity_in_stock)
+            VALUES (:product_id, :warehouse_id, :quantity_in_stock)
+            ON CONFLICT (product_id, warehouse_id)
+            DO UPDATE SET
+                quantity_in_stock = warehouse_product_link.quantity_in_stock + :quantity_in_stock
+        `
+
+		params := map[string]interface{}{
+			"product_id":        item.ProductID,
+			"warehouse_id":      warehouseID,
+			"quantity_in_stock": item.QuantityInStock,
+		}
+
+		_, err := tx.NamedExec(query, params)
+		if err != nil {
+			return errors.DatabaseError(err, "Error adding product to warehouse")
+		}
+
+		// Update the product's total_stock
+		updateProductQuery := `
+            UPDATE products
+            SET total_stock = total_stock + :quantity_in_stock
+            WHERE id = :product_id AND inventory_id = :inventory_id
+        `
+
+		updateParams := map[string]interface{}{
+			"quantity_in_stock": item.QuantityInStock,
+			"product_id":        item.ProductID,
+			"inventory_id":      movement.InventoryID,
+		}
+
+		_, err = tx.NamedExec(updateProductQuery, updateParams)
+		if err != nil {
+			return errors.DatabaseError(err, "Error updating product total stock")
+		}
+
+		movement.ProductID = item.ProductID
+		movement.WarehouseID = warehouseID
+		movement.Quantity = item.QuantityInStock
+		if err := RecordMovement(tx, movement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordMovement appends an entry to the stock ledger. It must run in the same
+// transaction as the quantity change it describes. Deleting the product or
+// the warehouse later clears the reference but keeps the entry.
+func RecordMovement(tx *sqlx.Tx, movement models.StockMovement) error {
+	if movement.Quantity == 0 {
+		return nil
+	}
+
+	movement.ID = uuid.New()
+	movement.CreatedAt = time.Now()
+
+	// The names are copied so the entry still reads right once either is deleted
+	query := `
+        INSERT INTO stock_movements (
+            id, inventory_id, product_id, warehouse_id, product_name, warehouse_name,
+            quantity, reason, document_id, user_id, created_at
+        )
+        SELECT
+            :id, :inventory_id, :product_id, :warehouse_id, p.name, w.name,
+            :quantity, :reason, :document_id, :user_id, :created_at
+        FROM products p, warehouses w
+        WHERE p.id = :product_id AND w.id = :warehouse_id
+    `
+	result, err := tx.NamedExec(query, movement)
+	if err != nil {
+		return errors.DatabaseError(err, "Error recording stock movement")
+	}
+	recorded, err := result.RowsAffected()
+	if err != nil {
+		return errors.DatabaseError(err, "Error recording stock movement")
+	}
+	if recorded == 0 {
+		return errors.NotFoundError("Product or warehouse of the stock movement not found")
+	}
+
+	return nil
+}
+
+// ListStockMovements returns one page of the stock ledger matching the given
+// conditions, newest first unless asked otherwise. Conditions refer to the
+// stock_movements table as sm.
+func ListStockMovements(db *sqlx.DB, conditions *utils.Conditions, query utils.ListQuery) (utils.Page[models.StockMovement], error) {
+	var page utils.Page[models.StockMovement]
+
+	if reason, ok := query.Filters["reason"]; ok {
+		conditions.Add("sm.reason = ?", reason)
+	}
+	if query.From != nil {
+		conditions.Add("sm.created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		conditions.Add("sm.created_at <= ?", *query.To)
+	}
+
+	var total int
+	countQuery := db.Rebind(`SELECT COUNT(*) FROM stock_movements sm` + conditions.Where())
+	if err := db.Get(&total, countQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error counting stock movements")
+	}
+
+	listQuery := db.Rebind(`
+        SELECT sm.* FROM stock_movements sm` + conditions.Where() + query.OrderAndLimit("sm.id"))
+
+	movements := []models.StockMovement{}
+	if err := db.Select(&movements, listQuery, conditions.Args()...); err != nil {
+		return page, errors.DatabaseError(err, "Error fetching stock movements")
+	}
+
+	return utils.NewPage(movements, total, query), nil
+}
+
+// ParseStockMovementQuery reads the list query of the stock movement endpoints.
+// Every endpoint filters by reason; extra filters are endpoint specific.
+func ParseStockMovementQuery(ctx echo.Context, filters ...string) (utils.ListQuery, error) {
+	return utils.ParseListQuery(ctx, stockMovementSortColumns, "createdAt", append([]string{"reason"}, filters...)...)
+}
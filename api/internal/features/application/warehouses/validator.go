@@ -47,7 +47,7 @@ func (v *WarehouseValidator) ValidateWarehouse(warehouse *models.Warehouse) erro
 	return nil
 }
 
-func (v *WarehouseValidator) ValidateStockItems(items []models.StockItemRequest, warehouseID uuid.UUID) error {
+func (v *WarehouseValidator) ValidateStockItems(items []models.StockItemRequest, warehouseID, inventoryID uuid.UUID) error {
 	if len(items) == 0 {
 		return errors.ValidationError("At least one stock item is required")
 	}
@@ -70,7 +70,7 @@ func (v *WarehouseValidator) ValidateStockItems(items []models.StockItemRequest,
 		err := v.db.Get(&product,
 			`SELECT name, total_quantity, total_stock 
 			 FROM products 
-			 WHERE id = $1`, item.ProductID)
+			 WHERE id = $1 AND inventory_id = $2`, item.ProductID, inventoryID)
 
 		if err != nil {
 			if err == sql.ErrNoRows {
@@ -102,7 +102,7 @@ func (v *WarehouseValidator) ValidateStockItems(items []models.StockItemRequest,
 	return nil
 }
 
-func (v *WarehouseValidator) ValidateTransferItems(fromWarehouseID, toWarehouseID uuid.UUID, items []models.TransferItemRequest) error {
+func (v *WarehouseValidator) ValidateTransferItems(inventoryID, fromWarehouseID, toWarehouseID uuid.UUID, items []models.TransferItemRequest) error {
 	if len(items) == 0 {
 		return errors.ValidationError("At least one transfer item is required")
 	}
@@ -135,8 +135,8 @@ func (v *WarehouseValidator) ValidateTransferItems(fromWarehouseID, toWarehouseI
 			LEFT JOIN warehouse_product_link wpl ON 
 				p.id = wpl.product_id AND 
 				wpl.warehouse_id = $1
-			WHERE p.id = $2`,
-			fromWarehouseID, item.ProductID)
+			WHERE p.id = $2 AND p.inventory_id = $3`,
+			fromWarehouseID, item.ProductID, inventoryID)
 
 		if err != nil {
 			if err == sql.ErrNoRows {
@@ -32,6 +32,7 @@ func ToCreateCustomer(req *models.CustomerRequest, inventoryID uuid.UUID) *model
 		Phone:        phone,
 		Address:      address,
 		CustomerType: req.CustomerType,
+		CreditLimit:  req.CreditLimit,
 		InventoryID:  inventoryID,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
@@ -61,6 +62,7 @@ func ToUpdateCustomer(req *models.CustomerRequest, existing *models.Customer) *m
 		Phone:        phone,
 		Address:      address,
 		CustomerType: req.CustomerType,
+		CreditLimit:  req.CreditLimit,
 		InventoryID:  existing.InventoryID,
 		CreatedAt:    existing.CreatedAt,
 		UpdatedAt:    time.Now(),
@@ -75,6 +77,7 @@ func ToCustomerResponse(customer *models.Customer) *models.CustomerResponse {
 		Phone:        customer.Phone,
 		Address:      customer.Address,
 		CustomerType: customer.CustomerType,
+		CreditLimit:  customer.CreditLimit,
 		CreatedAt:    customer.CreatedAt,
 		UpdatedAt:    customer.UpdatedAt,
 	}
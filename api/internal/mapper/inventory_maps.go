@@ -12,11 +12,16 @@ var trim = strings.TrimSpace
 
 func ToCreateInventory(req *models.InventoryRequest, userID uuid.UUID) (*models.Inventory, *models.Currency) {
 	inventory := &models.Inventory{
-		ID:        uuid.New(),
-		Name:      trim(req.Name),
-		UserID:    userID,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:              uuid.New(),
+		Name:            trim(req.Name),
+		UserID:          userID,
+		ValuationMethod: req.ValuationMethod,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	if inventory.ValuationMethod == "" {
+		inventory.ValuationMethod = models.ValuationWeightedAverage
 	}
 
 	currency := &models.Currency{
@@ -35,6 +40,9 @@ func ToCreateInventory(req *models.InventoryRequest, userID uuid.UUID) (*models.
 func ToEditInventory(req *models.InventoryRequest, existingInventory *models.Inventory, existingCurrency *models.Currency) (*models.Inventory, *models.Currency) {
 	// Update inventory fields
 	existingInventory.Name = req.Name
+	if req.ValuationMethod != "" {
+		existingInventory.ValuationMethod = req.ValuationMethod
+	}
 	existingInventory.UpdatedAt = time.Now()
 
 	// Update currency fields
@@ -48,9 +56,10 @@ func ToEditInventory(req *models.InventoryRequest, existingInventory *models.Inv
 
 func ToInventoryResponse(inventory *models.Inventory, currency *models.Currency) *models.InventoryResponse {
 	return &models.InventoryResponse{
-		ID:     inventory.ID,
-		Name:   inventory.Name,
-		UserID: inventory.UserID,
+		ID:              inventory.ID,
+		Name:            inventory.Name,
+		UserID:          inventory.UserID,
+		ValuationMethod: inventory.ValuationMethod,
 		Currency: models.CurrencyResponse{
 			ID:     currency.ID,
 			Name:   currency.Name,
@@ -61,3 +70,57 @@ func ToInventoryResponse(inventory *models.Inventory, currency *models.Currency)
 		UpdatedAt: inventory.UpdatedAt,
 	}
 }
+
+func ToCreateInventoryMember(inventoryID, userID uuid.UUID, role string, invitedBy *uuid.UUID) *models.InventoryMember {
+	status := models.MemberStatusPending
+	if role == models.RoleOwner {
+		status = models.MemberStatusActive
+	}
+
+	return &models.InventoryMember{
+		ID:          uuid.New(),
+		InventoryID: inventoryID,
+		UserID:      userID,
+		Role:        role,
+		Status:      status,
+		InvitedBy:   invitedBy,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+func ToInventoryMemberResponse(member *models.InventoryMember) *models.InventoryMemberResponse {
+	return &models.InventoryMemberResponse{
+		ID:        member.ID,
+		UserID:    member.UserID,
+		Username:  member.Username,
+		Email:     member.Email,
+		Role:      member.Role,
+		Status:    member.Status,
+		InvitedBy: member.InvitedBy,
+		CreatedAt: member.CreatedAt,
+		UpdatedAt: member.UpdatedAt,
+	}
+}
+
+func ToDocumentSequenceResponse(sequence *models.DocumentSequence) *models.DocumentSequenceResponse {
+	return &models.DocumentSequenceResponse{
+		DocumentType: sequence.DocumentType,
+		Prefix:       sequence.Prefix,
+		Template:     sequence.Template,
+		LastValue:    sequence.LastValue,
+		UpdatedAt:    sequence.UpdatedAt,
+	}
+}
+
+func ToDocumentTemplateResponse(template *models.DocumentTemplate) *models.DocumentTemplateResponse {
+	return &models.DocumentTemplateResponse{
+		Layout:       template.Layout,
+		Title:        template.Title,
+		Header:       template.Header,
+		Footer:       template.Footer,
+		PaperSize:    template.PaperSize,
+		ShowPayments: template.ShowPayments,
+		UpdatedAt:    template.UpdatedAt,
+	}
+}
@@ -0,0 +1,17 @@
+package mapper
+
+import (
+	"github.com/app/venside/internal/models"
+)
+
+func ToNotificationResponse(notification *models.Notification) *models.NotificationResponse {
+	return &models.NotificationResponse{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Message:   notification.Message,
+		ProductID: notification.ProductID,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+}
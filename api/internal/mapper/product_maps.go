@@ -38,6 +38,7 @@ func ToUpdateProduct(req *models.ProductRequest, existing *models.Product) *mode
 		Model:         trim(req.Model),
 		Description:   trim(req.Description),
 		TotalQuantity: req.TotalQuantity,
+		TotalStock:    existing.TotalStock,
 		RestockLevel:  req.RestockLevel,
 		OptimalLevel:  req.OptimalLevel,
 		CostPrice:     req.CostPrice,
@@ -166,3 +167,24 @@ func ToProductStock(productsWithStock []models.ProductWithStock) []models.StockI
 	}
 	return stockItems
 }
+
+func ToLowStockProductResponse(product *models.LowStockProduct) *models.LowStockProductResponse {
+	warehouses := product.Warehouses
+	if warehouses == nil {
+		warehouses = []models.LowStockWarehouse{}
+	}
+
+	return &models.LowStockProductResponse{
+		ID:                product.ID,
+		Name:              product.Name,
+		Code:              product.Code,
+		SKU:               product.SKU,
+		Stock:             product.Stock,
+		TotalStock:        product.TotalStock,
+		RestockLevel:      product.RestockLevel,
+		OptimalLevel:      product.OptimalLevel,
+		SuggestedQuantity: product.SuggestedQuantity,
+		SuggestedCost:     product.SuggestedQuantity * product.CostPrice,
+		Warehouses:        warehouses,
+	}
+}
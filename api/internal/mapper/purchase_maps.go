@@ -30,8 +30,8 @@ func ToCreatePurchase(req *models.PurchaseRequest, inventoryID uuid.UUID) *model
 		VendorName:      vendorName,
 		PurchaseDate:    purchaseDate,
 		Eta:             req.Eta,
+		DueDate:         req.DueDate,
 		ShippingCost:    req.ShippingCost,
-		TotalAmount:     req.TotalAmount,
 		PaymentStatus:   req.PaymentStatus,
 		PurchaseStatus:  req.PurchaseStatus,
 		DiscountAmount:  req.DiscountAmount,
@@ -47,13 +47,14 @@ func ToCreatePurchase(req *models.PurchaseRequest, inventoryID uuid.UUID) *model
 		productID, _ := uuid.Parse(itemReq.ProductID)
 
 		purchase.Items[i] = models.PurchaseItem{
-			ID:         uuid.New(),
-			PurchaseID: purchase.ID,
-			ProductID:  productID,
-			Quantity:   itemReq.Quantity,
-			UnitPrice:  itemReq.UnitPrice,
-			Subtotal:   itemReq.Subtotal,
-			CreatedAt:  time.Now(),
+			ID:              uuid.New(),
+			PurchaseID:      purchase.ID,
+			ProductID:       productID,
+			Quantity:        itemReq.Quantity,
+			UnitPrice:       itemReq.UnitPrice,
+			DiscountAmount:  itemReq.DiscountAmount,
+			DiscountPercent: itemReq.DiscountPercent,
+			CreatedAt:       time.Now(),
 		}
 	}
 
@@ -77,25 +78,71 @@ func ToEditPurchase(req *models.PurchaseRequest, existing *models.Purchase) *mod
 		vendorName = *req.VendorName
 	}
 
-	return &models.Purchase{
+	paymentStatus := existing.PaymentStatus
+	if req.PaymentStatus != "" {
+		paymentStatus = req.PaymentStatus
+	}
+
+	purchaseStatus := existing.PurchaseStatus
+	if req.PurchaseStatus != "" {
+		purchaseStatus = req.PurchaseStatus
+	}
+
+	purchase := &models.Purchase{
 		ID:              existing.ID,
 		PurchaseNumber:  existing.PurchaseNumber,
 		VendorID:        vendorID,
 		VendorName:      vendorName,
 		PurchaseDate:    purchaseDate,
 		Eta:             req.Eta,
+		DueDate:         req.DueDate,
 		DeliveryDate:    existing.DeliveryDate,
 		ShippingCost:    req.ShippingCost,
-		TotalAmount:     req.TotalAmount,
-		PaymentStatus:   req.PaymentStatus,
-		PurchaseStatus:  req.PurchaseStatus,
+		TotalAmount:     existing.TotalAmount,
+		PaymentStatus:   paymentStatus,
+		PurchaseStatus:  purchaseStatus,
 		DiscountAmount:  req.DiscountAmount,
 		DiscountPercent: req.DiscountPercent,
 		InventoryID:     existing.InventoryID,
 		CreatedAt:       existing.CreatedAt,
 		UpdatedAt:       time.Now(),
-		Items:           existing.Items,
 	}
+
+	// Lines that carry an ID replace the existing line, the rest are new
+	existingItems := make(map[uuid.UUID]models.PurchaseItem, len(existing.Items))
+	for _, item := range existing.Items {
+		existingItems[item.ID] = item
+	}
+
+	purchase.Items = make([]models.PurchaseItem, len(req.Items))
+	for i, itemReq := range req.Items {
+		productID, _ := uuid.Parse(itemReq.ProductID)
+
+		itemID := uuid.New()
+		if itemReq.ID != nil {
+			itemID, _ = uuid.Parse(*itemReq.ID)
+		}
+
+		item := models.PurchaseItem{
+			ID:              itemID,
+			PurchaseID:      purchase.ID,
+			ProductID:       productID,
+			Quantity:        itemReq.Quantity,
+			UnitPrice:       itemReq.UnitPrice,
+			DiscountAmount:  itemReq.DiscountAmount,
+			DiscountPercent: itemReq.DiscountPercent,
+			CreatedAt:       time.Now(),
+		}
+
+		if previous, ok := existingItems[itemID]; ok {
+			item.ReceivedQuantity = previous.ReceivedQuantity
+			item.CreatedAt = previous.CreatedAt
+		}
+
+		purchase.Items[i] = item
+	}
+
+	return purchase
 }
 
 func ToPurchaseResponse(purchase *models.Purchase) *models.PurchaseResponse {
@@ -106,9 +153,11 @@ func ToPurchaseResponse(purchase *models.Purchase) *models.PurchaseResponse {
 		VendorName:      purchase.VendorName,
 		PurchaseDate:    purchase.PurchaseDate,
 		Eta:             purchase.Eta,
+		DueDate:         purchase.DueDate,
 		DeliveryDate:    purchase.DeliveryDate,
 		ShippingCost:    purchase.ShippingCost,
 		TotalAmount:     purchase.TotalAmount,
+		Balance:         purchase.Balance,
 		PaymentStatus:   purchase.PaymentStatus,
 		PurchaseStatus:  purchase.PurchaseStatus,
 		DiscountAmount:  purchase.DiscountAmount,
@@ -122,12 +171,15 @@ func ToPurchaseResponse(purchase *models.Purchase) *models.PurchaseResponse {
 		response.Items = make([]models.PurchaseItemResponse, len(purchase.Items))
 		for i, item := range purchase.Items {
 			response.Items[i] = models.PurchaseItemResponse{
-				ID:        item.ID,
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-				UnitPrice: item.UnitPrice,
-				Subtotal:  item.Subtotal,
-				CreatedAt: item.CreatedAt,
+				ID:               item.ID,
+				ProductID:        item.ProductID,
+				Quantity:         item.Quantity,
+				ReceivedQuantity: item.ReceivedQuantity,
+				UnitPrice:        item.UnitPrice,
+				DiscountAmount:   item.DiscountAmount,
+				DiscountPercent:  item.DiscountPercent,
+				Subtotal:         item.Subtotal,
+				CreatedAt:        item.CreatedAt,
 			}
 
 			// Map product if available
@@ -142,12 +194,145 @@ func ToPurchaseResponse(purchase *models.Purchase) *models.PurchaseResponse {
 
 func ToPurchaseItemResponse(item *models.PurchaseItem) *models.PurchaseItemResponse {
 	return &models.PurchaseItemResponse{
-		ID:        item.ID,
-		ProductID: item.ProductID,
-		Quantity:  item.Quantity,
-		UnitPrice: item.UnitPrice,
-		Subtotal:  item.Subtotal,
-		CreatedAt: item.CreatedAt,
-		Product:   ToProductResponse(item.Product),
+		ID:               item.ID,
+		ProductID:        item.ProductID,
+		Quantity:         item.Quantity,
+		ReceivedQuantity: item.ReceivedQuantity,
+		UnitPrice:        item.UnitPrice,
+		DiscountAmount:   item.DiscountAmount,
+		DiscountPercent:  item.DiscountPercent,
+		Subtotal:         item.Subtotal,
+		CreatedAt:        item.CreatedAt,
+		Product:          ToProductResponse(item.Product),
+	}
+}
+
+func ToPurchaseReceipt(req *models.ReceivePurchaseRequest, receivedBy uuid.UUID) *models.PurchaseReceipt {
+	warehouseID, _ := uuid.Parse(req.WarehouseID)
+
+	deliveryDate := time.Now()
+	if req.DeliveryDate != nil {
+		deliveryDate = *req.DeliveryDate
+	}
+
+	receipt := &models.PurchaseReceipt{
+		WarehouseID:  warehouseID,
+		DeliveryDate: deliveryDate,
+		ReceivedBy:   receivedBy,
+		Items:        make([]models.PurchaseReceiptItem, len(req.Items)),
+	}
+
+	for i, itemReq := range req.Items {
+		purchaseItemID, _ := uuid.Parse(itemReq.PurchaseItemID)
+		receipt.Items[i] = models.PurchaseReceiptItem{
+			PurchaseItemID: purchaseItemID,
+			Quantity:       itemReq.Quantity,
+		}
+	}
+
+	return receipt
+}
+
+// Purchase Payment Mappers
+
+func ToCreatePurchasePayment(req *models.PurchasePaymentRequest, purchaseID uuid.UUID, defaultDate time.Time) *models.PurchasePayment {
+	paymentDate := defaultDate
+	if req.PaymentDate != nil {
+		paymentDate = *req.PaymentDate
+	}
+
+	var reference *string
+	if req.Reference != nil {
+		trimmed := trim(*req.Reference)
+		reference = &trimmed
+	}
+
+	return &models.PurchasePayment{
+		ID:          uuid.New(),
+		PurchaseID:  purchaseID,
+		Amount:      req.Amount,
+		Method:      req.Method,
+		Reference:   reference,
+		PaymentDate: paymentDate,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func ToPurchasePaymentResponse(payment *models.PurchasePayment) *models.PurchasePaymentResponse {
+	return &models.PurchasePaymentResponse{
+		ID:          payment.ID,
+		PurchaseID:  payment.PurchaseID,
+		Amount:      payment.Amount,
+		Method:      payment.Method,
+		Reference:   payment.Reference,
+		PaymentDate: payment.PaymentDate,
+		CreatedAt:   payment.CreatedAt,
+	}
+}
+
+// Purchase Return Mappers
+
+func ToCreatePurchaseReturn(req *models.PurchaseReturnRequest, purchaseID, inventoryID uuid.UUID, defaultDate time.Time) *models.PurchaseReturn {
+	warehouseID, _ := uuid.Parse(req.WarehouseID)
+
+	returnDate := defaultDate
+	if req.ReturnDate != nil {
+		returnDate = *req.ReturnDate
+	}
+
+	now := time.Now()
+	ret := &models.PurchaseReturn{
+		ID:          uuid.New(),
+		PurchaseID:  purchaseID,
+		InventoryID: inventoryID,
+		WarehouseID: &warehouseID,
+		Reason:      trim(req.Reason),
+		ReturnDate:  returnDate,
+		CreatedAt:   now,
+		Items:       make([]models.PurchaseReturnItem, len(req.Items)),
+	}
+
+	for i, item := range req.Items {
+		purchaseItemID, _ := uuid.Parse(item.PurchaseItemID)
+		ret.Items[i] = models.PurchaseReturnItem{
+			ID:             uuid.New(),
+			ReturnID:       ret.ID,
+			PurchaseItemID: purchaseItemID,
+			Quantity:       item.Quantity,
+			CreatedAt:      now,
+		}
+	}
+
+	return ret
+}
+
+func ToPurchaseReturnResponse(ret *models.PurchaseReturn) *models.PurchaseReturnResponse {
+	response := &models.PurchaseReturnResponse{
+		ID:             ret.ID,
+		ReturnNumber:   ret.ReturnNumber,
+		PurchaseID:     ret.PurchaseID,
+		PurchaseNumber: ret.PurchaseNumber,
+		VendorID:       ret.VendorID,
+		WarehouseID:    ret.WarehouseID,
+		Reason:         ret.Reason,
+		CreditAmount:   ret.CreditAmount,
+		RefundDue:      ret.RefundDue,
+		ReturnDate:     ret.ReturnDate,
+		ReturnedBy:     ret.ReturnedBy,
+		CreatedAt:      ret.CreatedAt,
+		Items:          make([]models.PurchaseReturnItemResponse, len(ret.Items)),
+	}
+
+	for i, item := range ret.Items {
+		response.Items[i] = models.PurchaseReturnItemResponse{
+			ID:             item.ID,
+			PurchaseItemID: item.PurchaseItemID,
+			ProductID:      item.ProductID,
+			Quantity:       item.Quantity,
+			UnitCost:       item.UnitCost,
+			Amount:         item.Amount,
+		}
 	}
+
+	return response
 }
@@ -0,0 +1,15 @@
+package mapper
+
+import (
+	"github.com/app/venside/internal/models"
+)
+
+func ToDocumentRevisionResponse(revision *models.DocumentRevision) *models.DocumentRevisionResponse {
+	return &models.DocumentRevisionResponse{
+		ID:        revision.ID,
+		Revision:  revision.Revision,
+		Snapshot:  revision.Snapshot,
+		EditedBy:  revision.EditedBy,
+		CreatedAt: revision.CreatedAt,
+	}
+}
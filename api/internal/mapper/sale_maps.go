@@ -29,9 +29,7 @@ func ToCreateSale(req *models.SaleRequest, inventoryID uuid.UUID) *models.Sale {
 		CustomerID:      customerID,
 		CustomerName:    trim(customerName),
 		SaleDate:        saleDate,
-		TotalAmount:     req.TotalAmount,
-		Balance:         req.Balance,
-		PaymentStatus:   req.PaymentStatus,
+		PaymentStatus:   models.PaymentStatusPending,
 		DiscountAmount:  req.DiscountAmount,
 		DiscountPercent: req.DiscountPercent,
 		InventoryID:     inventoryID,
@@ -44,17 +42,31 @@ func ToCreateSale(req *models.SaleRequest, inventoryID uuid.UUID) *models.Sale {
 	for i, itemReq := range req.Items {
 		productID, _ := uuid.Parse(itemReq.ProductID)
 
+		var warehouseID *uuid.UUID
+		if itemReq.WarehouseID != nil && *itemReq.WarehouseID != "" {
+			parsedID, _ := uuid.Parse(*itemReq.WarehouseID)
+			warehouseID = &parsedID
+		}
+
 		sale.Items[i] = models.SaleItem{
-			ID:        uuid.New(),
-			SaleID:    sale.ID,
-			ProductID: productID,
-			Quantity:  itemReq.Quantity,
-			UnitPrice: itemReq.UnitPrice,
-			Subtotal:  itemReq.Subtotal,
-			CreatedAt: time.Now(),
+			ID:              uuid.New(),
+			SaleID:          sale.ID,
+			ProductID:       productID,
+			WarehouseID:     warehouseID,
+			Quantity:        itemReq.Quantity,
+			UnitPrice:       itemReq.UnitPrice,
+			DiscountAmount:  itemReq.DiscountAmount,
+			DiscountPercent: itemReq.DiscountPercent,
+			CreatedAt:       time.Now(),
 		}
 	}
 
+	// Payments taken at checkout
+	sale.Payments = make([]models.SalePayment, len(req.Payments))
+	for i := range req.Payments {
+		sale.Payments[i] = *ToCreateSalePayment(&req.Payments[i], sale.ID, saleDate)
+	}
+
 	return sale
 }
 
@@ -75,22 +87,62 @@ func ToEditSale(req *models.SaleRequest, existing *models.Sale) *models.Sale {
 		customerName = *req.CustomerName
 	}
 
-	return &models.Sale{
+	sale := &models.Sale{
 		ID:              existing.ID,
 		SaleNumber:      existing.SaleNumber,
 		CustomerID:      customerID,
 		CustomerName:    trim(customerName),
 		SaleDate:        saleDate,
-		TotalAmount:     req.TotalAmount,
-		Balance:         req.Balance,
-		PaymentStatus:   req.PaymentStatus,
+		TotalAmount:     existing.TotalAmount,
+		Balance:         existing.Balance,
+		PaymentStatus:   existing.PaymentStatus,
 		DiscountAmount:  req.DiscountAmount,
 		DiscountPercent: req.DiscountPercent,
 		InventoryID:     existing.InventoryID,
 		CreatedAt:       existing.CreatedAt,
 		UpdatedAt:       time.Now(),
-		Items:           existing.Items,
 	}
+
+	// Lines that carry an ID replace the existing line, the rest are new
+	createdAt := make(map[uuid.UUID]time.Time, len(existing.Items))
+	for _, item := range existing.Items {
+		createdAt[item.ID] = item.CreatedAt
+	}
+
+	sale.Items = make([]models.SaleItem, len(req.Items))
+	for i, itemReq := range req.Items {
+		productID, _ := uuid.Parse(itemReq.ProductID)
+
+		itemID := uuid.New()
+		if itemReq.ID != nil {
+			itemID, _ = uuid.Parse(*itemReq.ID)
+		}
+
+		itemCreatedAt, ok := createdAt[itemID]
+		if !ok {
+			itemCreatedAt = time.Now()
+		}
+
+		var warehouseID *uuid.UUID
+		if itemReq.WarehouseID != nil && *itemReq.WarehouseID != "" {
+			parsedID, _ := uuid.Parse(*itemReq.WarehouseID)
+			warehouseID = &parsedID
+		}
+
+		sale.Items[i] = models.SaleItem{
+			ID:              itemID,
+			SaleID:          sale.ID,
+			ProductID:       productID,
+			WarehouseID:     warehouseID,
+			Quantity:        itemReq.Quantity,
+			UnitPrice:       itemReq.UnitPrice,
+			DiscountAmount:  itemReq.DiscountAmount,
+			DiscountPercent: itemReq.DiscountPercent,
+			CreatedAt:       itemCreatedAt,
+		}
+	}
+
+	return sale
 }
 
 func ToSaleResponse(sale *models.Sale) *models.SaleResponse {
@@ -114,12 +166,16 @@ func ToSaleResponse(sale *models.Sale) *models.SaleResponse {
 		response.Items = make([]models.SaleItemResponse, len(sale.Items))
 		for i, item := range sale.Items {
 			response.Items[i] = models.SaleItemResponse{
-				ID:        item.ID,
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-				UnitPrice: item.UnitPrice,
-				Subtotal:  item.Subtotal,
-				CreatedAt: item.CreatedAt,
+				ID:              item.ID,
+				ProductID:       item.ProductID,
+				WarehouseID:     item.WarehouseID,
+				Quantity:        item.Quantity,
+				UnitPrice:       item.UnitPrice,
+				UnitCost:        item.UnitCost,
+				DiscountAmount:  item.DiscountAmount,
+				DiscountPercent: item.DiscountPercent,
+				Subtotal:        item.Subtotal,
+				CreatedAt:       item.CreatedAt,
 			}
 
 			// Map product if available
@@ -132,6 +188,114 @@ func ToSaleResponse(sale *models.Sale) *models.SaleResponse {
 	return response
 }
 
+// Sale Payment Mappers
+
+func ToCreateSalePayment(req *models.SalePaymentRequest, saleID uuid.UUID, defaultDate time.Time) *models.SalePayment {
+	paymentDate := defaultDate
+	if req.PaymentDate != nil {
+		paymentDate = *req.PaymentDate
+	}
+
+	var reference *string
+	if req.Reference != nil {
+		trimmed := trim(*req.Reference)
+		reference = &trimmed
+	}
+
+	return &models.SalePayment{
+		ID:          uuid.New(),
+		SaleID:      saleID,
+		Amount:      req.Amount,
+		Method:      req.Method,
+		Reference:   reference,
+		PaymentDate: paymentDate,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func ToSalePaymentResponse(payment *models.SalePayment) *models.SalePaymentResponse {
+	return &models.SalePaymentResponse{
+		ID:          payment.ID,
+		SaleID:      payment.SaleID,
+		Amount:      payment.Amount,
+		Method:      payment.Method,
+		Reference:   payment.Reference,
+		PaymentDate: payment.PaymentDate,
+		CreatedAt:   payment.CreatedAt,
+	}
+}
+
+// Sale Return Mappers
+
+func ToCreateSaleReturn(req *models.SaleReturnRequest, saleID, inventoryID uuid.UUID, defaultDate time.Time) *models.SaleReturn {
+	warehouseID, _ := uuid.Parse(req.WarehouseID)
+
+	returnDate := defaultDate
+	if req.ReturnDate != nil {
+		returnDate = *req.ReturnDate
+	}
+
+	now := time.Now()
+	ret := &models.SaleReturn{
+		ID:           uuid.New(),
+		SaleID:       saleID,
+		InventoryID:  inventoryID,
+		WarehouseID:  &warehouseID,
+		Reason:       trim(req.Reason),
+		RefundMethod: req.RefundMethod,
+		ReturnDate:   returnDate,
+		CreatedAt:    now,
+		Items:        make([]models.SaleReturnItem, len(req.Items)),
+	}
+
+	for i, item := range req.Items {
+		saleItemID, _ := uuid.Parse(item.SaleItemID)
+		ret.Items[i] = models.SaleReturnItem{
+			ID:         uuid.New(),
+			ReturnID:   ret.ID,
+			SaleItemID: saleItemID,
+			Quantity:   item.Quantity,
+			Condition:  item.Condition,
+			CreatedAt:  now,
+		}
+	}
+
+	return ret
+}
+
+func ToSaleReturnResponse(ret *models.SaleReturn) *models.SaleReturnResponse {
+	response := &models.SaleReturnResponse{
+		ID:           ret.ID,
+		ReturnNumber: ret.ReturnNumber,
+		SaleID:       ret.SaleID,
+		SaleNumber:   ret.SaleNumber,
+		WarehouseID:  ret.WarehouseID,
+		Reason:       ret.Reason,
+		TotalAmount:  ret.TotalAmount,
+		RefundAmount: ret.RefundAmount,
+		RefundMethod: ret.RefundMethod,
+		ReturnDate:   ret.ReturnDate,
+		ReturnedBy:   ret.ReturnedBy,
+		CreatedAt:    ret.CreatedAt,
+		Items:        make([]models.SaleReturnItemResponse, len(ret.Items)),
+	}
+
+	for i, item := range ret.Items {
+		response.Items[i] = models.SaleReturnItemResponse{
+			ID:         item.ID,
+			SaleItemID: item.SaleItemID,
+			ProductID:  item.ProductID,
+			Quantity:   item.Quantity,
+			Condition:  item.Condition,
+			UnitPrice:  item.UnitPrice,
+			UnitCost:   item.UnitCost,
+			Amount:     item.Amount,
+		}
+	}
+
+	return response
+}
+
 // func ToCreateSaleItem(req *models.AddItemToSaleRequest, saleID uuid.UUID) *models.SaleItem {
 // 	productID, _ := uuid.Parse(req.ProductID)
 
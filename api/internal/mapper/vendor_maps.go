@@ -98,3 +98,31 @@ func ToVendorResponse(vendor *models.Vendor) *models.VendorResponse {
 		UpdatedAt:   vendor.UpdatedAt,
 	}
 }
+
+// Product Vendor Mappers
+
+func ToProductVendor(req *models.ProductVendorRequest, productID, vendorID, inventoryID uuid.UUID) *models.ProductVendor {
+	return &models.ProductVendor{
+		ProductID:    productID,
+		VendorID:     vendorID,
+		InventoryID:  inventoryID,
+		VendorSKU:    trim(req.VendorSKU),
+		LeadTimeDays: req.LeadTimeDays,
+		LastCost:     req.LastCost,
+		IsPreferred:  req.IsPreferred,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+}
+
+func ToProductVendorResponse(link *models.ProductVendor) *models.ProductVendorResponse {
+	return &models.ProductVendorResponse{
+		VendorID:     link.VendorID,
+		VendorName:   link.VendorName,
+		VendorSKU:    link.VendorSKU,
+		LeadTimeDays: link.LeadTimeDays,
+		LastCost:     link.LastCost,
+		IsPreferred:  link.IsPreferred,
+		UpdatedAt:    link.UpdatedAt,
+	}
+}
@@ -106,3 +106,25 @@ func ToWarehouseStock(warehousesWithStock []models.WarehouseWithStock) []models.
 	}
 	return storages
 }
+
+// ToStockMovementResponse leaves the product and warehouse IDs out once they
+// were deleted
+func ToStockMovementResponse(movement *models.StockMovement) *models.StockMovementResponse {
+	response := &models.StockMovementResponse{
+		ID:            movement.ID,
+		ProductName:   movement.ProductName,
+		WarehouseName: movement.WarehouseName,
+		Quantity:      movement.Quantity,
+		Reason:        movement.Reason,
+		DocumentID:    movement.DocumentID,
+		UserID:        movement.UserID,
+		CreatedAt:     movement.CreatedAt,
+	}
+	if movement.ProductID != uuid.Nil {
+		response.ProductID = &movement.ProductID
+	}
+	if movement.WarehouseID != uuid.Nil {
+		response.WarehouseID = &movement.WarehouseID
+	}
+	return response
+}
@@ -13,6 +13,7 @@ type Customer struct {
 	Phone        *string   `db:"phone" json:"phone"`
 	Address      *string   `db:"address" json:"address"`
 	CustomerType string    `db:"customer_type" json:"customerType"`
+	CreditLimit  *int      `db:"credit_limit" json:"creditLimit"`
 	InventoryID  uuid.UUID `db:"inventory_id" json:"inventoryId"`
 	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
 	UpdatedAt    time.Time `db:"updated_at" json:"updatedAt"`
@@ -24,6 +25,7 @@ type CustomerRequest struct {
 	Phone        *string `json:"phone" validate:"omitempty,max=20"`
 	Address      *string `json:"address"`
 	CustomerType string  `json:"customerType" validate:"required,oneof=individual business"`
+	CreditLimit  *int    `json:"creditLimit" validate:"omitempty,min=0"`
 }
 
 type CustomerResponse struct {
@@ -33,6 +35,7 @@ type CustomerResponse struct {
 	Phone        *string   `json:"phone"`
 	Address      *string   `json:"address"`
 	CustomerType string    `json:"customerType"`
+	CreditLimit  *int      `json:"creditLimit"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
@@ -8,26 +8,157 @@ import (
 
 // Inventory models
 type Inventory struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	Name      string    `db:"name" json:"name"`
-	UserID    uuid.UUID `db:"user_id" json:"userId"`
-	CreatedAt time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
-	Currency  *Currency `json:"currency,omitempty"`
+	ID              uuid.UUID `db:"id" json:"id"`
+	Name            string    `db:"name" json:"name"`
+	UserID          uuid.UUID `db:"user_id" json:"userId"`
+	ValuationMethod string    `db:"valuation_method" json:"valuationMethod"`
+	CreatedAt       time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updatedAt"`
+	Currency        *Currency `json:"currency,omitempty"`
 }
 
 type InventoryRequest struct {
-	Name     string          `json:"name" validate:"required,min=1,max=100"`
-	Currency CurrencyRequest `json:"currency" validate:"required"`
+	Name            string          `json:"name" validate:"required,min=1,max=100"`
+	ValuationMethod string          `json:"valuationMethod" validate:"omitempty,oneof=fifo weighted_average standard"`
+	Currency        CurrencyRequest `json:"currency" validate:"required"`
 }
 
 type InventoryResponse struct {
-	ID        uuid.UUID        `json:"id"`
-	Name      string           `json:"name"`
-	UserID    uuid.UUID        `json:"userId"`
-	Currency  CurrencyResponse `json:"currency"`
-	CreatedAt time.Time        `json:"createdAt"`
-	UpdatedAt time.Time        `json:"updatedAt"`
+	ID              uuid.UUID        `json:"id"`
+	Name            string           `json:"name"`
+	UserID          uuid.UUID        `json:"userId"`
+	ValuationMethod string           `json:"valuationMethod"`
+	Currency        CurrencyResponse `json:"currency"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	UpdatedAt       time.Time        `json:"updatedAt"`
+}
+
+// Inventory member models
+const (
+	RoleOwner   = "owner"
+	RoleManager = "manager"
+	RoleClerk   = "clerk"
+	RoleViewer  = "viewer"
+)
+
+const (
+	MemberStatusPending = "pending"
+	MemberStatusActive  = "active"
+)
+
+type InventoryMember struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	InventoryID uuid.UUID  `db:"inventory_id" json:"inventoryId"`
+	UserID      uuid.UUID  `db:"user_id" json:"userId"`
+	Role        string     `db:"role" json:"role"`
+	Status      string     `db:"status" json:"status"`
+	InvitedBy   *uuid.UUID `db:"invited_by" json:"invitedBy"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updatedAt"`
+
+	// Joined from users
+	Username string `db:"username" json:"username"`
+	Email    string `db:"email" json:"email"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Role  string `json:"role" validate:"required,oneof=manager clerk viewer"`
+}
+
+type InventoryMemberResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"userId"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	Status    string     `json:"status"`
+	InvitedBy *uuid.UUID `json:"invitedBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Document numbering models
+
+// DefaultDocumentPrefixes holds the prefix of each numbered document type until
+// the inventory configures its own
+var DefaultDocumentPrefixes = map[string]string{
+	DocumentTypeSale:           "SO",
+	DocumentTypePurchase:       "PO",
+	DocumentTypeSaleReturn:     "RMA",
+	DocumentTypePurchaseReturn: "DN",
+}
+
+type DocumentSequence struct {
+	InventoryID  uuid.UUID `db:"inventory_id" json:"inventoryId"`
+	DocumentType string    `db:"document_type" json:"documentType"`
+	Prefix       string    `db:"prefix" json:"prefix"`
+	Template     string    `db:"template" json:"template"`
+	LastValue    int       `db:"last_value" json:"lastValue"`
+	PeriodKey    string    `db:"period_key" json:"periodKey"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+type DocumentSequenceRequest struct {
+	Prefix   string `json:"prefix" validate:"required,min=1,max=20"`
+	Template string `json:"template" validate:"required,min=1,max=100"`
+}
+
+type DocumentSequenceResponse struct {
+	DocumentType string    `json:"documentType"`
+	Prefix       string    `json:"prefix"`
+	Template     string    `json:"template"`
+	LastValue    int       `json:"lastValue"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Document template models
+
+// Printed layouts that can be configured per inventory
+const (
+	DocumentLayoutInvoice       = "invoice"
+	DocumentLayoutReceipt       = "receipt"
+	DocumentLayoutPurchaseOrder = "purchase_order"
+)
+
+// DefaultDocumentTemplates holds how each layout prints until the inventory
+// configures its own
+var DefaultDocumentTemplates = map[string]DocumentTemplate{
+	DocumentLayoutInvoice:       {Layout: DocumentLayoutInvoice, Title: "Invoice", PaperSize: "a4", ShowPayments: true},
+	DocumentLayoutReceipt:       {Layout: DocumentLayoutReceipt, Title: "Receipt", PaperSize: "80mm", ShowPayments: true},
+	DocumentLayoutPurchaseOrder: {Layout: DocumentLayoutPurchaseOrder, Title: "Purchase order", PaperSize: "a4"},
+}
+
+// DocumentTemplate is the configurable part of a printed layout. Header lines
+// print under the inventory name, e.g. address and tax number, and the footer
+// at the bottom, e.g. payment terms.
+type DocumentTemplate struct {
+	InventoryID  uuid.UUID `db:"inventory_id" json:"inventoryId"`
+	Layout       string    `db:"layout" json:"layout"`
+	Title        string    `db:"title" json:"title"`
+	Header       string    `db:"header" json:"header"`
+	Footer       string    `db:"footer" json:"footer"`
+	PaperSize    string    `db:"paper_size" json:"paperSize"`
+	ShowPayments bool      `db:"show_payments" json:"showPayments"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+type DocumentTemplateRequest struct {
+	Title        string `json:"title" validate:"required,min=1,max=100"`
+	Header       string `json:"header" validate:"max=1000"`
+	Footer       string `json:"footer" validate:"max=1000"`
+	PaperSize    string `json:"paperSize" validate:"required"`
+	ShowPayments bool   `json:"showPayments"`
+}
+
+type DocumentTemplateResponse struct {
+	Layout       string    `json:"layout"`
+	Title        string    `json:"title"`
+	Header       string    `json:"header"`
+	Footer       string    `json:"footer"`
+	PaperSize    string    `json:"paperSize"`
+	ShowPayments bool      `json:"showPayments"`
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
 // Currency models
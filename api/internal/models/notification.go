@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification types
+const (
+	NotificationLowStock = "low_stock"
+)
+
+// Notification is a message for the members of an inventory, raised by
+// background jobs such as the low-stock check
+type Notification struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	InventoryID uuid.UUID  `db:"inventory_id" json:"inventoryId"`
+	Type        string     `db:"type" json:"type"`
+	Title       string     `db:"title" json:"title"`
+	Message     string     `db:"message" json:"message"`
+	ProductID   *uuid.UUID `db:"product_id" json:"productId"`
+	ReadAt      *time.Time `db:"read_at" json:"readAt"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+}
+
+type NotificationResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	ProductID *uuid.UUID `json:"productId"`
+	ReadAt    *time.Time `json:"readAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type UnreadNotificationsResponse struct {
+	Unread int `json:"unread"`
+}
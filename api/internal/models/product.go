@@ -89,6 +89,43 @@ type ProductWithStock struct {
 	QuantityInStock int       `db:"quantity_in_stock"`
 }
 
+// LowStockProduct is a product at or below its restock level. Stock is the
+// quantity that was checked: the total stock, or the stock of one warehouse.
+type LowStockProduct struct {
+	ID                uuid.UUID           `db:"id"`
+	Name              string              `db:"name"`
+	Code              string              `db:"code"`
+	SKU               string              `db:"sku"`
+	Stock             int                 `db:"stock"`
+	TotalStock        int                 `db:"total_stock"`
+	RestockLevel      int                 `db:"restock_level"`
+	OptimalLevel      int                 `db:"optimal_level"`
+	CostPrice         int                 `db:"cost_price"`
+	SuggestedQuantity int                 `db:"suggested_quantity"`
+	Warehouses        []LowStockWarehouse `db:"-"`
+}
+
+type LowStockWarehouse struct {
+	ProductID       uuid.UUID `db:"product_id" json:"-"`
+	WarehouseID     uuid.UUID `db:"warehouse_id" json:"warehouseId"`
+	Name            string    `db:"name" json:"name"`
+	QuantityInStock int       `db:"quantity_in_stock" json:"quantityInStock"`
+}
+
+type LowStockProductResponse struct {
+	ID                uuid.UUID           `json:"id"`
+	Name              string              `json:"name"`
+	Code              string              `json:"code"`
+	SKU               string              `json:"sku"`
+	Stock             int                 `json:"stock"`
+	TotalStock        int                 `json:"totalStock"`
+	RestockLevel      int                 `json:"restockLevel"`
+	OptimalLevel      int                 `json:"optimalLevel"`
+	SuggestedQuantity int                 `json:"suggestedQuantity"`
+	SuggestedCost     int                 `json:"suggestedCost"`
+	Warehouses        []LowStockWarehouse `json:"warehouses"`
+}
+
 // Image models
 type ProductImage struct {
 	ID        uuid.UUID `db:"id" json:"id"`
@@ -153,3 +190,41 @@ type StorageResponse struct {
 	Warehouse       WarehouseResponse `json:"warehouse"`
 	QuantityInStock int               `json:"quantityInStock"`
 }
+
+// Import models
+
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionSkip   = "skip"
+)
+
+// ProductImportRow is a spreadsheet row that passed validation and is ready to
+// be written. Stock holds the initial quantity per warehouse of new products.
+type ProductImportRow struct {
+	Row        int
+	Product    *Product
+	Categories []string
+	Stock      map[uuid.UUID]int
+	Update     bool
+}
+
+type ProductImportResult struct {
+	Row       int        `json:"row"`
+	Action    string     `json:"action"`
+	ProductID *uuid.UUID `json:"productId,omitempty"`
+	Name      string     `json:"name"`
+	SKU       string     `json:"sku"`
+	Code      string     `json:"code"`
+	Errors    []string   `json:"errors,omitempty"`
+	Warnings  []string   `json:"warnings,omitempty"`
+}
+
+type ProductImportResponse struct {
+	DryRun  bool                  `json:"dryRun"`
+	Total   int                   `json:"total"`
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Failed  int                   `json:"failed"`
+	Results []ProductImportResult `json:"results"`
+}
@@ -13,9 +13,11 @@ type Purchase struct {
 	VendorName      string         `db:"vendor_name" json:"vendorName"`
 	PurchaseDate    time.Time      `db:"purchase_date" json:"purchaseDate"`
 	Eta             *time.Time     `db:"eta" json:"eta"`
+	DueDate         *time.Time     `db:"due_date" json:"dueDate"`
 	DeliveryDate    *time.Time     `db:"delivery_date" json:"deliveryDate"`
 	ShippingCost    int            `db:"shipping_cost" json:"shippingCost"`
 	TotalAmount     int            `db:"total_amount" json:"totalAmount"`
+	Balance         int            `db:"balance" json:"balance"`
 	PaymentStatus   string         `db:"payment_status" json:"paymentStatus"`
 	PurchaseStatus  string         `db:"purchase_status" json:"purchaseStatus"`
 	DiscountAmount  int            `db:"discount_amount" json:"discountAmount"`
@@ -27,14 +29,91 @@ type Purchase struct {
 }
 
 type PurchaseItem struct {
-	ID         uuid.UUID `db:"id" json:"id"`
-	PurchaseID uuid.UUID `db:"purchase_id" json:"purchaseId"`
-	ProductID  uuid.UUID `db:"product_id" json:"productId"`
-	Quantity   int       `db:"quantity" json:"quantity"`
-	UnitPrice  int       `db:"unit_price" json:"unitPrice"`
-	Subtotal   int       `db:"subtotal" json:"subtotal"`
-	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
-	Product    *Product  `json:"product,omitempty"`
+	ID               uuid.UUID `db:"id" json:"id"`
+	PurchaseID       uuid.UUID `db:"purchase_id" json:"purchaseId"`
+	ProductID        uuid.UUID `db:"product_id" json:"productId"`
+	Quantity         int       `db:"quantity" json:"quantity"`
+	ReceivedQuantity int       `db:"received_quantity" json:"receivedQuantity"`
+	UnitPrice        int       `db:"unit_price" json:"unitPrice"`
+	DiscountAmount   int       `db:"discount_amount" json:"discountAmount"`
+	DiscountPercent  int       `db:"discount_percent" json:"discountPercent"`
+	Subtotal         int       `db:"subtotal" json:"subtotal"`
+	CreatedAt        time.Time `db:"created_at" json:"createdAt"`
+	Product          *Product  `json:"product,omitempty"`
+}
+
+type PurchasePayment struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	PurchaseID  uuid.UUID `db:"purchase_id" json:"purchaseId"`
+	Amount      int       `db:"amount" json:"amount"`
+	Method      string    `db:"method" json:"method"`
+	Reference   *string   `db:"reference" json:"reference"`
+	PaymentDate time.Time `db:"payment_date" json:"paymentDate"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+type PurchaseReceipt struct {
+	WarehouseID  uuid.UUID
+	DeliveryDate time.Time
+	ReceivedBy   uuid.UUID
+	Items        []PurchaseReceiptItem
+}
+
+type PurchaseReceiptItem struct {
+	PurchaseItemID uuid.UUID
+	Quantity       int
+}
+
+// PurchaseReturn sends received units back to the vendor. CreditAmount is the
+// value of the goods taken off the purchase's payable and RefundDue the part of
+// it the vendor has to pay back because it was already paid.
+type PurchaseReturn struct {
+	ID             uuid.UUID            `db:"id" json:"id"`
+	ReturnNumber   string               `db:"return_number" json:"returnNumber"`
+	PurchaseID     uuid.UUID            `db:"purchase_id" json:"purchaseId"`
+	PurchaseNumber string               `db:"purchase_number" json:"purchaseNumber"`
+	InventoryID    uuid.UUID            `db:"inventory_id" json:"inventoryId"`
+	VendorID       *uuid.UUID           `db:"vendor_id" json:"vendorId"`
+	WarehouseID    *uuid.UUID           `db:"warehouse_id" json:"warehouseId"`
+	Reason         string               `db:"reason" json:"reason"`
+	CreditAmount   int                  `db:"credit_amount" json:"creditAmount"`
+	RefundDue      int                  `db:"refund_due" json:"refundDue"`
+	ReturnDate     time.Time            `db:"return_date" json:"returnDate"`
+	ReturnedBy     *uuid.UUID           `db:"returned_by" json:"returnedBy"`
+	CreatedAt      time.Time            `db:"created_at" json:"createdAt"`
+	Items          []PurchaseReturnItem `json:"items,omitempty"`
+}
+
+type PurchaseReturnItem struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	ReturnID       uuid.UUID `db:"return_id" json:"returnId"`
+	PurchaseItemID uuid.UUID `db:"purchase_item_id" json:"purchaseItemId"`
+	ProductID      uuid.UUID `db:"product_id" json:"productId"`
+	Quantity       int       `db:"quantity" json:"quantity"`
+	UnitCost       int       `db:"unit_cost" json:"unitCost"`
+	Amount         int       `db:"amount" json:"amount"`
+	CreatedAt      time.Time `db:"created_at" json:"createdAt"`
+}
+
+// ReorderLine is a low-stock product to reorder from its preferred vendor.
+// Quantity already excludes what is on open purchases.
+type ReorderLine struct {
+	ProductID    uuid.UUID  `db:"product_id"`
+	ProductName  string     `db:"product_name"`
+	VendorID     *uuid.UUID `db:"vendor_id"`
+	VendorName   *string    `db:"vendor_name"`
+	LeadTimeDays int        `db:"lead_time_days"`
+	Quantity     int        `db:"quantity"`
+	UnitPrice    int        `db:"unit_price"`
+}
+
+// PurchaseDocument gathers everything a printed purchase order shows
+type PurchaseDocument struct {
+	InventoryName string
+	Purchase      Purchase
+	Vendor        *Vendor
+	Currency      Currency
+	Template      DocumentTemplate
 }
 
 // DTOs
@@ -43,9 +122,10 @@ type PurchaseRequest struct {
 	VendorName      *string               `json:"vendorName" validate:"min=1,max=100"`
 	PurchaseDate    *time.Time            `json:"purchaseDate"`
 	Eta             *time.Time            `json:"eta"`
+	DueDate         *time.Time            `json:"dueDate"`
 	ShippingCost    int                   `json:"shippingCost" validate:"min=0"`
-	TotalAmount     int                   `json:"totalAmount" validate:"required,min=0"`
-	PaymentStatus   string                `json:"paymentStatus" validate:"omitempty,oneof=pending partial paid overdue cancelled"`
+	TotalAmount     *int                  `json:"totalAmount" validate:"omitempty,min=0"`
+	PaymentStatus   string                `json:"paymentStatus" validate:"omitempty,oneof=pending partial paid overdue cancelled"` // only overdue and cancelled stick, the rest follows the payments
 	PurchaseStatus  string                `json:"purchaseStatus" validate:"omitempty,oneof=draft ordered shipped received cancelled"`
 	DiscountAmount  int                   `json:"discountAmount" validate:"min=0"`
 	DiscountPercent int                   `json:"discountPercent" validate:"min=0,max=100"`
@@ -53,10 +133,50 @@ type PurchaseRequest struct {
 }
 
 type PurchaseItemRequest struct {
-	ProductID string `json:"productId" validate:"required,uuid"`
-	Quantity  int    `json:"quantity" validate:"required,min=1"`
-	UnitPrice int    `json:"unitPrice" validate:"required,min=0"`
-	Subtotal  int    `json:"subtotal" validate:"required,min=0"`
+	ID              *string `json:"id" validate:"omitempty,uuid"` // set when editing an existing line
+	ProductID       string  `json:"productId" validate:"required,uuid"`
+	Quantity        int     `json:"quantity" validate:"required,min=1"`
+	UnitPrice       int     `json:"unitPrice" validate:"min=0"`
+	DiscountAmount  int     `json:"discountAmount" validate:"min=0"`
+	DiscountPercent int     `json:"discountPercent" validate:"min=0,max=100"`
+	Subtotal        *int    `json:"subtotal" validate:"omitempty,min=0"`
+}
+
+type PurchasePaymentRequest struct {
+	Amount      int        `json:"amount" validate:"required,min=1"`
+	Method      string     `json:"method" validate:"required,oneof=cash card transfer mobile_money"`
+	Reference   *string    `json:"reference" validate:"omitempty,max=100"`
+	PaymentDate *time.Time `json:"paymentDate"`
+}
+
+// GenerateDraftsRequest narrows the reorder to the stock of one warehouse or to
+// some products. Without it every low-stock product is reordered.
+type GenerateDraftsRequest struct {
+	WarehouseID *string  `json:"warehouseId" validate:"omitempty,uuid"`
+	ProductIDs  []string `json:"productIds" validate:"omitempty,max=500,dive,uuid"`
+}
+
+type ReceivePurchaseRequest struct {
+	WarehouseID  string               `json:"warehouseId" validate:"required,uuid"`
+	DeliveryDate *time.Time           `json:"deliveryDate"`
+	Items        []ReceiveItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type ReceiveItemRequest struct {
+	PurchaseItemID string `json:"purchaseItemId" validate:"required,uuid"`
+	Quantity       int    `json:"quantity" validate:"required,min=1"`
+}
+
+type PurchaseReturnRequest struct {
+	WarehouseID string                      `json:"warehouseId" validate:"required,uuid"`
+	Reason      string                      `json:"reason" validate:"required,min=1,max=500"`
+	ReturnDate  *time.Time                  `json:"returnDate"`
+	Items       []PurchaseReturnItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type PurchaseReturnItemRequest struct {
+	PurchaseItemID string `json:"purchaseItemId" validate:"required,uuid"`
+	Quantity       int    `json:"quantity" validate:"required,min=1"`
 }
 
 type PurchaseResponse struct {
@@ -66,9 +186,11 @@ type PurchaseResponse struct {
 	VendorName      string                 `json:"vendorName"`
 	PurchaseDate    time.Time              `json:"purchaseDate"`
 	Eta             *time.Time             `json:"eta"`
+	DueDate         *time.Time             `json:"dueDate"`
 	DeliveryDate    *time.Time             `json:"deliveryDate"`
 	ShippingCost    int                    `json:"shippingCost"`
 	TotalAmount     int                    `json:"totalAmount"`
+	Balance         int                    `json:"balance"`
 	PaymentStatus   string                 `json:"paymentStatus"`
 	PurchaseStatus  string                 `json:"purchaseStatus"`
 	DiscountAmount  int                    `json:"discountAmount"`
@@ -79,11 +201,77 @@ type PurchaseResponse struct {
 }
 
 type PurchaseItemResponse struct {
-	ID        uuid.UUID        `json:"id"`
-	ProductID uuid.UUID        `json:"productId"`
-	Quantity  int              `json:"quantity"`
-	UnitPrice int              `json:"unitPrice"`
-	Subtotal  int              `json:"subtotal"`
-	CreatedAt time.Time        `json:"createdAt"`
-	Product   *ProductResponse `json:"product,omitempty"`
+	ID               uuid.UUID        `json:"id"`
+	ProductID        uuid.UUID        `json:"productId"`
+	Quantity         int              `json:"quantity"`
+	ReceivedQuantity int              `json:"receivedQuantity"`
+	UnitPrice        int              `json:"unitPrice"`
+	DiscountAmount   int              `json:"discountAmount"`
+	DiscountPercent  int              `json:"discountPercent"`
+	Subtotal         int              `json:"subtotal"`
+	CreatedAt        time.Time        `json:"createdAt"`
+	Product          *ProductResponse `json:"product,omitempty"`
+}
+
+type PurchasePaymentResponse struct {
+	ID          uuid.UUID `json:"id"`
+	PurchaseID  uuid.UUID `json:"purchaseId"`
+	Amount      int       `json:"amount"`
+	Method      string    `json:"method"`
+	Reference   *string   `json:"reference"`
+	PaymentDate time.Time `json:"paymentDate"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type PurchaseReturnResponse struct {
+	ID             uuid.UUID                    `json:"id"`
+	ReturnNumber   string                       `json:"returnNumber"`
+	PurchaseID     uuid.UUID                    `json:"purchaseId"`
+	PurchaseNumber string                       `json:"purchaseNumber"`
+	VendorID       *uuid.UUID                   `json:"vendorId"`
+	WarehouseID    *uuid.UUID                   `json:"warehouseId"`
+	Reason         string                       `json:"reason"`
+	CreditAmount   int                          `json:"creditAmount"`
+	RefundDue      int                          `json:"refundDue"`
+	ReturnDate     time.Time                    `json:"returnDate"`
+	ReturnedBy     *uuid.UUID                   `json:"returnedBy"`
+	CreatedAt      time.Time                    `json:"createdAt"`
+	Items          []PurchaseReturnItemResponse `json:"items"`
+}
+
+type PurchaseReturnItemResponse struct {
+	ID             uuid.UUID `json:"id"`
+	PurchaseItemID uuid.UUID `json:"purchaseItemId"`
+	ProductID      uuid.UUID `json:"productId"`
+	Quantity       int       `json:"quantity"`
+	UnitCost       int       `json:"unitCost"`
+	Amount         int       `json:"amount"`
+}
+
+// PurchaseEmailRequest sends a purchase order to the vendor. Without
+// recipients it goes to the vendor's email address.
+type PurchaseEmailRequest struct {
+	To      []string `json:"to" validate:"omitempty,max=10,dive,email"`
+	Cc      []string `json:"cc" validate:"omitempty,max=10,dive,email"`
+	Subject string   `json:"subject" validate:"max=200"`
+	Message string   `json:"message" validate:"max=5000"`
+}
+
+type PurchaseEmailResponse struct {
+	To      []string  `json:"to"`
+	Cc      []string  `json:"cc"`
+	Subject string    `json:"subject"`
+	SentAt  time.Time `json:"sentAt"`
+}
+
+type GenerateDraftsResponse struct {
+	Purchases []PurchaseResponse   `json:"purchases"`
+	Skipped   []SkippedReorderLine `json:"skipped"`
+}
+
+// SkippedReorderLine is a low-stock product no draft was made for
+type SkippedReorderLine struct {
+	ProductID uuid.UUID `json:"productId"`
+	Name      string    `json:"name"`
+	Reason    string    `json:"reason"`
 }
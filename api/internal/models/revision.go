@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Document types that keep a revision history or a number sequence
+const (
+	DocumentTypeSale           = "sale"
+	DocumentTypePurchase       = "purchase"
+	DocumentTypeSaleReturn     = "sale_return"
+	DocumentTypePurchaseReturn = "purchase_return"
+)
+
+// DocumentRevision holds the state of a document as it was before an edit
+type DocumentRevision struct {
+	ID           uuid.UUID      `db:"id" json:"id"`
+	InventoryID  uuid.UUID      `db:"inventory_id" json:"inventoryId"`
+	DocumentType string         `db:"document_type" json:"documentType"`
+	DocumentID   uuid.UUID      `db:"document_id" json:"documentId"`
+	Revision     int            `db:"revision" json:"revision"`
+	Snapshot     types.JSONText `db:"snapshot" json:"snapshot"`
+	EditedBy     *uuid.UUID     `db:"edited_by" json:"editedBy"`
+	CreatedAt    time.Time      `db:"created_at" json:"createdAt"`
+}
+
+type DocumentRevisionResponse struct {
+	ID        uuid.UUID      `json:"id"`
+	Revision  int            `json:"revision"`
+	Snapshot  types.JSONText `json:"snapshot"`
+	EditedBy  *uuid.UUID     `json:"editedBy"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
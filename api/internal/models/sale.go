@@ -6,52 +6,156 @@ import (
 	"github.com/google/uuid"
 )
 
+// Payment statuses, derived from the payments recorded against a document
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusPartial   = "partial"
+	PaymentStatusPaid      = "paid"
+	PaymentStatusOverdue   = "overdue"
+	PaymentStatusCancelled = "cancelled"
+)
+
+// Payment methods
+const (
+	PaymentMethodCash        = "cash"
+	PaymentMethodCard        = "card"
+	PaymentMethodTransfer    = "transfer"
+	PaymentMethodMobileMoney = "mobile_money"
+)
+
 type Sale struct {
+	ID              uuid.UUID     `db:"id" json:"id"`
+	SaleNumber      string        `db:"sale_number" json:"saleNumber"`
+	CustomerID      *uuid.UUID    `db:"customer_id" json:"customerId"`
+	CustomerName    string        `db:"customer_name" json:"customerName"`
+	SaleDate        time.Time     `db:"sale_date" json:"saleDate"`
+	TotalAmount     int           `db:"total_amount" json:"totalAmount"`
+	Balance         int           `db:"balance" json:"balance"`
+	PaymentStatus   string        `db:"payment_status" json:"paymentStatus"`
+	DiscountAmount  int           `db:"discount_amount" json:"discountAmount"`
+	DiscountPercent int           `db:"discount_percent" json:"discountPercent"`
+	InventoryID     uuid.UUID     `db:"inventory_id" json:"inventoryId"`
+	CreatedAt       time.Time     `db:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time     `db:"updated_at" json:"updatedAt"`
+	Items           []SaleItem    `json:"items,omitempty"`
+	Payments        []SalePayment `json:"-"`
+}
+
+type SaleItem struct {
 	ID              uuid.UUID  `db:"id" json:"id"`
-	SaleNumber      string     `db:"sale_number" json:"saleNumber"`
-	CustomerID      *uuid.UUID `db:"customer_id" json:"customerId"`
-	CustomerName    string     `db:"customer_name" json:"customerName"`
-	SaleDate        time.Time  `db:"sale_date" json:"saleDate"`
-	TotalAmount     int        `db:"total_amount" json:"totalAmount"`
-	Balance         int        `db:"balance" json:"balance"`
-	PaymentStatus   string     `db:"payment_status" json:"paymentStatus"`
+	SaleID          uuid.UUID  `db:"sale_id" json:"saleId"`
+	ProductID       uuid.UUID  `db:"product_id" json:"productId"`
+	WarehouseID     *uuid.UUID `db:"warehouse_id" json:"warehouseId"`
+	Quantity        int        `db:"quantity" json:"quantity"`
+	UnitPrice       int        `db:"unit_price" json:"unitPrice"`
+	UnitCost        int        `db:"unit_cost" json:"unitCost"`
 	DiscountAmount  int        `db:"discount_amount" json:"discountAmount"`
 	DiscountPercent int        `db:"discount_percent" json:"discountPercent"`
-	InventoryID     uuid.UUID  `db:"inventory_id" json:"inventoryId"`
+	Subtotal        int        `db:"subtotal" json:"subtotal"`
 	CreatedAt       time.Time  `db:"created_at" json:"createdAt"`
-	UpdatedAt       time.Time  `db:"updated_at" json:"updatedAt"`
-	Items           []SaleItem `json:"items,omitempty"`
+	Product         *Product   `json:"product,omitempty"`
 }
 
-type SaleItem struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	SaleID    uuid.UUID `db:"sale_id" json:"saleId"`
-	ProductID uuid.UUID `db:"product_id" json:"productId"`
-	Quantity  int       `db:"quantity" json:"quantity"`
-	UnitPrice int       `db:"unit_price" json:"unitPrice"`
-	Subtotal  int       `db:"subtotal" json:"subtotal"`
-	CreatedAt time.Time `db:"created_at" json:"createdAt"`
-	Product   *Product  `json:"product,omitempty"`
+type SalePayment struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	SaleID      uuid.UUID `db:"sale_id" json:"saleId"`
+	Amount      int       `db:"amount" json:"amount"`
+	Method      string    `db:"method" json:"method"`
+	Reference   *string   `db:"reference" json:"reference"`
+	PaymentDate time.Time `db:"payment_date" json:"paymentDate"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+// Conditions of returned units
+const (
+	ReturnConditionRestockable = "restockable"
+	ReturnConditionDamaged     = "damaged"
+)
+
+// SaleReturn takes units of a sale back from the customer. TotalAmount is the
+// value credited against the sale and RefundAmount the part of it paid back.
+type SaleReturn struct {
+	ID           uuid.UUID        `db:"id" json:"id"`
+	ReturnNumber string           `db:"return_number" json:"returnNumber"`
+	SaleID       uuid.UUID        `db:"sale_id" json:"saleId"`
+	SaleNumber   string           `db:"sale_number" json:"saleNumber"`
+	InventoryID  uuid.UUID        `db:"inventory_id" json:"inventoryId"`
+	WarehouseID  *uuid.UUID       `db:"warehouse_id" json:"warehouseId"`
+	Reason       string           `db:"reason" json:"reason"`
+	TotalAmount  int              `db:"total_amount" json:"totalAmount"`
+	RefundAmount int              `db:"refund_amount" json:"refundAmount"`
+	RefundMethod *string          `db:"refund_method" json:"refundMethod"`
+	ReturnDate   time.Time        `db:"return_date" json:"returnDate"`
+	ReturnedBy   *uuid.UUID       `db:"returned_by" json:"returnedBy"`
+	CreatedAt    time.Time        `db:"created_at" json:"createdAt"`
+	Items        []SaleReturnItem `json:"items,omitempty"`
+}
+
+type SaleReturnItem struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	ReturnID   uuid.UUID `db:"return_id" json:"returnId"`
+	SaleItemID uuid.UUID `db:"sale_item_id" json:"saleItemId"`
+	ProductID  uuid.UUID `db:"product_id" json:"productId"`
+	Quantity   int       `db:"quantity" json:"quantity"`
+	Condition  string    `db:"condition" json:"condition"`
+	UnitPrice  int       `db:"unit_price" json:"unitPrice"`
+	UnitCost   int       `db:"unit_cost" json:"unitCost"`
+	Amount     int       `db:"amount" json:"amount"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+}
+
+// SaleDocument gathers everything a printed sale shows
+type SaleDocument struct {
+	InventoryName string
+	Sale          Sale
+	Customer      *Customer
+	Payments      []SalePayment
+	Currency      Currency
+	Template      DocumentTemplate
 }
 
 // DTOs
 type SaleRequest struct {
-	CustomerID      *string           `json:"customerId"`
-	CustomerName    *string           `json:"customerName" validate:"min=1,max=100"`
-	SaleDate        *time.Time        `json:"saleDate"`
-	DiscountAmount  int               `json:"discountAmount" validate:"min=0"`
-	DiscountPercent int               `json:"discountPercent" validate:"min=0,max=100"`
-	TotalAmount     int               `json:"totalAmount" validate:"required,min=0"`
-	Balance         int               `json:"balance" validate:"min=0"`
-	PaymentStatus   string            `json:"paymentStatus" validate:"omitempty,oneof=pending partial paid overdue cancelled"`
-	Items           []SaleItemRequest `json:"items" validate:"required,min=1,dive"`
+	CustomerID      *string              `json:"customerId"`
+	CustomerName    *string              `json:"customerName" validate:"min=1,max=100"`
+	SaleDate        *time.Time           `json:"saleDate"`
+	DiscountAmount  int                  `json:"discountAmount" validate:"min=0"`
+	DiscountPercent int                  `json:"discountPercent" validate:"min=0,max=100"`
+	TotalAmount     *int                 `json:"totalAmount" validate:"omitempty,min=0"`
+	Items           []SaleItemRequest    `json:"items" validate:"required,min=1,dive"`
+	Payments        []SalePaymentRequest `json:"payments" validate:"omitempty,dive"`
 }
 
 type SaleItemRequest struct {
-	ProductID string `json:"productId" validate:"required,uuid"`
-	Quantity  int    `json:"quantity" validate:"required,min=1"`
-	UnitPrice int    `json:"unitPrice" validate:"required,min=0"`
-	Subtotal  int    `json:"subtotal" validate:"required,min=0"`
+	ID              *string `json:"id" validate:"omitempty,uuid"` // set when editing an existing line
+	ProductID       string  `json:"productId" validate:"required,uuid"`
+	WarehouseID     *string `json:"warehouseId" validate:"omitempty,uuid"`
+	Quantity        int     `json:"quantity" validate:"required,min=1"`
+	UnitPrice       int     `json:"unitPrice" validate:"min=0"`
+	DiscountAmount  int     `json:"discountAmount" validate:"min=0"`
+	DiscountPercent int     `json:"discountPercent" validate:"min=0,max=100"`
+	Subtotal        *int    `json:"subtotal" validate:"omitempty,min=0"`
+}
+
+type SalePaymentRequest struct {
+	Amount      int        `json:"amount" validate:"required,min=1"`
+	Method      string     `json:"method" validate:"required,oneof=cash card transfer mobile_money"`
+	Reference   *string    `json:"reference" validate:"omitempty,max=100"`
+	PaymentDate *time.Time `json:"paymentDate"`
+}
+
+type SaleReturnRequest struct {
+	WarehouseID  string                  `json:"warehouseId" validate:"required,uuid"`
+	Reason       string                  `json:"reason" validate:"required,min=1,max=500"`
+	ReturnDate   *time.Time              `json:"returnDate"`
+	RefundMethod *string                 `json:"refundMethod" validate:"omitempty,oneof=cash card transfer mobile_money"`
+	Items        []SaleReturnItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type SaleReturnItemRequest struct {
+	SaleItemID string `json:"saleItemId" validate:"required,uuid"`
+	Quantity   int    `json:"quantity" validate:"required,min=1"`
+	Condition  string `json:"condition" validate:"required,oneof=restockable damaged"`
 }
 
 type SaleResponse struct {
@@ -71,11 +175,52 @@ type SaleResponse struct {
 }
 
 type SaleItemResponse struct {
-	ID        uuid.UUID        `json:"id"`
-	ProductID uuid.UUID        `json:"productId"`
-	Quantity  int              `json:"quantity"`
-	UnitPrice int              `json:"unitPrice"`
-	Subtotal  int              `json:"subtotal"`
-	CreatedAt time.Time        `json:"createdAt"`
-	Product   *ProductResponse `json:"product,omitempty"`
+	ID              uuid.UUID        `json:"id"`
+	ProductID       uuid.UUID        `json:"productId"`
+	WarehouseID     *uuid.UUID       `json:"warehouseId"`
+	Quantity        int              `json:"quantity"`
+	UnitPrice       int              `json:"unitPrice"`
+	UnitCost        int              `json:"unitCost"`
+	DiscountAmount  int              `json:"discountAmount"`
+	DiscountPercent int              `json:"discountPercent"`
+	Subtotal        int              `json:"subtotal"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	Product         *ProductResponse `json:"product,omitempty"`
+}
+
+type SalePaymentResponse struct {
+	ID          uuid.UUID `json:"id"`
+	SaleID      uuid.UUID `json:"saleId"`
+	Amount      int       `json:"amount"`
+	Method      string    `json:"method"`
+	Reference   *string   `json:"reference"`
+	PaymentDate time.Time `json:"paymentDate"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type SaleReturnResponse struct {
+	ID           uuid.UUID                `json:"id"`
+	ReturnNumber string                   `json:"returnNumber"`
+	SaleID       uuid.UUID                `json:"saleId"`
+	SaleNumber   string                   `json:"saleNumber"`
+	WarehouseID  *uuid.UUID               `json:"warehouseId"`
+	Reason       string                   `json:"reason"`
+	TotalAmount  int                      `json:"totalAmount"`
+	RefundAmount int                      `json:"refundAmount"`
+	RefundMethod *string                  `json:"refundMethod"`
+	ReturnDate   time.Time                `json:"returnDate"`
+	ReturnedBy   *uuid.UUID               `json:"returnedBy"`
+	CreatedAt    time.Time                `json:"createdAt"`
+	Items        []SaleReturnItemResponse `json:"items"`
+}
+
+type SaleReturnItemResponse struct {
+	ID         uuid.UUID `json:"id"`
+	SaleItemID uuid.UUID `json:"saleItemId"`
+	ProductID  uuid.UUID `json:"productId"`
+	Quantity   int       `json:"quantity"`
+	Condition  string    `json:"condition"`
+	UnitPrice  int       `json:"unitPrice"`
+	UnitCost   int       `json:"unitCost"`
+	Amount     int       `json:"amount"`
 }
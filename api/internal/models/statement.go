@@ -0,0 +1,115 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statement entry types
+const (
+	StatementEntryPurchase = "purchase"
+	StatementEntrySale     = "sale"
+	StatementEntryPayment  = "payment"
+	StatementEntryReturn   = "return"
+	StatementEntryRefund   = "refund"
+)
+
+// What the age of an open balance is counted from. Documents without a due
+// date age from their own date either way.
+const (
+	AgingBasisDueDate      = "dueDate"
+	AgingBasisDocumentDate = "documentDate"
+)
+
+// AgingBuckets splits open balances by how many days they have been
+// outstanding. Balances that are not due yet count as 0-30.
+type AgingBuckets struct {
+	Days0To30  int `json:"days0To30"`
+	Days31To60 int `json:"days31To60"`
+	Days61To90 int `json:"days61To90"`
+	Over90     int `json:"over90"`
+	Total      int `json:"total"`
+}
+
+// StatementEntry is one line of an account statement. Amount is signed:
+// positive entries raise the balance owed, negative ones settle it.
+type StatementEntry struct {
+	Date           time.Time `db:"entry_date" json:"date"`
+	Type           string    `db:"entry_type" json:"type"`
+	DocumentID     uuid.UUID `db:"document_id" json:"documentId"`
+	DocumentNumber string    `db:"document_number" json:"documentNumber"`
+	Reference      *string   `db:"reference" json:"reference"`
+	Amount         int       `db:"amount" json:"amount"`
+	Balance        int       `db:"-" json:"balance"`
+	CreatedAt      time.Time `db:"created_at" json:"-"`
+}
+
+// OpenDocument is a document with part of its total still outstanding
+type OpenDocument struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	Number          string     `db:"number" json:"number"`
+	Date            time.Time  `db:"document_date" json:"date"`
+	DueDate         *time.Time `db:"due_date" json:"dueDate"`
+	TotalAmount     int        `db:"total_amount" json:"totalAmount"`
+	Balance         int        `db:"balance" json:"balance"`
+	DaysOutstanding int        `db:"-" json:"daysOutstanding"`
+}
+
+// AccountStatement lists what happened on an account over a period, with the
+// balance carried in from before it. Open documents and aging are current.
+type AccountStatement struct {
+	From           *time.Time       `json:"from"`
+	To             *time.Time       `json:"to"`
+	Basis          string           `json:"basis"`
+	OpeningBalance int              `json:"openingBalance"`
+	ClosingBalance int              `json:"closingBalance"`
+	Entries        []StatementEntry `json:"entries"`
+	OpenDocuments  []OpenDocument   `json:"openDocuments"`
+	Aging          AgingBuckets     `json:"aging"`
+}
+
+type VendorStatementResponse struct {
+	VendorID   uuid.UUID `json:"vendorId"`
+	VendorName string    `json:"vendorName"`
+	AccountStatement
+}
+
+type CustomerStatementResponse struct {
+	CustomerID   uuid.UUID `json:"customerId"`
+	CustomerName string    `json:"customerName"`
+	CreditLimit  *int      `json:"creditLimit"`
+	AccountStatement
+}
+
+// VendorAging is the open payable to one vendor. Purchases without a vendor
+// are grouped under a nil vendor ID.
+type VendorAging struct {
+	VendorID   *uuid.UUID `json:"vendorId"`
+	VendorName string     `json:"vendorName"`
+	AgingBuckets
+}
+
+type PayablesAgingResponse struct {
+	AsOf    time.Time     `json:"asOf"`
+	Basis   string        `json:"basis"`
+	Totals  AgingBuckets  `json:"totals"`
+	Vendors []VendorAging `json:"vendors"`
+}
+
+// CustomerAging is the open receivable of one customer. Sales without a
+// customer are grouped under a nil customer ID.
+type CustomerAging struct {
+	CustomerID   *uuid.UUID `json:"customerId"`
+	CustomerName string     `json:"customerName"`
+	CreditLimit  *int       `json:"creditLimit"`
+	AgingBuckets
+}
+
+// ReceivablesAgingResponse ages open sales from their sale date, as sales
+// carry no due date
+type ReceivablesAgingResponse struct {
+	AsOf      time.Time       `json:"asOf"`
+	Totals    AgingBuckets    `json:"totals"`
+	Customers []CustomerAging `json:"customers"`
+}
@@ -25,20 +25,30 @@ type InventoryStats struct {
 	TotalStockQuantity  int       `json:"totalStockQuantity"`
 	TotalInventoryValue int       `json:"totalInventoryValue"`
 	GrossSalesRevenue   int       `json:"grossSalesRevenue"`
+	Discounts           int       `json:"discounts"`
+	NetSalesRevenue     int       `json:"netSalesRevenue"`
+	COGS                int       `json:"cogs"`
+	GrossProfit         int       `json:"grossProfit"`
+	GrossMargin         float64   `json:"grossMargin"`
 	NetProfit           int       `json:"netProfit"`
 	StartDate           time.Time `json:"startDate"`
 	EndDate             time.Time `json:"endDate"`
 }
 
 type StockData struct {
-	Month    string `json:"month" db:"month"`
-	Quantity int    `json:"quantity" db:"quantity"`
+	Period   time.Time `json:"period" db:"period"`
+	Month    string    `json:"month" db:"month"`
+	Quantity int       `json:"quantity" db:"quantity"`
+	Value    int       `json:"value" db:"value"`
 }
 
 type SalesData struct {
-	Month   string `json:"month" db:"month"`
-	Revenue int    `json:"revenue" db:"revenue"`
-	Profit  int    `json:"profit" db:"profit"`
+	Month     string  `json:"month" db:"month"`
+	Revenue   int     `json:"revenue" db:"revenue"`
+	Discounts int     `json:"discounts" db:"discounts"`
+	COGS      int     `json:"cogs" db:"cogs"`
+	Profit    int     `json:"profit" db:"profit"`
+	Margin    float64 `json:"margin" db:"-"`
 }
 
 type BestSellingProduct struct {
@@ -65,9 +75,11 @@ type StatsRequest struct {
 }
 
 type StockDataResponse struct {
-	StartDate time.Time   `json:"startDate"`
-	EndDate   time.Time   `json:"endDate"`
-	StockData []StockData `json:"stockData"`
+	StartDate   time.Time   `json:"startDate"`
+	EndDate     time.Time   `json:"endDate"`
+	Granularity string      `json:"granularity"`
+	WarehouseID *uuid.UUID  `json:"warehouseId,omitempty"`
+	StockData   []StockData `json:"stockData"`
 }
 
 type SalesDataResponse struct {
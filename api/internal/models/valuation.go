@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Inventory valuation methods
+const (
+	ValuationFIFO            = "fifo"
+	ValuationWeightedAverage = "weighted_average"
+	ValuationStandard        = "standard"
+)
+
+// Cost layer sources
+const (
+	CostLayerOpening    = "opening"
+	CostLayerReceipt    = "receipt"
+	CostLayerAdjustment = "adjustment"
+	CostLayerReturn     = "return"
+)
+
+// CostLayer is a batch of units that entered the inventory at one unit cost.
+// Outgoing stock consumes layers oldest first.
+type CostLayer struct {
+	ID                uuid.UUID  `db:"id" json:"id"`
+	InventoryID       uuid.UUID  `db:"inventory_id" json:"inventoryId"`
+	ProductID         uuid.UUID  `db:"product_id" json:"productId"`
+	Source            string     `db:"source" json:"source"`
+	DocumentID        *uuid.UUID `db:"document_id" json:"documentId"`
+	Quantity          int        `db:"quantity" json:"quantity"`
+	RemainingQuantity int        `db:"remaining_quantity" json:"remainingQuantity"`
+	UnitCost          int        `db:"unit_cost" json:"unitCost"`
+	StandardCost      int        `db:"standard_cost" json:"standardCost"`
+	ReceivedAt        time.Time  `db:"received_at" json:"receivedAt"`
+	CreatedAt         time.Time  `db:"created_at" json:"createdAt"`
+}
+
+// CostConsumption records units taken out of a layer and the cost they left
+// at. LayerID is nil when stock left without any layer to cover it. Releasing a
+// document adds reversal rows with negative quantities, so the history stays.
+type CostConsumption struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	InventoryID  uuid.UUID  `db:"inventory_id" json:"inventoryId"`
+	ProductID    uuid.UUID  `db:"product_id" json:"productId"`
+	LayerID      *uuid.UUID `db:"layer_id" json:"layerId"`
+	DocumentID   *uuid.UUID `db:"document_id" json:"documentId"`
+	Quantity     int        `db:"quantity" json:"quantity"`
+	TotalCost    int        `db:"total_cost" json:"totalCost"`
+	StandardCost int        `db:"standard_cost" json:"standardCost"`
+	ConsumedAt   time.Time  `db:"consumed_at" json:"consumedAt"`
+}
+
+type ProductValuation struct {
+	ProductID   uuid.UUID `json:"productId" db:"product_id"`
+	ProductName string    `json:"productName" db:"product_name"`
+	Quantity    int       `json:"quantity" db:"quantity"`
+	UnitCost    int       `json:"unitCost" db:"-"`
+	Value       int       `json:"value" db:"value"`
+}
+
+// DTOs
+
+type ValuationResponse struct {
+	AsOf          time.Time          `json:"asOf"`
+	Method        string             `json:"method"`
+	TotalQuantity int                `json:"totalQuantity"`
+	TotalValue    int                `json:"totalValue"`
+	Products      []ProductValuation `json:"products"`
+}
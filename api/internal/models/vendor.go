@@ -39,3 +39,35 @@ type VendorResponse struct {
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
+
+// ProductVendor links a product to a vendor it can be bought from. LastCost is
+// the unit cost paid on the last receipt from that vendor.
+type ProductVendor struct {
+	ProductID    uuid.UUID `db:"product_id" json:"productId"`
+	VendorID     uuid.UUID `db:"vendor_id" json:"vendorId"`
+	VendorName   string    `db:"vendor_name" json:"vendorName"`
+	InventoryID  uuid.UUID `db:"inventory_id" json:"inventoryId"`
+	VendorSKU    string    `db:"vendor_sku" json:"vendorSku"`
+	LeadTimeDays int       `db:"lead_time_days" json:"leadTimeDays"`
+	LastCost     *int      `db:"last_cost" json:"lastCost"`
+	IsPreferred  bool      `db:"is_preferred" json:"isPreferred"`
+	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+type ProductVendorRequest struct {
+	VendorSKU    string `json:"vendorSku" validate:"max=100"`
+	LeadTimeDays int    `json:"leadTimeDays" validate:"min=0,max=365"`
+	LastCost     *int   `json:"lastCost" validate:"omitempty,min=0"`
+	IsPreferred  bool   `json:"isPreferred"`
+}
+
+type ProductVendorResponse struct {
+	VendorID     uuid.UUID `json:"vendorId"`
+	VendorName   string    `json:"vendorName"`
+	VendorSKU    string    `json:"vendorSku"`
+	LeadTimeDays int       `json:"leadTimeDays"`
+	LastCost     *int      `json:"lastCost"`
+	IsPreferred  bool      `json:"isPreferred"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
@@ -84,3 +84,45 @@ type TransferItemRequest struct {
 	ProductID        uuid.UUID `json:"productId" validate:"required"`
 	TransferQuantity int       `json:"transferQuantity" validate:"required,min=1"`
 }
+
+// Stock movement models
+
+// Reasons a stock quantity can change
+const (
+	MovementReceipt    = "receipt"
+	MovementSale       = "sale"
+	MovementTransfer   = "transfer"
+	MovementAdjustment = "adjustment"
+	MovementReturn     = "return"
+)
+
+// StockMovement is one entry of the append-only stock ledger. Quantity is the
+// signed change of the product's stock in the warehouse.
+type StockMovement struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	InventoryID uuid.UUID  `db:"inventory_id" json:"inventoryId"`
+	ProductID   uuid.UUID  `db:"product_id" json:"productId"`
+	WarehouseID uuid.UUID  `db:"warehouse_id" json:"warehouseId"`
+	Quantity    int        `db:"quantity" json:"quantity"`
+	Reason      string     `db:"reason" json:"reason"`
+	DocumentID  *uuid.UUID `db:"document_id" json:"documentId"`
+	UserID      *uuid.UUID `db:"user_id" json:"userId"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+
+	// Recorded with the movement, so they outlive the product and warehouse
+	ProductName   string `db:"product_name" json:"productName"`
+	WarehouseName string `db:"warehouse_name" json:"warehouseName"`
+}
+
+type StockMovementResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	ProductID     *uuid.UUID `json:"productId"`
+	ProductName   string     `json:"productName"`
+	WarehouseID   *uuid.UUID `json:"warehouseId"`
+	WarehouseName string     `json:"warehouseName"`
+	Quantity      int        `json:"quantity"`
+	Reason        string     `json:"reason"`
+	DocumentID    *uuid.UUID `json:"documentId"`
+	UserID        *uuid.UUID `json:"userId"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/app/venside/internal/features/account/auth"
 	"github.com/app/venside/internal/features/application/customers"
+	"github.com/app/venside/internal/models"
 	"github.com/labstack/echo/v4"
 )
 
@@ -10,15 +11,19 @@ func CustomerRoutes(e *echo.Echo, controller customers.CustomerController, servi
 	api := e.Group("/api/inventories/:inventoryId")
 
 	// Auth protected routes (read-only)
-	api.Use(auth.AuthMiddleware(service))
+	api.Use(auth.AuthMiddleware(service), auth.InventoryMiddleware(service))
+	managers := auth.RoleMiddleware(models.RoleOwner, models.RoleManager)
+	staff := auth.RoleMiddleware(models.RoleOwner, models.RoleManager, models.RoleClerk)
 	readOnly := api.Group("")
 	readOnly.GET("/customers", controller.ListCustomers)
+	readOnly.GET("/customers/export", controller.ExportCustomers)
 	readOnly.GET("/customers/:customerId", controller.GetCustomer)
+	readOnly.GET("/customers/:customerId/statement", controller.GetCustomerStatement)
 
 	// Auth & CSRF protected routes (write operations)
 	customerGroup := api.Group("/customers")
 	customerGroup.Use(auth.CSRFMiddleware(service))
-	customerGroup.POST("", controller.CreateCustomer)
-	customerGroup.PUT("/:customerId", controller.UpdateCustomer)
-	customerGroup.DELETE("/:customerId", controller.DeleteCustomer)
+	customerGroup.POST("", controller.CreateCustomer, staff)
+	customerGroup.PUT("/:customerId", controller.UpdateCustomer, staff)
+	customerGroup.DELETE("/:customerId", controller.DeleteCustomer, managers)
 }
@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/app/venside/internal/features/account/auth"
 	"github.com/app/venside/internal/features/account/inventories"
+	"github.com/app/venside/internal/models"
 	"github.com/labstack/echo/v4"
 )
 
@@ -11,14 +12,27 @@ func InventoryRoutes(e *echo.Echo, controller inventories.InventoryController, s
 
 	// Auth protected routes (read-only)
 	api.Use(auth.AuthMiddleware(service))
+	inventoryAccess := auth.InventoryMiddleware(service)
+	managers := auth.RoleMiddleware(models.RoleOwner, models.RoleManager)
 	readOnly := api.Group("")
 	readOnly.GET("", controller.ListInventories)
-	readOnly.GET("/:id", controller.GetInventory)
+	readOnly.GET("/:inventoryId", controller.GetInventory, inventoryAccess)
+	readOnly.GET("/:inventoryId/members", controller.ListMembers, inventoryAccess)
+	readOnly.GET("/:inventoryId/numbering", controller.ListDocumentSequences, inventoryAccess)
+	readOnly.GET("/:inventoryId/templates", controller.ListDocumentTemplates, inventoryAccess)
 
 	// Auth & CSRF protected routes (write operations)
 	invGroup := api.Group("")
 	invGroup.Use(auth.CSRFMiddleware(service))
 	invGroup.POST("", controller.CreateInventory)
-	invGroup.PUT("/:id", controller.UpdateInventory)
-	invGroup.DELETE("/:id", controller.DeleteInventory)
+	invGroup.PUT("/:inventoryId", controller.UpdateInventory, inventoryAccess, managers)
+	invGroup.DELETE("/:inventoryId", controller.DeleteInventory, inventoryAccess, auth.RoleMiddleware(models.RoleOwner))
+
+	// Invited users are not active members yet, so accepting skips the inventory check
+	invGroup.POST("/:inventoryId/members", controller.InviteMember, inventoryAccess, managers)
+	invGroup.POST("/:inventoryId/members/accept", controller.AcceptInvitation)
+	invGroup.DELETE("/:inventoryId/members/:memberId", controller.RemoveMember, inventoryAccess, managers)
+
+	invGroup.PUT("/:inventoryId/numbering/:documentType", controller.UpdateDocumentSequence, inventoryAccess, managers)
+	invGroup.PUT("/:inventoryId/templates/:layout", controller.UpdateDocumentTemplate, inventoryAccess, managers)
 }
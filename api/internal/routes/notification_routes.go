@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/app/venside/internal/features/account/auth"
+	"github.com/app/venside/internal/features/account/notifications"
+	"github.com/labstack/echo/v4"
+)
+
+func NotificationRoutes(e *echo.Echo, controller notifications.NotificationController, service auth.AuthService) {
+	api := e.Group("/api/inventories/:inventoryId")
+
+	// Auth protected routes (read-only)
+	api.Use(auth.AuthMiddleware(service), auth.InventoryMiddleware(service))
+	readOnly := api.Group("")
+	readOnly.GET("/notifications", controller.ListNotifications)
+	readOnly.GET("/notifications/unread-count", controller.CountUnread)
+
+	// Auth & CSRF protected routes (write operations)
+	notificationGroup := api.Group("/notifications")
+	notificationGroup.Use(auth.CSRFMiddleware(service))
+	notificationGroup.PUT("/read", controller.MarkAllAsRead)
+	notificationGroup.PUT("/:notificationId/read", controller.MarkAsRead)
+}
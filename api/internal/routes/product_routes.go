@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/app/venside/internal/features/account/auth"
 	"github.com/app/venside/internal/features/application/products"
+	"github.com/app/venside/internal/models"
 	"github.com/labstack/echo/v4"
 )
 
@@ -10,21 +11,30 @@ func ProductRoutes(e *echo.Echo, controller products.ProductController, service
 	api := e.Group("/api/inventories/:inventoryId")
 
 	// Auth protected routes (read-only)
-	api.Use(auth.AuthMiddleware(service))
+	api.Use(auth.AuthMiddleware(service), auth.InventoryMiddleware(service))
+	managers := auth.RoleMiddleware(models.RoleOwner, models.RoleManager)
 	readOnly := api.Group("")
 	readOnly.GET("/products", controller.ListProducts)
+	readOnly.GET("/products/export", controller.ExportProducts)
+	readOnly.GET("/products/search", controller.SearchProducts)
+	readOnly.GET("/products/low-stock", controller.ListLowStockProducts)
 	readOnly.GET("/products/:productId", controller.GetProduct)
+	readOnly.GET("/products/:productId/movements", controller.ListProductMovements)
+	readOnly.GET("/products/:productId/vendors", controller.ListProductVendors)
 	readOnly.GET("/categories", controller.ListProductCategories)
 
 	// Auth & CSRF protected routes (write operations)
 	prdGroup := api.Group("/products")
 	prdGroup.Use(auth.CSRFMiddleware(service))
-	prdGroup.POST("", controller.CreateProduct)
-	prdGroup.PUT("/:productId", controller.UpdateProduct)
-	prdGroup.DELETE("/:productId", controller.DeleteProduct)
-	prdGroup.DELETE("", controller.DeleteMultipleProducts)
+	prdGroup.POST("", controller.CreateProduct, managers)
+	prdGroup.POST("/import", controller.ImportProducts, managers)
+	prdGroup.PUT("/:productId", controller.UpdateProduct, managers)
+	prdGroup.DELETE("/:productId", controller.DeleteProduct, managers)
+	prdGroup.DELETE("", controller.DeleteMultipleProducts, managers)
+	prdGroup.PUT("/:productId/vendors/:vendorId", controller.SetProductVendor, managers)
+	prdGroup.DELETE("/:productId/vendors/:vendorId", controller.DeleteProductVendor, managers)
 
 	imgGroup := api.Group("/images")
 	imgGroup.Use(auth.CSRFMiddleware(service))
-	imgGroup.PUT("/:imageId/primary", controller.SetPrimaryImage)
+	imgGroup.PUT("/:imageId/primary", controller.SetPrimaryImage, managers)
 }
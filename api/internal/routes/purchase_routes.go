@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/app/venside/internal/features/account/auth"
 	"github.com/app/venside/internal/features/application/purchases"
+	"github.com/app/venside/internal/models"
 	"github.com/labstack/echo/v4"
 )
 
@@ -10,14 +11,29 @@ func PurchaseRoutes(e *echo.Echo, controller purchases.PurchaseController, servi
 	api := e.Group("/api/inventories/:inventoryId")
 
 	// Auth protected routes (read-only)
-	api.Use(auth.AuthMiddleware(service))
+	api.Use(auth.AuthMiddleware(service), auth.InventoryMiddleware(service))
+	managers := auth.RoleMiddleware(models.RoleOwner, models.RoleManager)
+	staff := auth.RoleMiddleware(models.RoleOwner, models.RoleManager, models.RoleClerk)
 	readOnly := api.Group("")
 	readOnly.GET("/purchases", controller.ListPurchases)
+	readOnly.GET("/purchases/export", controller.ExportPurchases)
+	readOnly.GET("/purchases/returns", controller.ListReturns)
+	readOnly.GET("/purchases/returns/:returnId", controller.GetPurchaseReturn)
 	readOnly.GET("/purchases/:purchaseId", controller.GetPurchase)
+	readOnly.GET("/purchases/:purchaseId/payments", controller.ListPurchasePayments)
+	readOnly.GET("/purchases/:purchaseId/returns", controller.ListPurchaseReturns)
+	readOnly.GET("/purchases/:purchaseId/revisions", controller.ListPurchaseRevisions)
+	readOnly.GET("/purchases/:purchaseId/document.pdf", controller.GetPurchaseDocument)
 
 	// Auth & CSRF protected routes (write operations)
 	purchasesGroup := api.Group("/purchases")
 	// purchasesGroup.Use(auth.CSRFMiddleware(service))
-	purchasesGroup.POST("", controller.CreatePurchase)
-	purchasesGroup.DELETE("/:purchaseId", controller.DeletePurchase)
+	purchasesGroup.POST("", controller.CreatePurchase, managers)
+	purchasesGroup.POST("/generate-drafts", controller.GenerateDraftPurchases, managers)
+	purchasesGroup.PUT("/:purchaseId", controller.UpdatePurchase, managers)
+	purchasesGroup.DELETE("/:purchaseId", controller.DeletePurchase, managers)
+	purchasesGroup.POST("/:purchaseId/receive", controller.ReceivePurchase, staff)
+	purchasesGroup.POST("/:purchaseId/payments", controller.CreatePurchasePayment, managers)
+	purchasesGroup.POST("/:purchaseId/returns", controller.CreatePurchaseReturn, managers)
+	purchasesGroup.POST("/:purchaseId/email", controller.EmailPurchase, managers)
 }
@@ -4,6 +4,7 @@ package routes
 import (
 	"github.com/app/venside/internal/features/account/auth"
 	"github.com/app/venside/internal/features/application/sales"
+	"github.com/app/venside/internal/models"
 	"github.com/labstack/echo/v4"
 )
 
@@ -11,14 +12,26 @@ func SaleRoutes(e *echo.Echo, controller sales.SaleController, service auth.Auth
 	api := e.Group("/api/inventories/:inventoryId")
 
 	// Auth protected routes (read-only)
-	api.Use(auth.AuthMiddleware(service))
+	api.Use(auth.AuthMiddleware(service), auth.InventoryMiddleware(service))
+	managers := auth.RoleMiddleware(models.RoleOwner, models.RoleManager)
+	staff := auth.RoleMiddleware(models.RoleOwner, models.RoleManager, models.RoleClerk)
 	readOnly := api.Group("")
 	readOnly.GET("/sales", controller.ListSales)
+	readOnly.GET("/sales/export", controller.ExportSales)
+	readOnly.GET("/sales/returns", controller.ListReturns)
 	readOnly.GET("/sales/:saleId", controller.GetSale)
+	readOnly.GET("/sales/:saleId/payments", controller.ListSalePayments)
+	readOnly.GET("/sales/:saleId/returns", controller.ListSaleReturns)
+	readOnly.GET("/sales/:saleId/revisions", controller.ListSaleRevisions)
+	readOnly.GET("/sales/:saleId/invoice.pdf", controller.GetSaleInvoice)
+	readOnly.GET("/sales/:saleId/receipt.pdf", controller.GetSaleReceipt)
 
 	// Auth & CSRF protected routes (write operations)
 	salesGroup := api.Group("/sales")
 	salesGroup.Use(auth.CSRFMiddleware(service))
-	salesGroup.POST("", controller.CreateSale)
-	salesGroup.DELETE("/:saleId", controller.DeleteSale)
+	salesGroup.POST("", controller.CreateSale, staff)
+	salesGroup.PUT("/:saleId", controller.UpdateSale, staff)
+	salesGroup.DELETE("/:saleId", controller.DeleteSale, managers)
+	salesGroup.POST("/:saleId/payments", controller.CreateSalePayment, staff)
+	salesGroup.POST("/:saleId/returns", controller.CreateSaleReturn, staff)
 }
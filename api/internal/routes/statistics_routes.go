@@ -9,12 +9,15 @@ import (
 func StatisticsRoutes(e *echo.Echo, controller statistics.StatsController, service auth.AuthService) {
 	api := e.Group("/api/inventories/:inventoryId/statistics")
 
-	// Auth protected routes
-	api.Use(auth.AuthMiddleware(service))
+	// Auth & inventory access protected routes
+	api.Use(auth.AuthMiddleware(service), auth.InventoryMiddleware(service))
 
 	api.GET("", controller.GetInventoryStats)
 	api.GET("/stock-trend", controller.GetStockTrend)
 	api.GET("/sales-trend", controller.GetSalesTrend)
 	api.GET("/best-sellers", controller.GetBestSellingProducts)
 	api.GET("/recent-sales", controller.GetRecentSales)
+	api.GET("/valuation", controller.GetValuation)
+	api.GET("/payables-aging", controller.GetPayablesAging)
+	api.GET("/receivables-aging", controller.GetReceivablesAging)
 }
@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/app/venside/internal/features/account/auth"
 	"github.com/app/venside/internal/features/application/vendors"
+	"github.com/app/venside/internal/models"
 	"github.com/labstack/echo/v4"
 )
 
@@ -10,15 +11,18 @@ func VendorRoutes(e *echo.Echo, controller vendors.VendorController, service aut
 	api := e.Group("/api/inventories/:inventoryId")
 
 	// Auth protected routes (read-only)
-	api.Use(auth.AuthMiddleware(service))
+	api.Use(auth.AuthMiddleware(service), auth.InventoryMiddleware(service))
+	managers := auth.RoleMiddleware(models.RoleOwner, models.RoleManager)
 	readOnly := api.Group("")
 	readOnly.GET("/vendors", controller.ListVendors)
+	readOnly.GET("/vendors/export", controller.ExportVendors)
 	readOnly.GET("/vendors/:vendorId", controller.GetVendor)
+	readOnly.GET("/vendors/:vendorId/statement", controller.GetVendorStatement)
 
 	// Auth & CSRF protected routes (write operations)
 	vendorGroup := api.Group("/vendors")
 	vendorGroup.Use(auth.CSRFMiddleware(service))
-	vendorGroup.POST("", controller.CreateVendor)
-	vendorGroup.PUT("/:vendorId", controller.UpdateVendor)
-	vendorGroup.DELETE("/:vendorId", controller.DeleteVendor)
+	vendorGroup.POST("", controller.CreateVendor, managers)
+	vendorGroup.PUT("/:vendorId", controller.UpdateVendor, managers)
+	vendorGroup.DELETE("/:vendorId", controller.DeleteVendor, managers)
 }
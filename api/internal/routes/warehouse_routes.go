@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/app/venside/internal/features/account/auth"
 	"github.com/app/venside/internal/features/application/warehouses"
+	"github.com/app/venside/internal/models"
 	"github.com/labstack/echo/v4"
 )
 
@@ -10,21 +11,23 @@ func WarehouseRoutes(e *echo.Echo, controller warehouses.WarehouseController, se
 	api := e.Group("/api/inventories/:inventoryId/warehouses")
 
 	// Auth protected routes (read-only)
-	api.Use(auth.AuthMiddleware(service))
+	api.Use(auth.AuthMiddleware(service), auth.InventoryMiddleware(service))
+	managers := auth.RoleMiddleware(models.RoleOwner, models.RoleManager)
 	readOnly := api.Group("")
 	readOnly.GET("", controller.ListWarehouses)
 	readOnly.GET("/:warehouseId", controller.GetWarehouse)
+	readOnly.GET("/:warehouseId/movements", controller.ListWarehouseMovements)
 
 	// Auth & CSRF protected routes (write operations)
 	whGroup := api.Group("")
 	whGroup.Use(auth.CSRFMiddleware(service))
-	whGroup.POST("", controller.CreateWarehouse)
-	whGroup.PUT("/:warehouseId", controller.UpdateWarehouse)
-	whGroup.DELETE("/:warehouseId", controller.DeleteWarehouse)
+	whGroup.POST("", controller.CreateWarehouse, managers)
+	whGroup.PUT("/:warehouseId", controller.UpdateWarehouse, managers)
+	whGroup.DELETE("/:warehouseId", controller.DeleteWarehouse, managers)
 
-	whGroup.POST("/:warehouseId/products", controller.AddProductsToWarehouse)
-	whGroup.POST("/transfer", controller.TransferWarehouseStock)
-	whGroup.PUT("/:warehouseId/products/:productId/stock", controller.UpdateStockQuantity)
-	whGroup.DELETE("/:warehouseId/products/:productId", controller.RemoveProductFromWarehouse)
+	whGroup.POST("/:warehouseId/products", controller.AddProductsToWarehouse, managers)
+	whGroup.POST("/transfer", controller.TransferWarehouseStock, managers)
+	whGroup.PUT("/:warehouseId/products/:productId/stock", controller.UpdateStockQuantity, managers)
+	whGroup.DELETE("/:warehouseId/products/:productId", controller.RemoveProductFromWarehouse, managers)
 
 }
@@ -0,0 +1,70 @@
+package testdb
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Inventory is a seeded inventory with its owner and main warehouse
+type Inventory struct {
+	UserID      uuid.UUID
+	InventoryID uuid.UUID
+	WarehouseID uuid.UUID
+}
+
+// SeedInventory creates a user owning an inventory with a main warehouse.
+// valuationMethod may be empty for the schema default.
+func SeedInventory(tb testing.TB, db *sqlx.DB, valuationMethod string) Inventory {
+	tb.Helper()
+
+	seed := Inventory{
+		UserID:      uuid.New(),
+		InventoryID: uuid.New(),
+		WarehouseID: uuid.New(),
+	}
+
+	Exec(tb, db, `INSERT INTO users (id, username, email, password) VALUES ($1, $2, $3, 'x')`,
+		seed.UserID, "user-"+seed.UserID.String(), seed.UserID.String()+"@example.com")
+	Exec(tb, db, `INSERT INTO inventories (id, name, user_id) VALUES ($1, 'Test inventory', $2)`,
+		seed.InventoryID, seed.UserID)
+	if valuationMethod != "" {
+		Exec(tb, db, `UPDATE inventories SET valuation_method = $1 WHERE id = $2`, valuationMethod, seed.InventoryID)
+	}
+	Exec(tb, db, `INSERT INTO warehouses (id, name, storage_type, is_main, inventory_id) VALUES ($1, 'Main', 'general', true, $2)`,
+		seed.WarehouseID, seed.InventoryID)
+
+	return seed
+}
+
+// SeedProduct creates a product without stock
+func SeedProduct(tb testing.TB, db *sqlx.DB, inventoryID uuid.UUID, name string, costPrice, sellingPrice int) uuid.UUID {
+	tb.Helper()
+
+	id := uuid.New()
+	Exec(tb, db, `INSERT INTO products (id, name, cost_price, selling_price, inventory_id) VALUES ($1, $2, $3, $4, $5)`,
+		id, name, costPrice, sellingPrice, inventoryID)
+
+	return id
+}
+
+// SeedVendor creates a vendor
+func SeedVendor(tb testing.TB, db *sqlx.DB, inventoryID uuid.UUID, companyName string) uuid.UUID {
+	tb.Helper()
+
+	id := uuid.New()
+	Exec(tb, db, `INSERT INTO vendors (id, company_name, inventory_id) VALUES ($1, $2, $3)`,
+		id, companyName, inventoryID)
+
+	return id
+}
+
+// Exec runs a fixture statement and fails the test if it errors
+func Exec(tb testing.TB, db *sqlx.DB, query string, args ...interface{}) {
+	tb.Helper()
+
+	if _, err := db.Exec(query, args...); err != nil {
+		tb.Fatalf("seeding: %v\n%s", err, query)
+	}
+}
@@ -0,0 +1,367 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/app/venside/pkg/errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+const (
+	ExportCSV  = "csv"
+	ExportXLSX = "xlsx"
+	ExportJSON = "json"
+)
+
+// exportFlushEvery is how many rows are buffered before they are pushed to the client
+const exportFlushEvery = 200
+
+// ExportColumn is one column of an export. Value reads the cell from a row;
+// pointers are followed and nil becomes an empty cell. Money columns hold
+// amounts in minor units and are written in the inventory's locale in CSV and
+// XLSX, and as plain numbers in JSON. Key names the field in JSON exports.
+type ExportColumn[T any] struct {
+	Header string
+	Key    string
+	Money  bool
+	Value  func(*T) any
+}
+
+// ParseExportFormat reads the ?format= parameter of an export, defaulting to CSV
+func ParseExportFormat(ctx echo.Context) (string, error) {
+	format := strings.ToLower(ctx.QueryParam("format"))
+	switch format {
+	case "":
+		return ExportCSV, nil
+	case ExportCSV, ExportXLSX, ExportJSON:
+		return format, nil
+	default:
+		return "", errors.ValidationError("format must be csv, xlsx or json")
+	}
+}
+
+// FormatMoney writes an amount in minor units the way the locale does, with
+// the currency's symbol, e.g. 123456 USD in en-US as "$ 1,234.56". The number
+// of minor units comes from the currency, so 1234 JPY is "¥ 1,234".
+func FormatMoney(amount int, code, locale string) string {
+	return formatMoney(amount, code, locale, currency.Symbol)
+}
+
+func formatMoney(amount int, code, locale string, format currency.Formatter) string {
+	printer := message.NewPrinter(language.Make(locale))
+
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return printer.Sprintf("%s %.2f", code, float64(amount)/100)
+	}
+
+	scale, _ := currency.Standard.Rounding(unit)
+	return printer.Sprint(format(unit.Amount(float64(amount) / math.Pow10(scale))))
+}
+
+// EachRow scans the rows of a query one by one into write, closing them once
+// done. Errors returned by write are passed through as they are.
+func EachRow[T any](rows *sqlx.Rows, write func(*T) error) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		var row T
+		if err := rows.StructScan(&row); err != nil {
+			return errors.DatabaseError(err, "Error reading exported row")
+		}
+		if err := write(&row); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.DatabaseError(err, "Error reading exported rows")
+	}
+	return nil
+}
+
+// Exporter streams rows to the response as they are read from the database,
+// so exports never hold a whole result set in memory
+type Exporter[T any] struct {
+	format   string
+	columns  []ExportColumn[T]
+	code     string
+	locale   string
+	response *echo.Response
+	rows     int
+
+	filename string
+	started  bool
+
+	csv   *csv.Writer
+	zip   *zip.Writer
+	sheet io.Writer
+}
+
+// NewExporter prepares an export. The file name is given without extension.
+// Nothing is sent until the first row is written, so errors raised before that,
+// such as an invalid filter, still reach the client as a normal error response.
+func NewExporter[T any](ctx echo.Context, format, filename string, columns []ExportColumn[T], currencyCode, locale string) *Exporter[T] {
+	return &Exporter[T]{
+		format:   format,
+		columns:  columns,
+		code:     currencyCode,
+		locale:   locale,
+		response: ctx.Response(),
+		filename: filename,
+	}
+}
+
+// start sends the download headers and the header row
+func (e *Exporter[T]) start() error {
+	e.started = true
+
+	contentTypes := map[string]string{
+		ExportCSV:  "text/csv; charset=utf-8",
+		ExportXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		ExportJSON: echo.MIMEApplicationJSONCharsetUTF8,
+	}
+
+	header := e.response.Header()
+	header.Set(echo.HeaderContentType, contentTypes[e.format])
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, e.filename, e.format))
+	e.response.WriteHeader(http.StatusOK)
+
+	titles := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		titles[i] = column.Header
+	}
+
+	switch e.format {
+	case ExportCSV:
+		e.csv = csv.NewWriter(e.response)
+		return e.csv.Write(titles)
+	case ExportXLSX:
+		return e.startWorkbook(titles)
+	default:
+		_, err := io.WriteString(e.response, "[")
+		return err
+	}
+}
+
+// Write appends one row to the export
+func (e *Exporter[T]) Write(row *T) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch e.format {
+	case ExportCSV:
+		cells := make([]string, len(e.columns))
+		for i, column := range e.columns {
+			cells[i] = e.text(column, row)
+		}
+		err = e.csv.Write(cells)
+	case ExportXLSX:
+		err = e.writeSheetRow(e.rows+2, row)
+	case ExportJSON:
+		err = e.writeObject(row)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushEvery == 0 {
+		e.flush()
+	}
+	return nil
+}
+
+// Close finishes the file. It must be called once every row is written; an
+// export without rows still gets its header row.
+func (e *Exporter[T]) Close() error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch e.format {
+	case ExportCSV:
+		e.csv.Flush()
+		err = e.csv.Error()
+	case ExportXLSX:
+		if _, err = io.WriteString(e.sheet, `</sheetData></worksheet>`); err == nil {
+			err = e.zip.Close()
+		}
+	case ExportJSON:
+		_, err = io.WriteString(e.response, "]")
+	}
+
+	e.response.Flush()
+	return err
+}
+
+func (e *Exporter[T]) flush() {
+	if e.csv != nil {
+		e.csv.Flush()
+	}
+	e.response.Flush()
+}
+
+// value resolves the cell of a column, following pointers
+func (e *Exporter[T]) value(column ExportColumn[T], row *T) any {
+	value := column.Value(row)
+	if value == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		return rv.Elem().Interface()
+	}
+	return value
+}
+
+// text renders a cell for CSV and XLSX
+func (e *Exporter[T]) text(column ExportColumn[T], row *T) string {
+	value := e.value(column, row)
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case int:
+		if column.Money {
+			return FormatMoney(v, e.code, e.locale)
+		}
+		return strconv.Itoa(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04")
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (e *Exporter[T]) writeObject(row *T) error {
+	var sb strings.Builder
+	if e.rows > 0 {
+		sb.WriteString(",")
+	}
+	sb.WriteString("{")
+
+	for i, column := range e.columns {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+
+		key, err := json.Marshal(column.Key)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(e.value(column, row))
+		if err != nil {
+			return err
+		}
+
+		sb.Write(key)
+		sb.WriteString(":")
+		sb.Write(value)
+	}
+
+	sb.WriteString("}")
+	_, err := io.WriteString(e.response, sb.String())
+	return err
+}
+
+// An XLSX workbook is a zip of XML parts. The sheet is the last part, so its
+// rows can be streamed without knowing how many there will be.
+var xlsxStaticParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+func (e *Exporter[T]) startWorkbook(titles []string) error {
+	e.zip = zip.NewWriter(e.response)
+
+	for _, part := range xlsxStaticParts {
+		w, err := e.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, part.body); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := e.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	e.sheet = sheet
+
+	_, err = io.WriteString(e.sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<row r="1">`)
+	for _, title := range titles {
+		writeInlineCell(&sb, title)
+	}
+	sb.WriteString(`</row>`)
+
+	_, err = io.WriteString(e.sheet, sb.String())
+	return err
+}
+
+func (e *Exporter[T]) writeSheetRow(number int, row *T) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<row r="%d">`, number)
+
+	for _, column := range e.columns {
+		// Plain numbers stay numeric so they can be summed in the sheet
+		if v, ok := e.value(column, row).(int); ok && !column.Money {
+			fmt.Fprintf(&sb, `<c><v>%d</v></c>`, v)
+			continue
+		}
+		writeInlineCell(&sb, e.text(column, row))
+	}
+
+	sb.WriteString(`</row>`)
+	_, err := io.WriteString(e.sheet, sb.String())
+	return err
+}
+
+func writeInlineCell(sb *strings.Builder, text string) {
+	sb.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+	xml.EscapeText(sb, []byte(text))
+	sb.WriteString(`</t></is></c>`)
+}
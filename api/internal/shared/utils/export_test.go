@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		amount       int
+		code, locale string
+		want         string
+	}{
+		{amount: 123456, code: "USD", locale: "en-US", want: "$ 1,234.56"},
+		{amount: 1234, code: "JPY", locale: "en-US", want: "¥ 1,234"},
+		{amount: 1234, code: "KWD", locale: "en-US", want: "KWD 1.234"},
+		{amount: 5, code: "EUR", locale: "en-US", want: "€ 0.05"},
+		{amount: 123456, code: "XYZ", locale: "en-US", want: "XYZ 1,234.56"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMoney(tt.amount, tt.code, tt.locale); got != tt.want {
+			t.Errorf("FormatMoney(%d, %q, %q) = %q, want %q", tt.amount, tt.code, tt.locale, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/app/venside/pkg/errors"
+)
+
+// DefaultNumberTemplate is used for document types without a configured template
+const DefaultNumberTemplate = "{PREFIX}-{YYMMDD}-{SEQ:4}"
+
+// numberToken matches template tokens such as {PREFIX}, {YYMMDD} or {SEQ:4}
+var numberToken = regexp.MustCompile(`\{([A-Z]+)(?::(\d+))?\}`)
+
+// ValidateNumberTemplate checks that a template only uses known tokens and holds
+// exactly one {SEQ}, which is what makes the numbers unique.
+func ValidateNumberTemplate(template string) error {
+	seqTokens := 0
+
+	for _, match := range numberToken.FindAllStringSubmatch(template, -1) {
+		switch match[1] {
+		case "PREFIX", "YYYY", "YY", "MM", "DD", "YYMMDD":
+			if match[2] != "" {
+				return errors.ValidationError(fmt.Sprintf("Token {%s} does not take a width", match[1]))
+			}
+		case "SEQ":
+			seqTokens++
+			if match[2] != "" {
+				if width, _ := strconv.Atoi(match[2]); width < 1 || width > 9 {
+					return errors.ValidationError("{SEQ} width must be between 1 and 9")
+				}
+			}
+		default:
+			return errors.ValidationError(fmt.Sprintf("Unknown token {%s}", match[1]))
+		}
+	}
+
+	if seqTokens != 1 {
+		return errors.ValidationError("Template must contain exactly one {SEQ} token")
+	}
+
+	return nil
+}
+
+// FormatDocumentNumber renders a number template for the given date and sequence value
+func FormatDocumentNumber(template, prefix string, date time.Time, seq int) string {
+	return numberToken.ReplaceAllStringFunc(template, func(token string) string {
+		match := numberToken.FindStringSubmatch(token)
+
+		switch match[1] {
+		case "PREFIX":
+			return strings.TrimSpace(prefix)
+		case "YYYY":
+			return date.Format("2006")
+		case "YY":
+			return date.Format("06")
+		case "MM":
+			return date.Format("01")
+		case "DD":
+			return date.Format("02")
+		case "YYMMDD":
+			return date.Format("060102")
+		case "SEQ":
+			width := 1
+			if match[2] != "" {
+				width, _ = strconv.Atoi(match[2])
+			}
+			return fmt.Sprintf("%0*d", width, seq)
+		}
+
+		return token
+	})
+}
@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/app/venside/pkg/cache"
+	"github.com/app/venside/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ListQuery is the common query contract of list endpoints:
+// ?limit=&cursor=|page=&sort=&order=&from=&to= plus per-resource filters.
+type ListQuery struct {
+	Limit   int
+	Offset  int
+	Sort    string // SQL column, resolved from the resource's sortable fields
+	Order   string // ASC or DESC
+	From    *time.Time
+	To      *time.Time
+	Filters map[string]string
+}
+
+// Page is the response envelope of list endpoints
+type Page[T any] struct {
+	Data       []T     `json:"data"`
+	Total      int     `json:"total"`
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+// ParseListQuery reads the list query parameters. Sorting is restricted to the
+// given field->column map and only the named filters are picked up.
+func ParseListQuery(ctx echo.Context, sortable map[string]string, defaultSort string, filters ...string) (ListQuery, error) {
+	query := ListQuery{
+		Limit:   DefaultPageLimit,
+		Sort:    sortable[defaultSort],
+		Order:   "DESC",
+		Filters: map[string]string{},
+	}
+
+	if limit := ctx.QueryParam("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MaxPageLimit {
+			return query, errors.ValidationError(fmt.Sprintf("limit must be between 1 and %d", MaxPageLimit))
+		}
+		query.Limit = value
+	}
+
+	// A cursor wins over a page number
+	if cursor := ctx.QueryParam("cursor"); cursor != "" {
+		offset, err := decodeCursor(cursor)
+		if err != nil {
+			return query, errors.ValidationError("Invalid cursor")
+		}
+		query.Offset = offset
+	} else if page := ctx.QueryParam("page"); page != "" {
+		value, err := strconv.Atoi(page)
+		if err != nil || value < 1 {
+			return query, errors.ValidationError("page must be a positive number")
+		}
+		query.Offset = (value - 1) * query.Limit
+	}
+
+	if field := ctx.QueryParam("sort"); field != "" {
+		column, ok := sortable[field]
+		if !ok {
+			return query, errors.ValidationError(fmt.Sprintf("Cannot sort by \"%s\"", field))
+		}
+		query.Sort = column
+	}
+
+	switch strings.ToLower(ctx.QueryParam("order")) {
+	case "":
+	case "asc":
+		query.Order = "ASC"
+	case "desc":
+		query.Order = "DESC"
+	default:
+		return query, errors.ValidationError("order must be asc or desc")
+	}
+
+	from, to, err := ParseDateRange(ctx)
+	if err != nil {
+		return query, err
+	}
+	query.From, query.To = from, to
+
+	for _, name := range filters {
+		if value := strings.TrimSpace(ctx.QueryParam(name)); value != "" {
+			query.Filters[name] = value
+		}
+	}
+
+	return query, nil
+}
+
+// UUIDFilter returns the named filter parsed as a UUID, or nil when it is not set
+func (q ListQuery) UUIDFilter(name string) (*uuid.UUID, error) {
+	value, ok := q.Filters[name]
+	if !ok {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, errors.ValidationError(fmt.Sprintf("Invalid %s", name))
+	}
+
+	return &id, nil
+}
+
+// OrderAndLimit renders the ORDER BY / LIMIT / OFFSET tail of a list query. The
+// tiebreak column keeps page boundaries stable when sort values repeat.
+func (q ListQuery) OrderAndLimit(tiebreak string) string {
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s LIMIT %d OFFSET %d",
+		q.Sort, q.Order, tiebreak, q.Order, q.Limit, q.Offset)
+}
+
+// OrderBy renders the ORDER BY tail alone, for exports that read every row
+func (q ListQuery) OrderBy(tiebreak string) string {
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s", q.Sort, q.Order, tiebreak, q.Order)
+}
+
+// CacheKey is a stable representation of the query, used to cache single pages
+func (q ListQuery) CacheKey() string {
+	parts := []string{
+		q.Sort, q.Order, strconv.Itoa(q.Limit), strconv.Itoa(q.Offset),
+		formatTime(q.From), formatTime(q.To),
+	}
+
+	names := make([]string, 0, len(q.Filters))
+	for name := range q.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		parts = append(parts, name+"="+q.Filters[name])
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// Conditions collects the WHERE clause of a list query. Conditions are written
+// with ? placeholders; rebind the final query for the driver.
+type Conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+func NewConditions(clause string, args ...interface{}) *Conditions {
+	c := &Conditions{}
+	c.Add(clause, args...)
+	return c
+}
+
+func (c *Conditions) Add(clause string, args ...interface{}) {
+	c.clauses = append(c.clauses, clause)
+	c.args = append(c.args, args...)
+}
+
+func (c *Conditions) Where() string {
+	return " WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+func (c *Conditions) Args() []interface{} {
+	return c.args
+}
+
+func NewPage[T any](data []T, total int, q ListQuery) Page[T] {
+	page := Page[T]{Data: data, Total: total, Limit: q.Limit}
+
+	if next := q.Offset + len(data); len(data) > 0 && next < total {
+		cursor := encodeCursor(next)
+		page.NextCursor = &cursor
+	}
+
+	return page
+}
+
+// MapPage converts the items of a page while keeping its envelope
+func MapPage[T, R any](page Page[T], convert func(*T) R) Page[R] {
+	mapped := Page[R]{
+		Data:       make([]R, len(page.Data)),
+		Total:      page.Total,
+		Limit:      page.Limit,
+		NextCursor: page.NextCursor,
+	}
+
+	for i := range page.Data {
+		mapped.Data[i] = convert(&page.Data[i])
+	}
+
+	return mapped
+}
+
+// PageCacheKey returns the cache key for one page of a list. The list key itself
+// stores a generation stamp, so deleting it, as the invalidate helpers do,
+// drops every cached page of the list at once.
+func PageCacheKey(c cache.RedisService, listKey string, q ListQuery, ttl time.Duration) string {
+	var generation int64
+	if err := c.Get(listKey, &generation); err != nil {
+		generation = time.Now().UnixNano()
+		c.Set(listKey, generation, ttl)
+	}
+
+	return fmt.Sprintf("%s:%d:%s", listKey, generation, q.CacheKey())
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	value, ok := strings.CutPrefix(string(raw), "o:")
+	if !ok {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+
+	return offset, nil
+}
+
+// ParseDateRange reads the optional from and to query parameters. A bare date
+// in to covers that whole day.
+func ParseDateRange(ctx echo.Context) (*time.Time, *time.Time, error) {
+	from, err := parseDateParam(ctx.QueryParam("from"), false)
+	if err != nil {
+		return nil, nil, errors.ValidationError("Invalid from date")
+	}
+	to, err := parseDateParam(ctx.QueryParam("to"), true)
+	if err != nil {
+		return nil, nil, errors.ValidationError("Invalid to date")
+	}
+
+	return from, to, nil
+}
+
+// parseDateParam accepts RFC3339 timestamps or plain dates. A plain "to" date
+// covers that whole day.
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return &t, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// A small PDF writer for printed documents. It only draws text in the standard
+// Type 1 fonts, lines and filled boxes, which every PDF reader ships with, so
+// no font files need to be embedded. Positions are in millimetres from the top
+// left corner of the page; y is the baseline of text.
+
+type PDFFont int
+
+const (
+	FontRegular PDFFont = iota
+	FontBold
+	FontMono
+	FontMonoBold
+)
+
+var pdfFontNames = []string{"Helvetica", "Helvetica-Bold", "Courier", "Courier-Bold"}
+
+const pointsPerMM = 72 / 25.4
+
+type pdfOp struct {
+	kind         byte // t: text, l: line, r: filled box
+	font         PDFFont
+	size         float64
+	x, y, x2, y2 float64
+	width, shade float64
+	text         []byte
+}
+
+type pdfPage struct {
+	width, height float64
+	ops           []pdfOp
+}
+
+type PDF struct {
+	pages []*pdfPage
+	page  *pdfPage
+}
+
+func NewPDF() *PDF {
+	return &PDF{}
+}
+
+// AddPage starts a new page of the given size and makes it current
+func (p *PDF) AddPage(width, height float64) {
+	p.page = &pdfPage{width: width, height: height}
+	p.pages = append(p.pages, p.page)
+}
+
+// SetPageHeight resizes the current page, for documents such as receipts whose
+// length is only known once everything is drawn
+func (p *PDF) SetPageHeight(height float64) {
+	p.page.height = height
+}
+
+func (p *PDF) PageCount() int {
+	return len(p.pages)
+}
+
+// SetPage makes the n-th page, counted from 1, current again
+func (p *PDF) SetPage(n int) {
+	p.page = p.pages[n-1]
+}
+
+func (p *PDF) Text(x, y float64, font PDFFont, size float64, text string) {
+	p.page.ops = append(p.page.ops, pdfOp{kind: 't', font: font, size: size, x: x, y: y, text: encodePDFText(text)})
+}
+
+// TextRight draws text ending at x
+func (p *PDF) TextRight(x, y float64, font PDFFont, size float64, text string) {
+	p.Text(x-TextWidth(font, size, text), y, font, size, text)
+}
+
+// TextCenter draws text centred on x
+func (p *PDF) TextCenter(x, y float64, font PDFFont, size float64, text string) {
+	p.Text(x-TextWidth(font, size, text)/2, y, font, size, text)
+}
+
+func (p *PDF) Line(x1, y1, x2, y2, width float64) {
+	p.page.ops = append(p.page.ops, pdfOp{kind: 'l', x: x1, y: y1, x2: x2, y2: y2, width: width})
+}
+
+// FillRect fills a box with a grey shade, 0 being black and 1 white
+func (p *PDF) FillRect(x, y, width, height, shade float64) {
+	p.page.ops = append(p.page.ops, pdfOp{kind: 'r', x: x, y: y, x2: width, y2: height, shade: shade})
+}
+
+// Bytes renders the document
+func (p *PDF) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: catalog, 2: page tree, 3-6: fonts, then a page and its content per page
+	fontRefs := make([]string, len(pdfFontNames))
+	for i := range pdfFontNames {
+		fontRefs[i] = fmt.Sprintf("/F%d %d 0 R", i+1, i+3)
+	}
+
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 7+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	for _, name := range pdfFontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+
+	for i, page := range p.pages {
+		content := page.content()
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			page.width*pointsPerMM, page.height*pointsPerMM, strings.Join(fontRefs, " "), 8+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// content turns the page's operations into a content stream. PDF measures in
+// points from the bottom left, so coordinates are flipped here.
+func (page *pdfPage) content() []byte {
+	var sb bytes.Buffer
+	for _, op := range page.ops {
+		switch op.kind {
+		case 't':
+			fmt.Fprintf(&sb, "BT /F%d %.2f Tf %.2f %.2f Td (", op.font+1, op.size, op.x*pointsPerMM, (page.height-op.y)*pointsPerMM)
+			writePDFString(&sb, op.text)
+			sb.WriteString(") Tj ET\n")
+		case 'l':
+			fmt.Fprintf(&sb, "%.2f w %.2f %.2f m %.2f %.2f l S\n", op.width*pointsPerMM,
+				op.x*pointsPerMM, (page.height-op.y)*pointsPerMM, op.x2*pointsPerMM, (page.height-op.y2)*pointsPerMM)
+		case 'r':
+			fmt.Fprintf(&sb, "q %.2f g %.2f %.2f %.2f %.2f re f Q\n", op.shade,
+				op.x*pointsPerMM, (page.height-op.y-op.y2)*pointsPerMM, op.x2*pointsPerMM, op.y2*pointsPerMM)
+		}
+	}
+	return sb.Bytes()
+}
+
+// encodePDFText converts text to the Windows-1252 bytes the standard fonts are
+// drawn with. Characters outside it are replaced.
+func encodePDFText(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		if b, ok := encodePDFRune(r); ok {
+			encoded = append(encoded, b)
+		} else {
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// canEncodePDFText tells whether text prints without any replaced characters
+func canEncodePDFText(text string) bool {
+	for _, r := range text {
+		if _, ok := encodePDFRune(r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func encodePDFRune(r rune) (byte, bool) {
+	switch r {
+	case '\u00a0', '\u2009', '\u202f':
+		// Locales group digits with non-breaking and thin spaces
+		return ' ', true
+	case '\t', '\n', '\r':
+		return ' ', true
+	}
+	return charmap.Windows1252.EncodeRune(r)
+}
+
+func writePDFString(sb *bytes.Buffer, text []byte) {
+	for _, b := range text {
+		switch {
+		case b == '(' || b == ')' || b == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(b)
+		case b < 32 || b > 126:
+			fmt.Fprintf(sb, "\\%03o", b)
+		default:
+			sb.WriteByte(b)
+		}
+	}
+}
+
+// Glyph widths of printable ASCII in the standard fonts, in thousandths of the
+// font size. Other characters are measured as a digit.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// TextWidth measures text in millimetres
+func TextWidth(font PDFFont, size float64, text string) float64 {
+	units := 0
+	for _, b := range encodePDFText(text) {
+		switch {
+		case font == FontMono || font == FontMonoBold:
+			units += 600
+		case b < 32 || b > 126:
+			units += 556
+		case font == FontBold:
+			units += helveticaBoldWidths[b-32]
+		default:
+			units += helveticaWidths[b-32]
+		}
+	}
+	return float64(units) * size / 1000 / pointsPerMM
+}
+
+// WrapText breaks text into lines no wider than width, at spaces where it can.
+// Line breaks in the text are kept.
+func WrapText(font PDFFont, size float64, text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if TextWidth(font, size, candidate) <= width {
+				line = candidate
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// Words longer than a line are cut
+			for TextWidth(font, size, word) > width {
+				cut := len([]rune(word))
+				for cut > 1 && TextWidth(font, size, string([]rune(word)[:cut])) > width {
+					cut--
+				}
+				lines = append(lines, string([]rune(word)[:cut]))
+				word = string([]rune(word)[cut:])
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
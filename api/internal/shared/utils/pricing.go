@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/app/venside/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// Amounts are integers in the currency's minor unit. Percentages are whole
+// numbers between 0 and 100.
+
+// PercentOf returns percent% of amount, rounded half up to the minor unit
+func PercentOf(amount, percent int) int {
+	return (amount*percent + 50) / 100
+}
+
+// ApplyDiscounts takes the percentage discount off amount first and the fixed
+// discount off what remains. The result is negative when the discounts exceed
+// the amount; callers decide how to report that.
+func ApplyDiscounts(amount, percent, fixed int) int {
+	return amount - PercentOf(amount, percent) - fixed
+}
+
+// ShareOf returns the part/whole share of total, rounded half up to the minor
+// unit. whole must be positive.
+func ShareOf(total, part, whole int) int {
+	return (2*total*part + whole) / (2 * whole)
+}
+
+// LinePrice is what a document line is priced from. Subtotal is the one the
+// client sent, if any.
+type LinePrice struct {
+	Quantity        int
+	UnitPrice       int
+	DiscountPercent int
+	DiscountAmount  int
+	Subtotal        *int
+}
+
+// PriceLines prices sale and purchase lines and returns each line's subtotal
+// and their sum. A line is quantity * unit price, less its percentage discount,
+// less its fixed discount. A sent subtotal must match the computed one. Every
+// bad line is reported in one validation error.
+func PriceLines(lines []LinePrice) ([]int, int, error) {
+	var errorMessages []string
+
+	subtotals := make([]int, len(lines))
+	sum := 0
+	for i, line := range lines {
+		subtotals[i] = ApplyDiscounts(line.Quantity*line.UnitPrice, line.DiscountPercent, line.DiscountAmount)
+		if subtotals[i] < 0 {
+			errorMessages = append(errorMessages, fmt.Sprintf("Item %d: discount exceeds the line amount", i+1))
+			continue
+		}
+
+		if line.Subtotal != nil && *line.Subtotal != subtotals[i] {
+			errorMessages = append(errorMessages,
+				fmt.Sprintf("Item %d: subtotal %d does not match the computed %d", i+1, *line.Subtotal, subtotals[i]))
+		}
+
+		sum += subtotals[i]
+	}
+
+	if len(errorMessages) > 0 {
+		return nil, 0, errors.ValidationError(strings.Join(errorMessages, "; "))
+	}
+
+	return subtotals, sum, nil
+}
+
+// PriceTotal takes the order percentage discount and then the order fixed
+// discount off the sum of the lines and adds charges, which are never
+// discounted. A sent total must match the computed one. document names the
+// document in errors.
+func PriceTotal(linesSum, discountPercent, discountAmount, charges int, sent *int, document string) (int, error) {
+	discounted := ApplyDiscounts(linesSum, discountPercent, discountAmount)
+	if discounted < 0 {
+		return 0, errors.ValidationError(fmt.Sprintf("Discount exceeds the %s amount", document))
+	}
+	total := discounted + charges
+
+	if sent != nil && *sent != total {
+		return 0, errors.ValidationError(fmt.Sprintf("Total amount %d does not match the computed %d", *sent, total))
+	}
+
+	return total, nil
+}
+
+// CheckEditedLines makes sure every line ID sent with an edit of a sale or
+// purchase is one of the document's existing lines. Lines without an ID are
+// new. document names the document in errors.
+func CheckEditedLines(existing []uuid.UUID, edited []*string, document string) error {
+	existingIDs := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		existingIDs[id.String()] = true
+	}
+
+	for _, id := range edited {
+		if id != nil && !existingIDs[*id] {
+			return errors.ValidationError(fmt.Sprintf("Item with ID %s not found on the %s", *id, document))
+		}
+	}
+
+	return nil
+}
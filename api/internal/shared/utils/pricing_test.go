@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestPercentOf(t *testing.T) {
+	tests := []struct {
+		amount, percent, want int
+	}{
+		{amount: 1000, percent: 10, want: 100},
+		{amount: 0, percent: 50, want: 0},
+		{amount: 1234, percent: 0, want: 0},
+		{amount: 1234, percent: 100, want: 1234},
+		{amount: 150, percent: 5, want: 8},    // 7.5 rounds up
+		{amount: 149, percent: 5, want: 7},    // 7.45 rounds down
+		{amount: 50, percent: 1, want: 1},     // 0.5 rounds up
+		{amount: 49, percent: 1, want: 0},     // 0.49 rounds down
+		{amount: 999, percent: 33, want: 330}, // 329.67
+	}
+
+	for _, tt := range tests {
+		if got := PercentOf(tt.amount, tt.percent); got != tt.want {
+			t.Errorf("PercentOf(%d, %d) = %d, want %d", tt.amount, tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestApplyDiscounts(t *testing.T) {
+	tests := []struct {
+		name                   string
+		amount, percent, fixed int
+		want                   int
+	}{
+		{name: "no discount", amount: 1000, want: 1000},
+		{name: "percent only", amount: 1000, percent: 15, want: 850},
+		{name: "fixed only", amount: 1000, fixed: 125, want: 875},
+		{name: "percent before fixed", amount: 1000, percent: 10, fixed: 100, want: 800},
+		{name: "percent rounds half up", amount: 150, percent: 5, want: 142},
+		{name: "everything off", amount: 1000, percent: 100, want: 0},
+		{name: "fixed exceeds amount", amount: 1000, percent: 50, fixed: 600, want: -100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyDiscounts(tt.amount, tt.percent, tt.fixed); got != tt.want {
+				t.Errorf("ApplyDiscounts(%d, %d, %d) = %d, want %d", tt.amount, tt.percent, tt.fixed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShareOf(t *testing.T) {
+	tests := []struct {
+		total, part, whole, want int
+	}{
+		{total: 1000, part: 1, whole: 4, want: 250},
+		{total: 1000, part: 1, whole: 3, want: 333}, // 333.33
+		{total: 1000, part: 2, whole: 3, want: 667}, // 666.67
+		{total: 5, part: 1, whole: 2, want: 3},      // 2.5 rounds up
+		{total: 1000, part: 0, whole: 7, want: 0},
+		{total: 1000, part: 7, whole: 7, want: 1000},
+	}
+
+	for _, tt := range tests {
+		if got := ShareOf(tt.total, tt.part, tt.whole); got != tt.want {
+			t.Errorf("ShareOf(%d, %d, %d) = %d, want %d", tt.total, tt.part, tt.whole, got, tt.want)
+		}
+	}
+}
+
+func TestPriceLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		lines         []LinePrice
+		wantSubtotals []int
+		wantSum       int
+		wantErr       string
+	}{
+		{
+			name: "plain lines",
+			lines: []LinePrice{
+				{Quantity: 2, UnitPrice: 450},
+				{Quantity: 1, UnitPrice: 1999},
+			},
+			wantSubtotals: []int{900, 1999},
+			wantSum:       2899,
+		},
+		{
+			name: "line discounts",
+			lines: []LinePrice{
+				{Quantity: 3, UnitPrice: 333, DiscountPercent: 10},                     // 999 - 100
+				{Quantity: 1, UnitPrice: 1000, DiscountAmount: 250},                    // 1000 - 250
+				{Quantity: 4, UnitPrice: 250, DiscountPercent: 5, DiscountAmount: 100}, // 1000 - 50 - 100
+			},
+			wantSubtotals: []int{899, 750, 850},
+			wantSum:       2499,
+		},
+		{
+			name: "matching sent subtotal",
+			lines: []LinePrice{
+				{Quantity: 3, UnitPrice: 333, DiscountPercent: 10, Subtotal: intPtr(899)},
+			},
+			wantSubtotals: []int{899},
+			wantSum:       899,
+		},
+		{
+			name: "mismatched sent subtotal",
+			lines: []LinePrice{
+				{Quantity: 3, UnitPrice: 333, DiscountPercent: 10, Subtotal: intPtr(900)},
+			},
+			wantErr: "Item 1: subtotal 900 does not match the computed 899",
+		},
+		{
+			name: "discount exceeding the line",
+			lines: []LinePrice{
+				{Quantity: 1, UnitPrice: 100},
+				{Quantity: 1, UnitPrice: 100, DiscountAmount: 101},
+			},
+			wantErr: "Item 2: discount exceeds the line amount",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtotals, sum, err := PriceLines(tt.lines)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PriceLines: %v", err)
+			}
+			if !reflect.DeepEqual(subtotals, tt.wantSubtotals) || sum != tt.wantSum {
+				t.Errorf("got %v = %d, want %v = %d", subtotals, sum, tt.wantSubtotals, tt.wantSum)
+			}
+		})
+	}
+}
+
+func TestPriceTotal(t *testing.T) {
+	tests := []struct {
+		name                         string
+		sum, percent, fixed, charges int
+		sent                         *int
+		want                         int
+		wantErr                      string
+	}{
+		{name: "no discount", sum: 2499, want: 2499},
+		{name: "order percent rounds half up", sum: 2499, percent: 10, want: 2249},        // 249.9 off
+		{name: "order percent then fixed", sum: 2499, percent: 10, fixed: 49, want: 2200}, // 2249 - 49
+		{name: "charges are not discounted", sum: 1000, percent: 50, charges: 300, want: 800},
+		{name: "matching sent total", sum: 1000, fixed: 100, sent: intPtr(900), want: 900},
+		{name: "mismatched sent total", sum: 1000, fixed: 100, sent: intPtr(1000), wantErr: "Total amount 1000 does not match the computed 900"},
+		{name: "discount exceeding the lines", sum: 1000, percent: 20, fixed: 801, charges: 500, wantErr: "Discount exceeds the sale amount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PriceTotal(tt.sum, tt.percent, tt.fixed, tt.charges, tt.sent, "sale")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PriceTotal: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PriceTotal = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckEditedLines(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	existing := []uuid.UUID{first, second}
+	firstID, foreignID := first.String(), uuid.NewString()
+
+	tests := []struct {
+		name    string
+		edited  []*string
+		wantErr string
+	}{
+		{name: "existing and new lines", edited: []*string{&firstID, nil}},
+		{name: "no lines", edited: nil},
+		{name: "line from another document", edited: []*string{&firstID, &foreignID},
+			wantErr: "Item with ID " + foreignID + " not found on the purchase"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckEditedLines(existing, tt.edited, "purchase")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckEditedLines: %v", err)
+			}
+		})
+	}
+}
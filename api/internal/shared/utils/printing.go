@@ -0,0 +1,350 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/currency"
+)
+
+// PaperSizes holds the page sizes of printed documents in millimetres
+var PaperSizes = map[string][2]float64{
+	"a4":     {210, 297},
+	"letter": {215.9, 279.4},
+}
+
+// ReceiptWidths holds the roll widths of thermal receipt printers in millimetres
+var ReceiptWidths = map[string]float64{
+	"58mm": 58,
+	"80mm": 80,
+}
+
+// PrintedColumn is a column of a printed table. Width is its share of the
+// table width; all shares of a table add up to 1.
+type PrintedColumn struct {
+	Header string
+	Width  float64
+	Right  bool
+}
+
+// PrintedTable is a titled table printed under the main one, e.g. payments
+type PrintedTable struct {
+	Title   string
+	Columns []PrintedColumn
+	Rows    [][]string
+}
+
+type PrintedTotal struct {
+	Label string
+	Value string
+	Bold  bool
+}
+
+// PrintedDocument is the content of a full-page business document such as an
+// invoice or a purchase order, already formatted as text
+type PrintedDocument struct {
+	PaperSize   string
+	Title       string
+	Issuer      string
+	IssuerLines []string
+	Details     [][2]string
+	PartyTitle  string
+	PartyLines  []string
+	Items       PrintedTable
+	Totals      []PrintedTotal
+	Tables      []PrintedTable
+	Footer      string
+}
+
+const (
+	docMargin     = 15.0
+	docFontSize   = 9.0
+	docLineHeight = 4.6
+	docRowPadding = 1.6
+)
+
+// RenderDocumentPDF lays out a document on as many pages as it needs. The
+// items table repeats its header on every page and pages are numbered.
+func RenderDocumentPDF(doc PrintedDocument) []byte {
+	size, ok := PaperSizes[doc.PaperSize]
+	if !ok {
+		size = PaperSizes["a4"]
+	}
+	width, height := size[0], size[1]
+	right := width - docMargin
+	bottom := height - docMargin - 8
+
+	pdf := NewPDF()
+	pdf.AddPage(width, height)
+
+	// Issuer on the left, title and document details on the right
+	y := docMargin + 6
+	pdf.Text(docMargin, y, FontBold, 16, doc.Issuer)
+	pdf.TextRight(right, y, FontBold, 18, strings.ToUpper(doc.Title))
+
+	left := y + 2
+	for _, line := range doc.IssuerLines {
+		for _, wrapped := range WrapText(FontRegular, docFontSize, line, (width-2*docMargin)/2) {
+			left += docLineHeight
+			pdf.Text(docMargin, left, FontRegular, docFontSize, wrapped)
+		}
+	}
+
+	details := y + 4
+	for _, detail := range doc.Details {
+		details += docLineHeight
+		pdf.TextRight(right-38, details, FontBold, docFontSize, detail[0])
+		pdf.TextRight(right, details, FontRegular, docFontSize, detail[1])
+	}
+
+	y = max(left, details) + 10
+
+	if len(doc.PartyLines) > 0 {
+		pdf.Text(docMargin, y, FontBold, docFontSize, strings.ToUpper(doc.PartyTitle))
+		for _, line := range doc.PartyLines {
+			for _, wrapped := range WrapText(FontRegular, docFontSize, line, (width-2*docMargin)/2) {
+				y += docLineHeight
+				pdf.Text(docMargin, y, FontRegular, docFontSize, wrapped)
+			}
+		}
+		y += 8
+	}
+
+	newPage := func() float64 {
+		pdf.AddPage(width, height)
+		y = docMargin + 6
+		return y
+	}
+
+	y = drawPrintedTable(pdf, doc.Items, y, width, bottom, newPage)
+
+	// Totals sit under the amount columns
+	y += 4
+	if y+float64(len(doc.Totals))*docLineHeight > bottom {
+		newPage()
+	}
+	for _, total := range doc.Totals {
+		y += docLineHeight
+		font := FontRegular
+		if total.Bold {
+			font = FontBold
+			pdf.Line(right-70, y-docLineHeight+1, right, y-docLineHeight+1, 0.2)
+		}
+		pdf.TextRight(right-38, y, font, docFontSize, total.Label)
+		pdf.TextRight(right, y, font, docFontSize, total.Value)
+	}
+
+	for _, table := range doc.Tables {
+		if len(table.Rows) == 0 {
+			continue
+		}
+
+		y += 10
+		if y+3*docLineHeight > bottom {
+			newPage()
+		}
+		pdf.Text(docMargin, y, FontBold, docFontSize+1, table.Title)
+		y = drawPrintedTable(pdf, table, y+2, width, bottom, newPage)
+	}
+
+	if doc.Footer != "" {
+		lines := WrapText(FontRegular, docFontSize-1, doc.Footer, width-2*docMargin)
+		if y+10+float64(len(lines))*docLineHeight > bottom {
+			newPage()
+		}
+
+		y = bottom - float64(len(lines)-1)*docLineHeight
+		for _, line := range lines {
+			pdf.Text(docMargin, y, FontRegular, docFontSize-1, line)
+			y += docLineHeight
+		}
+	}
+
+	pages := pdf.PageCount()
+	for page := 1; page <= pages; page++ {
+		pdf.SetPage(page)
+		pdf.Line(docMargin, height-docMargin-4, right, height-docMargin-4, 0.2)
+		pdf.TextRight(right, height-docMargin, FontRegular, docFontSize-2, fmt.Sprintf("Page %d of %d", page, pages))
+	}
+
+	return pdf.Bytes()
+}
+
+// drawPrintedTable draws a table from y, moving to a new page whenever a row
+// would run past bottom, and returns where the table ends
+func drawPrintedTable(pdf *PDF, table PrintedTable, y, width, bottom float64, newPage func() float64) float64 {
+	tableWidth := width - 2*docMargin
+	starts := make([]float64, len(table.Columns))
+	x := docMargin
+	for i, column := range table.Columns {
+		starts[i] = x
+		x += column.Width * tableWidth
+	}
+
+	cell := func(i int, row float64, font PDFFont, text string) {
+		column := table.Columns[i]
+		if column.Right {
+			pdf.TextRight(starts[i]+column.Width*tableWidth-docRowPadding, row, font, docFontSize, text)
+		} else {
+			pdf.Text(starts[i]+docRowPadding, row, font, docFontSize, text)
+		}
+	}
+
+	header := func() {
+		pdf.FillRect(docMargin, y, tableWidth, docLineHeight+2*docRowPadding, 0.9)
+		y += docLineHeight + docRowPadding - 1
+		for i, column := range table.Columns {
+			cell(i, y, FontBold, column.Header)
+		}
+		y += docRowPadding + 1
+	}
+
+	header()
+	for _, row := range table.Rows {
+		// Cells wrap inside their column; the row is as tall as its tallest cell
+		wrapped := make([][]string, len(table.Columns))
+		lines := 1
+		for i := range table.Columns {
+			text := ""
+			if i < len(row) {
+				text = row[i]
+			}
+			wrapped[i] = WrapText(FontRegular, docFontSize, text, table.Columns[i].Width*tableWidth-2*docRowPadding)
+			lines = max(lines, len(wrapped[i]))
+		}
+
+		rowHeight := float64(lines)*docLineHeight + 2*docRowPadding
+		if y+rowHeight > bottom {
+			y = newPage()
+			header()
+		}
+
+		for i := range table.Columns {
+			for j, line := range wrapped[i] {
+				cell(i, y+docRowPadding+float64(j+1)*docLineHeight-1, FontRegular, line)
+			}
+		}
+		y += rowHeight
+		pdf.Line(docMargin, y, width-docMargin, y, 0.1)
+	}
+
+	return y
+}
+
+// TextLines splits configured text, such as a template header, into its
+// non-empty lines
+func TextLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// PrintedMoney formats an amount like FormatMoney for a printed document. The
+// standard PDF fonts lack symbols such as ₦ or ₹, so those currencies are
+// printed with their ISO code instead, e.g. "INR 1,234.56".
+func PrintedMoney(amount int, code, locale string) string {
+	if text := FormatMoney(amount, code, locale); canEncodePDFText(text) {
+		return text
+	}
+	return formatMoney(amount, code, locale, currency.ISO)
+}
+
+// DiscountText describes a line discount, e.g. "10% + $ 1.00"
+func DiscountText(percent, amount int, money func(int) string) string {
+	var parts []string
+	if percent > 0 {
+		parts = append(parts, fmt.Sprintf("%d%%", percent))
+	}
+	if amount > 0 {
+		parts = append(parts, money(amount))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// Humanize turns a stored value such as "mobile_money" into "Mobile money"
+func Humanize(value string) string {
+	value = strings.ReplaceAll(value, "_", " ")
+	if value == "" {
+		return value
+	}
+	return strings.ToUpper(value[:1]) + value[1:]
+}
+
+// ReceiptLine is one line of a thermal receipt. Right is printed flush right
+// on the last line of Left. Rule draws a dashed separator instead of text.
+type ReceiptLine struct {
+	Left   string
+	Right  string
+	Bold   bool
+	Center bool
+	Rule   bool
+}
+
+const (
+	receiptMargin     = 3.0
+	receiptFontSize   = 8.0
+	receiptLineHeight = 3.6
+)
+
+// RenderReceiptPDF prints lines in a monospaced font on a single page as long
+// as the receipt, the way a roll printer does
+func RenderReceiptPDF(paper string, lines []ReceiptLine) []byte {
+	width, ok := ReceiptWidths[paper]
+	if !ok {
+		width = ReceiptWidths["80mm"]
+	}
+
+	usable := width - 2*receiptMargin
+	columns := int(usable / TextWidth(FontMono, receiptFontSize, "0"))
+
+	pdf := NewPDF()
+	pdf.AddPage(width, 0)
+
+	y := receiptMargin + 2
+	for _, line := range lines {
+		font := FontMono
+		if line.Bold {
+			font = FontMonoBold
+		}
+
+		if line.Rule {
+			y += receiptLineHeight
+			pdf.Text(receiptMargin, y, font, receiptFontSize, strings.Repeat("-", columns))
+			continue
+		}
+
+		// Leave room for the right text on the last line and keep the indent
+		room := columns
+		if line.Right != "" {
+			room = columns - len([]rune(line.Right)) - 1
+		}
+		text := strings.TrimLeft(line.Left, " ")
+		indent := line.Left[:len(line.Left)-len(text)]
+		room -= len(indent)
+
+		wrapped := WrapText(FontMono, receiptFontSize, text, float64(max(room, 1))*TextWidth(FontMono, receiptFontSize, "0"))
+		for i := range wrapped {
+			wrapped[i] = indent + wrapped[i]
+		}
+
+		for i, text := range wrapped {
+			y += receiptLineHeight
+			switch {
+			case line.Center:
+				pdf.TextCenter(width/2, y, font, receiptFontSize, text)
+			default:
+				pdf.Text(receiptMargin, y, font, receiptFontSize, text)
+			}
+			if i == len(wrapped)-1 && line.Right != "" {
+				pdf.TextRight(width-receiptMargin, y, font, receiptFontSize, line.Right)
+			}
+		}
+	}
+
+	pdf.SetPageHeight(y + receiptMargin + 4)
+	return pdf.Bytes()
+}
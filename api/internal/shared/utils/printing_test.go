@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+func TestPrintedMoney(t *testing.T) {
+	tests := []struct {
+		amount       int
+		code, locale string
+		want         string
+	}{
+		{amount: 123456, code: "USD", locale: "en-US", want: "$ 1,234.56"},
+		{amount: 123456, code: "EUR", locale: "en-US", want: "€ 1,234.56"},
+		{amount: 123456, code: "INR", locale: "en-IN", want: "INR 1,234.56"},
+		{amount: 1234, code: "KRW", locale: "ko-KR", want: "KRW 1,234"},
+		{amount: 1234, code: "JPY", locale: "en-US", want: "¥ 1,234"},
+	}
+
+	for _, tt := range tests {
+		got := PrintedMoney(tt.amount, tt.code, tt.locale)
+		if got != tt.want {
+			t.Errorf("PrintedMoney(%d, %q, %q) = %q, want %q", tt.amount, tt.code, tt.locale, got, tt.want)
+		}
+		if !canEncodePDFText(got) {
+			t.Errorf("PrintedMoney(%d, %q, %q) = %q cannot be printed", tt.amount, tt.code, tt.locale, got)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/app/venside/pkg/errors"
+)
+
+// ReadSpreadsheet returns the rows of an uploaded .csv or .xlsx file as text
+// cells. For workbooks only the first sheet is read. Trailing empty rows are
+// dropped so the row count matches what the user sees. Files with more than
+// maxRows rows, header included, are rejected while reading.
+func ReadSpreadsheet(file *multipart.FileHeader, maxRows int) ([][]string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.ValidationError("Failed to open uploaded file")
+	}
+	defer src.Close()
+
+	var rows [][]string
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".csv":
+		rows, err = readCSV(src, maxRows)
+		if err != nil {
+			return nil, errors.ValidationError("Invalid CSV file: " + err.Error())
+		}
+	case ".xlsx":
+		rows, err = readXLSX(src, file.Size, maxRows)
+		if err != nil {
+			return nil, errors.ValidationError("Invalid XLSX file: " + err.Error())
+		}
+	default:
+		return nil, errors.ValidationError("Unsupported file type. Upload a .csv or .xlsx file")
+	}
+
+	for len(rows) > 0 && isBlankRow(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+
+	return rows, nil
+}
+
+func readCSV(src io.Reader, maxRows int) ([][]string, error) {
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == maxRows {
+			return nil, fmt.Errorf("more than %d rows", maxRows)
+		}
+		rows = append(rows, record)
+	}
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// XLSX is a zip of XML parts. Only what is needed to read cell text is modelled.
+// Everything in an uploaded workbook is untrusted, so row and column references
+// and the decompressed size of each part are bounded before anything is
+// allocated from them.
+
+const (
+	// maxXLSXColumns is the widest sheet Excel allows, up to column XFD
+	maxXLSXColumns = 16384
+
+	// maxXLSXPartSize caps how much of one part is decompressed, so a small zip
+	// cannot expand into gigabytes of XML
+	maxXLSXPartSize = 32 << 20
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var sb strings.Builder
+	for _, run := range t.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Index int        `xml:"r,attr"`
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+func readXLSX(src io.ReaderAt, size int64, maxRows int) ([][]string, error) {
+	archive, err := zip.NewReader(src, size)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(parts, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(parts, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RelID {
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPath = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPath = path.Join("xl", rel.Target)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, fmt.Errorf("first sheet not found")
+	}
+
+	var shared xlsxSharedStrings
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(parts, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := decodeXLSXPart(parts, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, row := range sheet.Rows {
+		// Rows and cells may be sparse; their references say where they belong
+		rowIndex := i
+		if row.Index > 0 {
+			rowIndex = row.Index - 1
+		}
+		if rowIndex >= maxRows {
+			// Formatting alone can leave empty rows far below the data
+			if xlsxRowIsEmpty(row.Cells) {
+				continue
+			}
+			return nil, fmt.Errorf("more than %d rows", maxRows)
+		}
+		for len(rows) <= rowIndex {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for j, cell := range row.Cells {
+			column := j
+			if cell.Ref != "" {
+				column, err = xlsxColumnIndex(cell.Ref)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if column >= maxXLSXColumns {
+				return nil, fmt.Errorf("more than %d columns", maxXLSXColumns)
+			}
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("invalid shared string reference in %s", cell.Ref)
+				}
+				cells[column] = shared.Items[index].String()
+			case "inlineStr":
+				cells[column] = cell.Inline.String()
+			default:
+				cells[column] = cell.Value
+			}
+		}
+		rows[rowIndex] = cells
+	}
+
+	return rows, nil
+}
+
+func xlsxRowIsEmpty(cells []xlsxCell) bool {
+	for _, cell := range cells {
+		if cell.Value != "" || cell.Inline.String() != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeXLSXPart(parts map[string]*zip.File, name string, dest interface{}) error {
+	part, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("missing %s", name)
+	}
+
+	rc, err := part.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	limited := &io.LimitedReader{R: rc, N: maxXLSXPartSize + 1}
+	err = xml.NewDecoder(limited).Decode(dest)
+	if limited.N <= 0 {
+		return fmt.Errorf("%s is larger than %d MB", name, maxXLSXPartSize>>20)
+	}
+
+	return err
+}
+
+// xlsxColumnIndex turns the letters of a cell reference into a zero-based
+// column, e.g. "C7" into 2 and "AA1" into 26
+func xlsxColumnIndex(ref string) (int, error) {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A') + 1
+		if column > maxXLSXColumns {
+			return 0, fmt.Errorf("cell reference %s is past the last column", ref)
+		}
+	}
+	if column == 0 {
+		return 0, fmt.Errorf("malformed cell reference %q", ref)
+	}
+	return column - 1, nil
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildXLSX zips a minimal workbook whose first sheet holds sheetData
+func buildXLSX(t *testing.T, sheetData string) *bytes.Reader {
+	t.Helper()
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst><si><t>Name</t></si><si><r><t>Wid</t></r><r><t>get</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData>` + sheetData + `</sheetData></worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadXLSX(t *testing.T) {
+	sheet := `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="inlineStr"><is><t>Price</t></is></c></row>` +
+		`<row r="3"><c r="A3" t="s"><v>1</v></c><c r="C3"><v>12.5</v></c></row>`
+
+	src := buildXLSX(t, sheet)
+	rows, err := readXLSX(src, src.Size(), 10)
+	if err != nil {
+		t.Fatalf("readXLSX: %v", err)
+	}
+
+	want := [][]string{
+		{"Name", "", "Price"},
+		nil,
+		{"Widget", "", "12.5"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestReadXLSXRejectsOutOfBoundsReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		sheet   string
+		wantErr string
+	}{
+		{
+			name:    "row past the limit",
+			sheet:   `<row r="100000000"><c r="A100000000"><v>1</v></c></row>`,
+			wantErr: "more than 10 rows",
+		},
+		{
+			name:    "too many rows without references",
+			sheet:   strings.Repeat(`<row><c><v>1</v></c></row>`, 11),
+			wantErr: "more than 10 rows",
+		},
+		{
+			name:    "column past XFD",
+			sheet:   `<row r="1"><c r="ZZZZZZ1"><v>1</v></c></row>`,
+			wantErr: "past the last column",
+		},
+		{
+			name:    "reference without letters",
+			sheet:   `<row r="1"><c r="12"><v>1</v></c></row>`,
+			wantErr: "malformed cell reference",
+		},
+		{
+			name:    "part larger than the cap",
+			sheet:   `<row r="1"><c r="A1"><v>` + strings.Repeat("9", maxXLSXPartSize) + `</v></c></row>`,
+			wantErr: "larger than 32 MB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := buildXLSX(t, tt.sheet)
+			_, err := readXLSX(src, src.Size(), 10)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadXLSXSkipsEmptyRowsPastTheLimit(t *testing.T) {
+	sheet := `<row r="1"><c r="A1"><v>1</v></c></row><row r="1048576"><c r="A1048576"/></row>`
+
+	src := buildXLSX(t, sheet)
+	rows, err := readXLSX(src, src.Size(), 10)
+	if err != nil {
+		t.Fatalf("readXLSX: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("rows = %d, want 1", len(rows))
+	}
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer writes every message as an .eml file instead of sending it, so
+// what would have gone out can be opened in any mail client
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	if message.From == "" {
+		message.From = m.from
+	}
+
+	data, err := message.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), uuid.NewString()[:8])
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	return nil
+}
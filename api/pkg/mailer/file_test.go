@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerSendsAttachment(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFileMailer(dir, "Venside <billing@venside.test>")
+
+	// Long enough to be split over several encoded lines
+	pdf := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte{0x00, 0xff, 0x10}, 100)...)
+	message := Message{
+		To:      []string{"alice@example.com", "bob@example.com"},
+		Cc:      []string{"accounts@example.com"},
+		ReplyTo: "sales@venside.test",
+		Subject: "Invoice S-0001 – Venside",
+		Body:    "Hello,\nyour invoice is attached.",
+		Attachments: []Attachment{
+			{Filename: "S-0001.pdf", ContentType: "application/pdf", Data: pdf},
+		},
+	}
+	if err := m.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("got %v (%v), want one .eml file", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("reading email: %v", err)
+	}
+
+	written, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing email: %v", err)
+	}
+
+	headers := map[string]string{
+		"From":     "Venside <billing@venside.test>",
+		"To":       "alice@example.com, bob@example.com",
+		"Cc":       "accounts@example.com",
+		"Reply-To": "sales@venside.test",
+	}
+	for name, want := range headers {
+		if got := written.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(written.Header.Get("Subject"))
+	if err != nil || subject != message.Subject {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, message.Subject)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(written.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q (%v), want multipart/mixed", mediaType, err)
+	}
+	reader := multipart.NewReader(written.Body, params["boundary"])
+
+	body, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("reading body part: %v", err)
+	}
+	text, _ := io.ReadAll(body)
+	if string(text) != "Hello,\r\nyour invoice is attached." {
+		t.Errorf("body = %q", text)
+	}
+
+	attachment, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("reading attachment part: %v", err)
+	}
+	if got := attachment.Header.Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("attachment Content-Type = %q, want application/pdf", got)
+	}
+	if attachment.FileName() != "S-0001.pdf" {
+		t.Errorf("attachment filename = %q, want S-0001.pdf", attachment.FileName())
+	}
+	encoded, _ := io.ReadAll(attachment)
+	for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("encoded line of %d characters, want at most 76", len(line))
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil || !bytes.Equal(decoded, pdf) {
+		t.Errorf("attachment does not decode to the PDF sent (%v)", err)
+	}
+
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("want no parts after the attachment, got %v", err)
+	}
+}
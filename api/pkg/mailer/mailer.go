@@ -0,0 +1,144 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mailer delivers outgoing email. Implementations are picked from the
+// configuration so documents can be sent through SMTP in production and
+// captured on disk or by an SMTP catcher in development and tests.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+type Message struct {
+	From        string
+	To          []string
+	Cc          []string
+	ReplyTo     string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+type Config struct {
+	Driver   string // smtp or file
+	From     string
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Dir      string
+}
+
+// New returns the mailer the configuration asks for. Without a driver, an
+// SMTP host selects SMTP and anything else writes messages to disk.
+func New(cfg Config) (Mailer, error) {
+	driver := cfg.Driver
+	if driver == "" {
+		driver = "file"
+		if cfg.Host != "" {
+			driver = "smtp"
+		}
+	}
+
+	switch driver {
+	case "smtp":
+		if cfg.Host == "" {
+			return nil, fmt.Errorf("smtp mailer needs a host")
+		}
+		return NewSMTPMailer(cfg), nil
+	case "file":
+		dir := cfg.Dir
+		if dir == "" {
+			dir = "mail"
+		}
+		return NewFileMailer(dir, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", driver)
+	}
+}
+
+// recipients lists every address the message is delivered to
+func (m Message) recipients() []string {
+	return append(append([]string{}, m.To...), m.Cc...)
+}
+
+// Bytes renders the message in MIME format: a text body followed by its
+// attachments, base64 encoded
+func (m Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + m.From,
+		"To: " + strings.Join(m.To, ", "),
+	}
+	if len(m.Cc) > 0 {
+		headers = append(headers, "Cc: "+strings.Join(m.Cc, ", "))
+	}
+	if m.ReplyTo != "" {
+		headers = append(headers, "Reply-To: "+m.ReplyTo)
+	}
+	headers = append(headers,
+		"Subject: "+mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date: "+time.Now().Format(time.RFC1123Z),
+		"Message-ID: <"+uuid.NewString()+"@venside>",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary="+writer.Boundary(),
+	)
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	body, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := body.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range m.Attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Encoded lines are kept under the 78 characters mail allows
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for start := 0; start < len(encoded); start += 76 {
+			end := min(start+76, len(encoded))
+			if _, err := part.Write([]byte(encoded[start:end] + "\r\n")); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+)
+
+// SMTPMailer sends through an SMTP server. Without credentials it sends
+// unauthenticated, which is how SMTP catchers such as Mailpit are used.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(cfg Config) *SMTPMailer {
+	port := cfg.Port
+	if port == "" {
+		port = "587"
+	}
+
+	m := &SMTPMailer{addr: net.JoinHostPort(cfg.Host, port), from: cfg.From}
+	if cfg.Username != "" {
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	if message.From == "" {
+		message.From = m.from
+	}
+
+	data, err := message.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	// net/smtp has no context support; run it aside so a cancelled request
+	// does not wait on a slow server
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, m.from, message.recipients(), data)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
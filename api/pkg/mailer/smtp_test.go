@@ -0,0 +1,116 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// smtpCatcher is a minimal SMTP server that records one delivery
+type smtpCatcher struct {
+	listener   net.Listener
+	from       string
+	recipients []string
+	data       string
+	done       chan struct{}
+}
+
+func newSMTPCatcher(t *testing.T) *smtpCatcher {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	c := &smtpCatcher{listener: listener, done: make(chan struct{})}
+	go c.serve()
+	return c
+}
+
+func (c *smtpCatcher) serve() {
+	defer close(c.done)
+
+	conn, err := c.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 catcher ready")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			text.PrintfLine("250 catcher")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			c.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			text.PrintfLine("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			c.recipients = append(c.recipients, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			text.PrintfLine("250 OK")
+		case command == "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			c.data = string(data)
+			text.PrintfLine("250 OK")
+		case command == "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	catcher := newSMTPCatcher(t)
+	host, port, _ := net.SplitHostPort(catcher.listener.Addr().String())
+	m := NewSMTPMailer(Config{Host: host, Port: port, From: "billing@venside.test"})
+
+	message := Message{
+		To:      []string{"alice@example.com"},
+		Cc:      []string{"accounts@example.com"},
+		Subject: "Invoice S-0001",
+		Body:    "Your invoice is attached.",
+		Attachments: []Attachment{
+			{Filename: "S-0001.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4\n")},
+		},
+	}
+	if err := m.Send(context.Background(), message); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	<-catcher.done
+
+	if catcher.from != "billing@venside.test" {
+		t.Errorf("MAIL FROM = %q, want billing@venside.test", catcher.from)
+	}
+	if want := []string{"alice@example.com", "accounts@example.com"}; !reflect.DeepEqual(catcher.recipients, want) {
+		t.Errorf("recipients = %v, want %v", catcher.recipients, want)
+	}
+
+	header, err := textproto.NewReader(bufio.NewReader(strings.NewReader(catcher.data))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("parsing delivered headers: %v", err)
+	}
+	if got := header.Get("From"); got != "billing@venside.test" {
+		t.Errorf("From = %q, want the configured sender", got)
+	}
+	if !strings.Contains(catcher.data, `filename=S-0001.pdf`) {
+		t.Errorf("delivered message has no S-0001.pdf attachment:\n%s", catcher.data)
+	}
+}